@@ -1,15 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
+	"strings"
 
 	"git.containerum.net/ch/permissions/pkg/clients"
 	"git.containerum.net/ch/permissions/pkg/database"
 	"git.containerum.net/ch/permissions/pkg/database/postgres"
+	"git.containerum.net/ch/permissions/pkg/model"
 	"git.containerum.net/ch/permissions/pkg/server"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/locales/en"
 	"github.com/go-playground/locales/en_US"
@@ -149,6 +153,36 @@ func SetupSolutionsClient(addr string) (clients.SolutionsClient, error) {
 	}
 }
 
+func setupEventSinks(webhookURL, file string) ([]clients.EventSink, error) {
+	var sinks []clients.EventSink
+
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid events webhook url: %v", err)
+		}
+		sinks = append(sinks, clients.NewEventWebhookSink(u))
+	}
+
+	if file != "" {
+		sink, err := clients.NewEventFileSink(file)
+		if err != nil {
+			return nil, fmt.Errorf("events file sink setup failed: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+func setupWebhookClient() clients.WebhookClient {
+	return clients.NewWebhookHTTPClient()
+}
+
+func setupCleanupHookClient() clients.CleanupHookClient {
+	return clients.NewCleanupHookHTTPClient()
+}
+
 func setupServiceClients(ctx *cli.Context) (*server.Clients, error) {
 	var errs []error
 	var clients server.Clients
@@ -182,10 +216,21 @@ func setupServiceClients(ctx *cli.Context) (*server.Clients, error) {
 		errs = append(errs, err)
 	}
 
+	if clients.EventSinks, err = setupEventSinks(ctx.String(EventsWebhookURLFlag.Name), ctx.String(EventsFileFlag.Name)); err != nil {
+		errs = append(errs, err)
+	}
+
+	clients.Webhook = setupWebhookClient()
+	clients.CleanupHook = setupCleanupHookClient()
+
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("clients setup errors: %v", errs)
 	}
 
+	for _, sink := range clients.EventSinks {
+		logrus.Infof("%s", sink)
+	}
+
 	v := reflect.ValueOf(clients)
 	for i := 0; i < reflect.TypeOf(clients).NumField(); i++ {
 		f := v.Field(i)
@@ -196,3 +241,109 @@ func setupServiceClients(ctx *cli.Context) (*server.Clients, error) {
 
 	return &clients, nil
 }
+
+func setupServerConfig(ctx *cli.Context) (server.Config, error) {
+	var cfg server.Config
+
+	cfg.TransferOldOwnerAccess = kubeClientModel.AccessLevel(ctx.String(TransferOldOwnerAccessFlag.Name))
+	switch cfg.TransferOldOwnerAccess {
+	case kubeClientModel.Write, kubeClientModel.ReadDelete, kubeClientModel.Read, kubeClientModel.None:
+	default:
+		return server.Config{}, fmt.Errorf("invalid access level for old owner: %s", cfg.TransferOldOwnerAccess)
+	}
+
+	cfg.NamespaceRetention = ctx.Duration(NamespaceRetentionFlag.Name)
+	cfg.TombstonesPurgeInterval = ctx.Duration(TombstonesPurgeIntervalFlag.Name)
+	cfg.ExpiredAccessesSweepInterval = ctx.Duration(ExpiredAccessesSweepIntervalFlag.Name)
+	cfg.GroupsReconcileInterval = ctx.Duration(GroupsReconcileIntervalFlag.Name)
+
+	groupRoleMapping, err := parseGroupRoleMapping(ctx.String(GroupRoleMappingFlag.Name))
+	if err != nil {
+		return server.Config{}, err
+	}
+	cfg.GroupRoleMapping = groupRoleMapping
+
+	cfg.UserLimits = model.ResourcesLimits{
+		Namespaces:     ctx.Int(UserMaxNamespacesFlag.Name),
+		CPU:            ctx.Int(UserMaxCPUFlag.Name),
+		RAM:            ctx.Int(UserMaxRAMFlag.Name),
+		MaxExtServices: ctx.Int(UserMaxExtServicesFlag.Name),
+		MaxIntServices: ctx.Int(UserMaxIntServicesFlag.Name),
+	}
+
+	cfg.EventsDispatchInterval = ctx.Duration(EventsDispatchIntervalFlag.Name)
+	cfg.EventsBatchSize = ctx.Int(EventsBatchSizeFlag.Name)
+	if cfg.EventsBatchSize <= 0 {
+		return server.Config{}, fmt.Errorf("invalid events batch size: %d", cfg.EventsBatchSize)
+	}
+	cfg.EventsRetention = ctx.Duration(EventsRetentionFlag.Name)
+	cfg.EventsMaxAttempts = ctx.Int(EventsMaxAttemptsFlag.Name)
+	if cfg.EventsMaxAttempts <= 0 {
+		return server.Config{}, fmt.Errorf("invalid events max attempts: %d", cfg.EventsMaxAttempts)
+	}
+	cfg.EventsRetryBackoff = ctx.Duration(EventsRetryBackoffFlag.Name)
+
+	cfg.WebhooksDeliveryInterval = ctx.Duration(WebhooksDeliveryIntervalFlag.Name)
+	cfg.WebhookMaxAttempts = ctx.Int(WebhookMaxAttemptsFlag.Name)
+	if cfg.WebhookMaxAttempts <= 0 {
+		return server.Config{}, fmt.Errorf("invalid webhook max attempts: %d", cfg.WebhookMaxAttempts)
+	}
+	cfg.WebhookRetryBackoff = ctx.Duration(WebhookRetryBackoffFlag.Name)
+
+	cfg.OperationsRecoveryInterval = ctx.Duration(OperationsRecoveryIntervalFlag.Name)
+	cfg.OperationsLeaseTimeout = ctx.Duration(OperationsLeaseTimeoutFlag.Name)
+	if cfg.OperationsLeaseTimeout <= 0 {
+		return server.Config{}, fmt.Errorf("invalid operations lease timeout: %s", cfg.OperationsLeaseTimeout)
+	}
+	cfg.OperationsRetention = ctx.Duration(OperationsRetentionFlag.Name)
+
+	cfg.CleanupStepAttempts = ctx.Int(CleanupStepAttemptsFlag.Name)
+	if cfg.CleanupStepAttempts <= 0 {
+		return server.Config{}, fmt.Errorf("invalid cleanup step attempts: %d", cfg.CleanupStepAttempts)
+	}
+	cfg.CleanupRetryBackoff = ctx.Duration(CleanupRetryBackoffFlag.Name)
+	cfg.CleanupMaxRuns = ctx.Int(CleanupMaxRunsFlag.Name)
+	if cfg.CleanupMaxRuns <= 0 {
+		return server.Config{}, fmt.Errorf("invalid cleanup max runs: %d", cfg.CleanupMaxRuns)
+	}
+
+	return cfg, nil
+}
+
+// parseGroupRoleMapping parses mapping in format "role1=access1,role2=access2"
+func parseGroupRoleMapping(str string) (model.GroupRoleMapping, error) {
+	mapping := make(model.GroupRoleMapping)
+	for _, pair := range strings.Split(str, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid group role mapping entry: %s", pair)
+		}
+
+		mapping[kubeClientModel.UserGroupAccess(strings.TrimSpace(kv[0]))] = kubeClientModel.AccessLevel(strings.TrimSpace(kv[1]))
+	}
+
+	if !mapping.IsValid() {
+		return nil, fmt.Errorf("invalid group role mapping: %s", str)
+	}
+
+	return mapping, nil
+}
+
+// parseCleanupHooks parses JSON array of HTTP cleanup hooks, e.g. [{"name":"mail","url":"http://mail/cleanup","depends_on":["kube"]}]
+func parseCleanupHooks(str string) ([]server.HTTPCleanupHookConfig, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, nil
+	}
+
+	var hooks []server.HTTPCleanupHookConfig
+	if err := json.Unmarshal([]byte(str), &hooks); err != nil {
+		return nil, fmt.Errorf("invalid cleanup hooks: %v", err)
+	}
+
+	return hooks, nil
+}
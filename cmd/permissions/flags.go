@@ -1,6 +1,9 @@
 package main
 
 import (
+	"time"
+
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/urfave/cli.v2"
 )
@@ -91,4 +94,163 @@ var (
 	CORSFlag = cli.BoolFlag{
 		Name: "cors",
 	}
+
+	TransferOldOwnerAccessFlag = cli.StringFlag{
+		Name:    "transfer_old_owner_access",
+		EnvVars: []string{"TRANSFER_OLD_OWNER_ACCESS"},
+		Value:   string(kubeClientModel.Write),
+	}
+
+	NamespaceRetentionFlag = cli.DurationFlag{
+		Name:    "namespace_retention",
+		EnvVars: []string{"NAMESPACE_RETENTION"},
+		Value:   30 * 24 * time.Hour,
+	}
+
+	TombstonesPurgeIntervalFlag = cli.DurationFlag{
+		Name:    "tombstones_purge_interval",
+		EnvVars: []string{"TOMBSTONES_PURGE_INTERVAL"},
+		Value:   time.Hour,
+	}
+
+	ExpiredAccessesSweepIntervalFlag = cli.DurationFlag{
+		Name:    "expired_accesses_sweep_interval",
+		EnvVars: []string{"EXPIRED_ACCESSES_SWEEP_INTERVAL"},
+		Value:   time.Minute,
+	}
+
+	GroupsReconcileIntervalFlag = cli.DurationFlag{
+		Name:    "groups_reconcile_interval",
+		EnvVars: []string{"GROUPS_RECONCILE_INTERVAL"},
+		Value:   10 * time.Minute,
+	}
+
+	GroupRoleMappingFlag = cli.StringFlag{
+		Name:    "group_role_mapping",
+		EnvVars: []string{"GROUP_ROLE_MAPPING"},
+	}
+
+	UserMaxNamespacesFlag = cli.IntFlag{
+		Name:    "user_max_namespaces",
+		EnvVars: []string{"USER_MAX_NAMESPACES"},
+	}
+
+	UserMaxCPUFlag = cli.IntFlag{
+		Name:    "user_max_cpu",
+		EnvVars: []string{"USER_MAX_CPU"},
+	}
+
+	UserMaxRAMFlag = cli.IntFlag{
+		Name:    "user_max_ram",
+		EnvVars: []string{"USER_MAX_RAM"},
+	}
+
+	UserMaxExtServicesFlag = cli.IntFlag{
+		Name:    "user_max_ext_services",
+		EnvVars: []string{"USER_MAX_EXT_SERVICES"},
+	}
+
+	UserMaxIntServicesFlag = cli.IntFlag{
+		Name:    "user_max_int_services",
+		EnvVars: []string{"USER_MAX_INT_SERVICES"},
+	}
+
+	EventsWebhookURLFlag = cli.StringFlag{
+		Name:    "events_webhook_url",
+		EnvVars: []string{"EVENTS_WEBHOOK_URL"},
+	}
+
+	EventsFileFlag = cli.StringFlag{
+		Name:    "events_file",
+		EnvVars: []string{"EVENTS_FILE"},
+	}
+
+	EventsDispatchIntervalFlag = cli.DurationFlag{
+		Name:    "events_dispatch_interval",
+		EnvVars: []string{"EVENTS_DISPATCH_INTERVAL"},
+		Value:   5 * time.Second,
+	}
+
+	EventsBatchSizeFlag = cli.IntFlag{
+		Name:    "events_batch_size",
+		EnvVars: []string{"EVENTS_BATCH_SIZE"},
+		Value:   100,
+	}
+
+	EventsRetentionFlag = cli.DurationFlag{
+		Name:    "events_retention",
+		EnvVars: []string{"EVENTS_RETENTION"},
+		Value:   7 * 24 * time.Hour,
+	}
+
+	EventsMaxAttemptsFlag = cli.IntFlag{
+		Name:    "events_max_attempts",
+		EnvVars: []string{"EVENTS_MAX_ATTEMPTS"},
+		Value:   20,
+	}
+
+	EventsRetryBackoffFlag = cli.DurationFlag{
+		Name:    "events_retry_backoff",
+		EnvVars: []string{"EVENTS_RETRY_BACKOFF"},
+		Value:   5 * time.Second,
+	}
+
+	WebhooksDeliveryIntervalFlag = cli.DurationFlag{
+		Name:    "webhooks_delivery_interval",
+		EnvVars: []string{"WEBHOOKS_DELIVERY_INTERVAL"},
+		Value:   10 * time.Second,
+	}
+
+	WebhookMaxAttemptsFlag = cli.IntFlag{
+		Name:    "webhook_max_attempts",
+		EnvVars: []string{"WEBHOOK_MAX_ATTEMPTS"},
+		Value:   10,
+	}
+
+	WebhookRetryBackoffFlag = cli.DurationFlag{
+		Name:    "webhook_retry_backoff",
+		EnvVars: []string{"WEBHOOK_RETRY_BACKOFF"},
+		Value:   30 * time.Second,
+	}
+
+	OperationsRecoveryIntervalFlag = cli.DurationFlag{
+		Name:    "operations_recovery_interval",
+		EnvVars: []string{"OPERATIONS_RECOVERY_INTERVAL"},
+		Value:   time.Minute,
+	}
+
+	OperationsLeaseTimeoutFlag = cli.DurationFlag{
+		Name:    "operations_lease_timeout",
+		EnvVars: []string{"OPERATIONS_LEASE_TIMEOUT"},
+		Value:   10 * time.Minute,
+	}
+
+	OperationsRetentionFlag = cli.DurationFlag{
+		Name:    "operations_retention",
+		EnvVars: []string{"OPERATIONS_RETENTION"},
+		Value:   7 * 24 * time.Hour,
+	}
+
+	CleanupStepAttemptsFlag = cli.IntFlag{
+		Name:    "cleanup_step_attempts",
+		EnvVars: []string{"CLEANUP_STEP_ATTEMPTS"},
+		Value:   3,
+	}
+
+	CleanupRetryBackoffFlag = cli.DurationFlag{
+		Name:    "cleanup_retry_backoff",
+		EnvVars: []string{"CLEANUP_RETRY_BACKOFF"},
+		Value:   time.Second,
+	}
+
+	CleanupMaxRunsFlag = cli.IntFlag{
+		Name:    "cleanup_max_runs",
+		EnvVars: []string{"CLEANUP_MAX_RUNS"},
+		Value:   10,
+	}
+
+	CleanupHooksFlag = cli.StringFlag{
+		Name:    "cleanup_hooks",
+		EnvVars: []string{"CLEANUP_HOOKS"},
+	}
 )
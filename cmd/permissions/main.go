@@ -53,7 +53,10 @@ func prettyPrintFlags(ctx *cli.Context) {
 	w.Flush()
 }
 
-const httpServerContextKey = "httpsrv"
+const (
+	httpServerContextKey = "httpsrv"
+	stopJobsContextKey   = "stopjobs"
+)
 
 var version string
 
@@ -79,6 +82,34 @@ func main() {
 			&VolumeManagerAddrFlag,
 			&SolutionsAddrFlag,
 			&CORSFlag,
+			&TransferOldOwnerAccessFlag,
+			&NamespaceRetentionFlag,
+			&TombstonesPurgeIntervalFlag,
+			&ExpiredAccessesSweepIntervalFlag,
+			&GroupsReconcileIntervalFlag,
+			&GroupRoleMappingFlag,
+			&UserMaxNamespacesFlag,
+			&UserMaxCPUFlag,
+			&UserMaxRAMFlag,
+			&UserMaxExtServicesFlag,
+			&UserMaxIntServicesFlag,
+			&EventsWebhookURLFlag,
+			&EventsFileFlag,
+			&EventsDispatchIntervalFlag,
+			&EventsBatchSizeFlag,
+			&EventsRetentionFlag,
+			&EventsMaxAttemptsFlag,
+			&EventsRetryBackoffFlag,
+			&WebhooksDeliveryIntervalFlag,
+			&WebhookMaxAttemptsFlag,
+			&WebhookRetryBackoffFlag,
+			&OperationsRecoveryIntervalFlag,
+			&OperationsLeaseTimeoutFlag,
+			&OperationsRetentionFlag,
+			&CleanupStepAttemptsFlag,
+			&CleanupRetryBackoffFlag,
+			&CleanupMaxRunsFlag,
+			&CleanupHooksFlag,
 		},
 		Before: func(ctx *cli.Context) error {
 			prettyPrintFlags(ctx)
@@ -100,7 +131,27 @@ func main() {
 				return err
 			}
 
-			srv := server.NewServer(db, clients)
+			cfg, err := setupServerConfig(ctx)
+			if err != nil {
+				return err
+			}
+
+			cleanupHooks, err := parseCleanupHooks(ctx.String(CleanupHooksFlag.Name))
+			if err != nil {
+				return err
+			}
+
+			srv := server.NewServer(db, clients, cfg)
+
+			for _, hook := range cleanupHooks {
+				if err := srv.RegisterHTTPCleanupHook(hook); err != nil {
+					return err
+				}
+			}
+
+			jobsCtx, stopJobs := context.WithCancel(context.Background())
+			srv.StartJobs(jobsCtx)
+			ctx.App.Metadata[stopJobsContextKey] = stopJobs
 
 			g := gin.New()
 			g.Use(gonic.Recovery(errors.ErrInternal, cherrylog.NewLogrusAdapter(logrus.WithField("component", "gin_recovery"))))
@@ -127,6 +178,17 @@ func main() {
 			r.SetupAccessRoutes(srv)
 			r.SetupNamespaceRoutes(srv)
 			r.SetupProjectRoutes(srv)
+			r.SetupVolumeRoutes(srv)
+			r.SetupAccessRequestRoutes(srv)
+			r.SetupInvitationRoutes(srv)
+			r.SetupRoleRoutes(srv)
+			r.SetupServiceAccountRoutes(srv)
+			r.SetupGroupRoutes(srv)
+			r.SetupOrganizationRoutes(srv)
+			r.SetupLimitsRoutes(srv)
+			r.SetupAuditRoutes(srv)
+			r.SetupWebhookRoutes(srv)
+			r.SetupOperationRoutes(srv)
 
 			// for graceful shutdown
 			httpsrv := &http.Server{
@@ -154,6 +216,7 @@ func main() {
 				return err
 			case <-quit:
 				logrus.Infoln("shutting down server...")
+				ctx.App.Metadata[stopJobsContextKey].(context.CancelFunc)()
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				return httpsrv.Shutdown(ctx)
@@ -0,0 +1,84 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/cherry/adaptors/cherrylog"
+	"github.com/containerum/utils/httputil"
+	"github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/resty.v1"
+)
+
+// CleanupHookClient calls HTTP hooks deleting data of deleted namespaces in other services.
+type CleanupHookClient interface {
+	// Call posts cleanup target to url. Hook is considered done if nil returned.
+	Call(ctx context.Context, url string, target model.CleanupTarget) error
+}
+
+type CleanupHookHTTPClient struct {
+	log    *cherrylog.LogrusAdapter
+	client *resty.Client
+}
+
+func NewCleanupHookHTTPClient() *CleanupHookHTTPClient {
+	log := cherrylog.NewLogrusAdapter(logrus.WithField("component", "cleanup_hook_client"))
+	client := resty.New().
+		SetLogger(log.WriterLevel(logrus.DebugLevel)).
+		SetDebug(true).
+		SetTimeout(30*time.Second).
+		SetHeader("Content-Type", "application/json")
+	client.JSONMarshal = jsoniter.Marshal
+	client.JSONUnmarshal = jsoniter.Unmarshal
+	return &CleanupHookHTTPClient{
+		log:    log,
+		client: client,
+	}
+}
+
+func (c *CleanupHookHTTPClient) Call(ctx context.Context, url string, target model.CleanupTarget) error {
+	c.log.WithFields(logrus.Fields{
+		"url":          url,
+		"user_id":      target.UserID,
+		"namespace_id": target.NamespaceID,
+	}).Debugf("call cleanup hook")
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeaders(httputil.RequestXHeadersMap(ctx)).
+		SetBody(target).
+		Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() >= http.StatusMultipleChoices {
+		return fmt.Errorf("cleanup hook responded with status %s", resp.Status())
+	}
+	return nil
+}
+
+func (c CleanupHookHTTPClient) String() string {
+	return "cleanup hook http client"
+}
+
+type dryRunCleanupHookClient struct {
+	CleanupHookClient
+}
+
+// NewDryRunCleanupHookClient wraps cleanup hook client to skip calls in dry run mode
+func NewDryRunCleanupHookClient(client CleanupHookClient) CleanupHookClient {
+	return dryRunCleanupHookClient{CleanupHookClient: client}
+}
+
+func (c dryRunCleanupHookClient) Call(ctx context.Context, url string, target model.CleanupTarget) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("cleanup_hook", "Call", url)
+		return nil
+	}
+	return c.CleanupHookClient.Call(ctx, url, target)
+}
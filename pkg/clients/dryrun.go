@@ -0,0 +1,213 @@
+package clients
+
+import (
+	"context"
+	"strings"
+
+	"git.containerum.net/ch/auth/proto"
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	btypes "github.com/containerum/bill-external/models"
+	"github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+)
+
+// Clients below skip calls changing state of external services for dry run requests and record them as side effects.
+// Calls used only for reading are passed to wrapped client.
+
+type dryRunAuthClient struct {
+	AuthClient
+}
+
+// NewDryRunAuthClient wraps auth client to skip access updates in dry run mode
+func NewDryRunAuthClient(client AuthClient) AuthClient {
+	return dryRunAuthClient{AuthClient: client}
+}
+
+func (c dryRunAuthClient) UpdateUserAccess(ctx context.Context, userID string, access *authProto.ResourcesAccess) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.RefreshUser(userID)
+		return nil
+	}
+	return c.AuthClient.UpdateUserAccess(ctx, userID, access)
+}
+
+type dryRunKubeAPIClient struct {
+	KubeAPIClient
+}
+
+// NewDryRunKubeAPIClient wraps kube-api client to skip namespace changes in dry run mode
+func NewDryRunKubeAPIClient(client KubeAPIClient) KubeAPIClient {
+	return dryRunKubeAPIClient{KubeAPIClient: client}
+}
+
+func (c dryRunKubeAPIClient) CreateNamespace(ctx context.Context, req model.Namespace) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("kube-api", "CreateNamespace", req.ID)
+		return nil
+	}
+	return c.KubeAPIClient.CreateNamespace(ctx, req)
+}
+
+func (c dryRunKubeAPIClient) SetNamespaceQuota(ctx context.Context, ns model.Namespace) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("kube-api", "SetNamespaceQuota", ns.ID)
+		return nil
+	}
+	return c.KubeAPIClient.SetNamespaceQuota(ctx, ns)
+}
+
+func (c dryRunKubeAPIClient) DeleteNamespace(ctx context.Context, ns model.Namespace) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("kube-api", "DeleteNamespace", ns.ID)
+		return nil
+	}
+	return c.KubeAPIClient.DeleteNamespace(ctx, ns)
+}
+
+func (c dryRunKubeAPIClient) DeleteUserNamespaces(ctx context.Context, userID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("kube-api", "DeleteUserNamespaces", userID)
+		return nil
+	}
+	return c.KubeAPIClient.DeleteUserNamespaces(ctx, userID)
+}
+
+type dryRunBillingClient struct {
+	BillingClient
+}
+
+// NewDryRunBillingClient wraps billing client to skip subscription changes in dry run mode
+func NewDryRunBillingClient(client BillingClient) BillingClient {
+	return dryRunBillingClient{BillingClient: client}
+}
+
+func (c dryRunBillingClient) Subscribe(ctx context.Context, req btypes.SubscribeTariffRequest) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("billing", "Subscribe", req.ResourceID)
+		return nil
+	}
+	return c.BillingClient.Subscribe(ctx, req)
+}
+
+func (c dryRunBillingClient) Rename(ctx context.Context, resourceID, newLabel string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("billing", "Rename", resourceID)
+		return nil
+	}
+	return c.BillingClient.Rename(ctx, resourceID, newLabel)
+}
+
+func (c dryRunBillingClient) UpdateSubscription(ctx context.Context, resourceID, newTariffID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("billing", "UpdateSubscription", resourceID)
+		return nil
+	}
+	return c.BillingClient.UpdateSubscription(ctx, resourceID, newTariffID)
+}
+
+func (c dryRunBillingClient) Unsubscribe(ctx context.Context, resourceID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("billing", "Unsubscribe", resourceID)
+		return nil
+	}
+	return c.BillingClient.Unsubscribe(ctx, resourceID)
+}
+
+func (c dryRunBillingClient) MassiveUnsubscribe(ctx context.Context, resourceIDs []string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("billing", "MassiveUnsubscribe", strings.Join(resourceIDs, ","))
+		return nil
+	}
+	return c.BillingClient.MassiveUnsubscribe(ctx, resourceIDs)
+}
+
+type dryRunVolumeManagerClient struct {
+	VolumeManagerClient
+}
+
+// NewDryRunVolumeManagerClient wraps volume manager client to skip volume changes in dry run mode
+func NewDryRunVolumeManagerClient(client VolumeManagerClient) VolumeManagerClient {
+	return dryRunVolumeManagerClient{VolumeManagerClient: client}
+}
+
+func (c dryRunVolumeManagerClient) CreateVolume(ctx context.Context, nsID, label string, capacity int) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("volume-manager", "CreateVolume", nsID+"/"+label)
+		return nil
+	}
+	return c.VolumeManagerClient.CreateVolume(ctx, nsID, label, capacity)
+}
+
+func (c dryRunVolumeManagerClient) DeleteNamespaceVolume(ctx context.Context, nsID, volume string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("volume-manager", "DeleteNamespaceVolume", nsID+"/"+volume)
+		return nil
+	}
+	return c.VolumeManagerClient.DeleteNamespaceVolume(ctx, nsID, volume)
+}
+
+func (c dryRunVolumeManagerClient) DeleteNamespaceVolumes(ctx context.Context, nsID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("volume-manager", "DeleteNamespaceVolumes", nsID)
+		return nil
+	}
+	return c.VolumeManagerClient.DeleteNamespaceVolumes(ctx, nsID)
+}
+
+func (c dryRunVolumeManagerClient) DeleteAllUserVolumes(ctx context.Context) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("volume-manager", "DeleteAllUserVolumes", httputil.MustGetUserID(ctx))
+		return nil
+	}
+	return c.VolumeManagerClient.DeleteAllUserVolumes(ctx)
+}
+
+type dryRunResourceServiceClient struct {
+	ResourceServiceClient
+}
+
+// NewDryRunResourceServiceClient wraps resource service client to skip resources deletion in dry run mode
+func NewDryRunResourceServiceClient(client ResourceServiceClient) ResourceServiceClient {
+	return dryRunResourceServiceClient{ResourceServiceClient: client}
+}
+
+func (c dryRunResourceServiceClient) DeleteNamespaceResources(ctx context.Context, namespaceID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("resource-service", "DeleteNamespaceResources", namespaceID)
+		return nil
+	}
+	return c.ResourceServiceClient.DeleteNamespaceResources(ctx, namespaceID)
+}
+
+func (c dryRunResourceServiceClient) DeleteAllUserNamespaces(ctx context.Context) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("resource-service", "DeleteAllUserNamespaces", httputil.MustGetUserID(ctx))
+		return nil
+	}
+	return c.ResourceServiceClient.DeleteAllUserNamespaces(ctx)
+}
+
+type dryRunSolutionsClient struct {
+	SolutionsClient
+}
+
+// NewDryRunSolutionsClient wraps solutions client to skip solutions deletion in dry run mode
+func NewDryRunSolutionsClient(client SolutionsClient) SolutionsClient {
+	return dryRunSolutionsClient{SolutionsClient: client}
+}
+
+func (c dryRunSolutionsClient) DeleteNamespaceSolutions(ctx context.Context, nsID string) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("solutions", "DeleteNamespaceSolutions", nsID)
+		return nil
+	}
+	return c.SolutionsClient.DeleteNamespaceSolutions(ctx, nsID)
+}
+
+func (c dryRunSolutionsClient) DeleteUserSolutions(ctx context.Context) error {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("solutions", "DeleteUserSolutions", httputil.MustGetUserID(ctx))
+		return nil
+	}
+	return c.SolutionsClient.DeleteUserSolutions(ctx)
+}
@@ -0,0 +1,116 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/cherry/adaptors/cherrylog"
+	"github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/resty.v1"
+)
+
+// EventSink delivers domain events to other services.
+// Event is considered delivered if Send returned nil, otherwise it will be sent again.
+type EventSink interface {
+	Send(ctx context.Context, event model.Event) error
+}
+
+// EventWebhookSink posts events in JSON to configured URL.
+type EventWebhookSink struct {
+	log    *cherrylog.LogrusAdapter
+	client *resty.Client
+	url    string
+}
+
+func NewEventWebhookSink(url *url.URL) *EventWebhookSink {
+	log := cherrylog.NewLogrusAdapter(logrus.WithField("component", "event_webhook_sink"))
+	client := resty.New().
+		SetLogger(log.WriterLevel(logrus.DebugLevel)).
+		SetDebug(true).
+		SetTimeout(10*time.Second).
+		SetHeader("Content-Type", "application/json")
+	client.JSONMarshal = jsoniter.Marshal
+	client.JSONUnmarshal = jsoniter.Unmarshal
+	return &EventWebhookSink{
+		log:    log,
+		client: client,
+		url:    url.String(),
+	}
+}
+
+func (s *EventWebhookSink) Send(ctx context.Context, event model.Event) error {
+	s.log.WithFields(logrus.Fields{
+		"id":   event.ID,
+		"type": event.Type,
+	}).Debugf("send event")
+
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetBody(event).
+		Post(s.url)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %s", resp.Status())
+	}
+	return nil
+}
+
+func (s EventWebhookSink) String() string {
+	return fmt.Sprintf("event webhook sink: url=%s", s.url)
+}
+
+// EventFileSink appends events to file in JSON lines format.
+type EventFileSink struct {
+	log  *cherrylog.LogrusAdapter
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewEventFileSink(path string) (*EventFileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &EventFileSink{
+		log:  cherrylog.NewLogrusAdapter(logrus.WithField("component", "event_file_sink")),
+		file: file,
+	}, nil
+}
+
+func (s *EventFileSink) Send(ctx context.Context, event model.Event) error {
+	s.log.WithFields(logrus.Fields{
+		"id":   event.ID,
+		"type": event.Type,
+	}).Debugf("write event")
+
+	line, err := jsoniter.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// event is marked as delivered after return so it must be on disk
+	return s.file.Sync()
+}
+
+func (s *EventFileSink) Close() error {
+	return s.file.Close()
+}
+
+func (s *EventFileSink) String() string {
+	return fmt.Sprintf("event file sink: path=%s", s.file.Name())
+}
@@ -0,0 +1,191 @@
+package clients
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/cherry/adaptors/cherrylog"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/resty.v1"
+)
+
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookClient sends deliveries to webhook subscribers.
+type WebhookClient interface {
+	// Send posts body to url signed with secret. Returns HTTP status of response, zero if request failed.
+	Send(ctx context.Context, url, secret string, eventType model.EventType, deliveryID int64, body []byte) (int, error)
+}
+
+// WebhookSignature returns value of signature header: hex-encoded HMAC-SHA256 of body prefixed by "sha256=".
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookDeniedNetworks contains addresses of service itself and cluster internal networks which webhooks can not be sent to
+var webhookDeniedNetworks = func() []*net.IPNet {
+	var ret []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.168.0.0/16", "224.0.0.0/4", "240.0.0.0/4", "::/128", "::1/128", "fc00::/7", "fe80::/10", "ff00::/8",
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		ret = append(ret, network)
+	}
+	return ret
+}()
+
+// webhookDeniedHostSuffixes contains suffixes of cluster local host names
+var webhookDeniedHostSuffixes = []string{".localhost", ".local", ".internal", ".svc"}
+
+// CheckWebhookIP returns error if ip is loopback, private, link-local or other not public address.
+func CheckWebhookIP(ip net.IP) error {
+	for _, network := range webhookDeniedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("address %s is not public", ip)
+		}
+	}
+	return nil
+}
+
+// CheckWebhookURL returns error if webhook can not be sent to url: it is not http(s) or its host is not public.
+// Addresses are also checked on connect, so host resolved to other address later is rejected too.
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q is not supported", u.Scheme)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("host is empty")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckWebhookIP(ip)
+	}
+
+	// single label names are resolved to cluster services
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return fmt.Errorf("host %s is not public", host)
+	}
+	for _, suffix := range webhookDeniedHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("host %s is not public", host)
+		}
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := CheckWebhookIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWebhookDial rejects connections to not public addresses, including ones made on redirects
+func checkWebhookDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("address %s is not ip", host)
+	}
+	return CheckWebhookIP(ip)
+}
+
+type WebhookHTTPClient struct {
+	log    *cherrylog.LogrusAdapter
+	client *resty.Client
+}
+
+func NewWebhookHTTPClient() *WebhookHTTPClient {
+	log := cherrylog.NewLogrusAdapter(logrus.WithField("component", "webhook_client"))
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: checkWebhookDial,
+	}
+	client := resty.New().
+		SetTransport(&http.Transport{DialContext: dialer.DialContext}).
+		SetLogger(log.WriterLevel(logrus.DebugLevel)).
+		SetDebug(true).
+		SetTimeout(10*time.Second).
+		SetHeader("Content-Type", "application/json")
+	return &WebhookHTTPClient{
+		log:    log,
+		client: client,
+	}
+}
+
+func (c *WebhookHTTPClient) Send(ctx context.Context, url, secret string, eventType model.EventType, deliveryID int64, body []byte) (int, error) {
+	c.log.WithFields(logrus.Fields{
+		"url":         url,
+		"event_type":  eventType,
+		"delivery_id": deliveryID,
+	}).Debugf("send webhook")
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader(WebhookEventHeader, string(eventType)).
+		SetHeader(WebhookDeliveryHeader, strconv.FormatInt(deliveryID, 10)).
+		SetHeader(WebhookSignatureHeader, WebhookSignature(secret, body)).
+		SetBody(body).
+		Post(url)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode() >= http.StatusMultipleChoices {
+		return resp.StatusCode(), fmt.Errorf("webhook responded with status %s", resp.Status())
+	}
+	return resp.StatusCode(), nil
+}
+
+func (c WebhookHTTPClient) String() string {
+	return "webhook http client"
+}
+
+type dryRunWebhookClient struct {
+	WebhookClient
+}
+
+// NewDryRunWebhookClient wraps webhook client to skip sending in dry run mode
+func NewDryRunWebhookClient(client WebhookClient) WebhookClient {
+	return dryRunWebhookClient{WebhookClient: client}
+}
+
+func (c dryRunWebhookClient) Send(ctx context.Context, url, secret string, eventType model.EventType, deliveryID int64, body []byte) (int, error) {
+	if rec := dryrun.FromContext(ctx); rec != nil {
+		rec.SideEffect("webhook", "Send", url)
+		return 0, nil
+	}
+	return c.WebhookClient.Send(ctx, url, secret, eventType, deliveryID, body)
+}
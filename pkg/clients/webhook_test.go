@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+	}{
+		{
+			name:      "empty",
+			signature: "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+		{
+			name:      "event",
+			secret:    "secret",
+			body:      `{"event":"namespace.created"}`,
+			signature: "sha256=05dc1e221c39073d8871e4bd3e056210d5d3268d9fe1fef7f4ecd55d65d754b6",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := WebhookSignature(tc.secret, []byte(tc.body)); actual != tc.signature {
+				t.Errorf("expected %s, got %s", tc.signature, actual)
+			}
+		})
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	// only urls not requiring name resolution are checked here
+	for _, tc := range []struct {
+		name    string
+		url     string
+		allowed bool
+	}{
+		{name: "public ipv4", url: "https://8.8.8.8/hook", allowed: true},
+		{name: "public ipv6", url: "http://[2001:4860:4860::8888]:8080/hook", allowed: true},
+		{name: "not http", url: "ftp://8.8.8.8/hook"},
+		{name: "empty host", url: "https:///hook"},
+		{name: "loopback", url: "http://127.0.0.1/hook"},
+		{name: "private", url: "http://10.1.2.3/hook"},
+		{name: "link local metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "ipv6 loopback", url: "http://[::1]/hook"},
+		{name: "ipv4 mapped ipv6 loopback", url: "http://[::ffff:127.0.0.1]/hook"},
+		{name: "localhost", url: "http://localhost:8080/hook"},
+		{name: "single label", url: "http://permissions/hook"},
+		{name: "cluster service", url: "http://permissions.default.svc/hook"},
+		{name: "local suffix", url: "http://printer.local./hook"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckWebhookURL(context.Background(), tc.url)
+			switch {
+			case tc.allowed && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case !tc.allowed && err == nil:
+				t.Errorf("expected url to be rejected")
+			}
+		})
+	}
+}
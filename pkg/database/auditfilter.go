@@ -0,0 +1,23 @@
+package database
+
+import (
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg/orm"
+)
+
+// AuditFilter selects records of audit log. Empty fields are not applied.
+type AuditFilter struct {
+	orm.Pager
+
+	ResourceType model.ResourceType
+	ResourceID   string
+	ActorUserID  string
+
+	From *time.Time
+	To   *time.Time
+
+	// Select only changes of namespaces, volumes, projects and organizations owned by user
+	OwnerUserID string
+}
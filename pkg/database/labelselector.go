@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type LabelSelectorOperator string
+
+const (
+	LabelEquals    LabelSelectorOperator = "="
+	LabelNotEquals LabelSelectorOperator = "!="
+	LabelIn        LabelSelectorOperator = "in"
+	LabelNotIn     LabelSelectorOperator = "notin"
+	LabelExists    LabelSelectorOperator = "exists"
+	LabelNotExists LabelSelectorOperator = "!"
+)
+
+// LabelRequirement is a single requirement of label selector, i.e. "env=prod" or "team notin (infra,ops)"
+type LabelRequirement struct {
+	Key      string
+	Operator LabelSelectorOperator
+	Values   []string
+}
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[a-zA-Z0-9]([-_./a-zA-Z0-9]{0,252}[a-zA-Z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^([a-zA-Z0-9]([-_.a-zA-Z0-9]{0,61}[a-zA-Z0-9])?)?$`)
+	setRequirement   = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// ValidateLabels checks that labels keys and values has kubernetes labels format
+func ValidateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if !labelKeyRegexp.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if !labelValueRegexp.MatchString(v) {
+			return fmt.Errorf("invalid value %q for label %q", v, k)
+		}
+	}
+	return nil
+}
+
+// splitLabelSelector splits selector by commas which are not inside of parentheses
+func splitLabelSelector(selector string) ([]string, error) {
+	var ret []string
+	var depth, start int
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected ')' at %d", i)
+			}
+		case ',':
+			if depth == 0 {
+				ret = append(ret, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unclosed '('")
+	}
+	return append(ret, selector[start:]), nil
+}
+
+func parseLabelRequirement(s string) (LabelRequirement, error) {
+	s = strings.TrimSpace(s)
+
+	var req LabelRequirement
+	switch {
+	case setRequirement.MatchString(s):
+		parts := setRequirement.FindStringSubmatch(s)
+		req.Key, req.Operator = parts[1], LabelSelectorOperator(parts[2])
+		for _, v := range strings.Split(parts[3], ",") {
+			req.Values = append(req.Values, strings.TrimSpace(v))
+		}
+	case strings.Contains(s, string(LabelNotEquals)):
+		parts := strings.SplitN(s, string(LabelNotEquals), 2)
+		req.Key, req.Operator, req.Values = strings.TrimSpace(parts[0]), LabelNotEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(s, "=="):
+		parts := strings.SplitN(s, "==", 2)
+		req.Key, req.Operator, req.Values = strings.TrimSpace(parts[0]), LabelEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.Contains(s, string(LabelEquals)):
+		parts := strings.SplitN(s, string(LabelEquals), 2)
+		req.Key, req.Operator, req.Values = strings.TrimSpace(parts[0]), LabelEquals, []string{strings.TrimSpace(parts[1])}
+	case strings.HasPrefix(s, string(LabelNotExists)):
+		req.Key, req.Operator = strings.TrimSpace(s[1:]), LabelNotExists
+	default:
+		req.Key, req.Operator = s, LabelExists
+	}
+
+	if !labelKeyRegexp.MatchString(req.Key) {
+		return LabelRequirement{}, fmt.Errorf("invalid label key %q", req.Key)
+	}
+	for _, v := range req.Values {
+		if !labelValueRegexp.MatchString(v) {
+			return LabelRequirement{}, fmt.Errorf("invalid value %q for label %q", v, req.Key)
+		}
+	}
+
+	return req, nil
+}
+
+// ParseLabelSelector parses kubernetes-like label selector. Supported requirements are
+// "key=value", "key==value", "key!=value", "key in (v1,v2)", "key notin (v1,v2)", "key" and "!key".
+// Requirements separated with comma and all must be satisfied.
+func ParseLabelSelector(selector string) ([]LabelRequirement, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+
+	parts, err := splitLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]LabelRequirement, 0, len(parts))
+	for _, part := range parts {
+		req, err := parseLabelRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, req)
+	}
+
+	return ret, nil
+}
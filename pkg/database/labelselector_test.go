@@ -0,0 +1,88 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		selector string
+		expected []LabelRequirement
+		err      bool
+	}{
+		{name: "empty", selector: "  "},
+		{
+			name:     "equals",
+			selector: "env=prod",
+			expected: []LabelRequirement{{Key: "env", Operator: LabelEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "double equals",
+			selector: "env == prod",
+			expected: []LabelRequirement{{Key: "env", Operator: LabelEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "not equals",
+			selector: "env!=prod",
+			expected: []LabelRequirement{{Key: "env", Operator: LabelNotEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "equals empty value",
+			selector: "env=",
+			expected: []LabelRequirement{{Key: "env", Operator: LabelEquals, Values: []string{""}}},
+		},
+		{
+			name:     "in",
+			selector: "team in (infra, ops)",
+			expected: []LabelRequirement{{Key: "team", Operator: LabelIn, Values: []string{"infra", "ops"}}},
+		},
+		{
+			name:     "notin",
+			selector: "team notin (infra)",
+			expected: []LabelRequirement{{Key: "team", Operator: LabelNotIn, Values: []string{"infra"}}},
+		},
+		{
+			name:     "exists",
+			selector: "example.com/team",
+			expected: []LabelRequirement{{Key: "example.com/team", Operator: LabelExists}},
+		},
+		{
+			name:     "not exists",
+			selector: "!team",
+			expected: []LabelRequirement{{Key: "team", Operator: LabelNotExists}},
+		},
+		{
+			name:     "multiple requirements",
+			selector: "env=prod, team in (infra,ops),!legacy",
+			expected: []LabelRequirement{
+				{Key: "env", Operator: LabelEquals, Values: []string{"prod"}},
+				{Key: "team", Operator: LabelIn, Values: []string{"infra", "ops"}},
+				{Key: "legacy", Operator: LabelNotExists},
+			},
+		},
+		{name: "unclosed parenthesis", selector: "team in (infra,ops", err: true},
+		{name: "unexpected parenthesis", selector: "team in infra)", err: true},
+		{name: "invalid key", selector: "-env=prod", err: true},
+		{name: "invalid value", selector: "env=prod!", err: true},
+		{name: "invalid set value", selector: "team in (infra,o ps)", err: true},
+		{name: "empty requirement", selector: "env=prod,", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseLabelSelector(tc.selector)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
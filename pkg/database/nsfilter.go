@@ -14,6 +14,12 @@ type NamespaceFilter struct {
 	Limited    bool `filter:"limited"`
 	Owned      bool `filter:"owner"`
 	NotOwned   bool `filter:"not_owner"`
+
+	// Applicable for namespaces only
+	LabelSelector []LabelRequirement
+
+	// Applicable for namespaces only
+	OrganizationID string
 }
 
 var nsFilterCache = make(map[string]int)
@@ -2,11 +2,14 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/database"
 	"git.containerum.net/ch/permissions/pkg/model"
 	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
 )
 
 func (pgdb *PgDB) UserAccesses(ctx context.Context, userID string) ([]database.AccessWithLabel, error) {
@@ -15,12 +18,14 @@ func (pgdb *PgDB) UserAccesses(ctx context.Context, userID string) ([]database.A
 	var ret []database.AccessWithLabel
 	err := pgdb.db.Model(&ret).
 		ColumnExpr("?TableAlias.*").
-		ColumnExpr("ns.label AS label").
+		ColumnExpr("coalesce(ns.label, vol.label) AS label").
 		Join("LEFT JOIN namespaces AS ns").JoinOn("?TableAlias.resource_id = ns.id").JoinOn("?TableAlias.resource_type = ?", model.ResourceNamespace).
+		Join("LEFT JOIN volumes AS vol").JoinOn("?TableAlias.resource_id = vol.id").JoinOn("?TableAlias.resource_type = ?", model.ResourceVolume).
 		Where("?TableAlias.user_id = ?", userID).
 		WhereGroup(func(query *orm.Query) (*orm.Query, error) {
 			return query.
-				Where("ns.label IS NOT NULL"), nil
+				WhereOr("ns.label IS NOT NULL").
+				WhereOr("vol.label IS NOT NULL"), nil
 		}).
 		Select()
 	if err != nil {
@@ -34,9 +39,14 @@ func (pgdb *PgDB) SetUserAccesses(ctx context.Context, userID string, level kube
 	pgdb.log.WithField("user_id", userID).Debugf("set accesses to %s", level)
 
 	nsIDsQuery := pgdb.db.Model(&model.Namespace{}).Column("id").Where("owner_user_id = ?", userID)
+	projectIDsQuery := pgdb.db.Model(&model.Project{}).Column("id").Where("owner_user_id = ?", userID)
 	// We can lower initial access lever, upper current access level (but not greater then initial) or set to initial
 	_, err := pgdb.db.Model(&model.Permission{}).
-		Where("resource_id IN (?)", nsIDsQuery).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.
+				WhereOr("resource_id IN (?)", nsIDsQuery).
+				WhereOr("resource_id IN (?)", projectIDsQuery), nil
+		}).
 		Set(`current_access_level = CASE WHEN current_access_level > ?0 THEN ?0
 											WHEN current_access_level <= ?0 AND initial_access_level > ?0 THEN ?0
 											ELSE initial_access_level END`, level).
@@ -53,6 +63,9 @@ func (pgdb *PgDB) setResourceAccess(ctx context.Context, permission model.Permis
 		OnConflict(`(resource_type, resource_id, user_id) DO UPDATE`).
 		Set(`initial_access_level = ?initial_access_level`).
 		Set(`current_access_level = LEAST(?initial_access_level, ?current_access_level)::ACCESS_LEVEL`).
+		Set(`group_id = ?group_id`).
+		Set(`expires_at = ?expires_at`).
+		Set(`role = ?role`).
 		Insert()
 
 	if err != nil {
@@ -62,7 +75,7 @@ func (pgdb *PgDB) setResourceAccess(ctx context.Context, permission model.Permis
 	return nil
 }
 
-func (pgdb *PgDB) SetNamespaceAccess(ctx context.Context, ns model.Namespace, accessLevel kubeClientModel.AccessLevel, toUserID string) error {
+func (pgdb *PgDB) SetNamespaceAccess(ctx context.Context, ns model.Namespace, accessLevel kubeClientModel.AccessLevel, toUserID string, expiresAt *time.Time) error {
 	pgdb.log.WithField("ns_id", ns.KubeName).Debugf("set namespace access %s to %s", accessLevel, toUserID)
 
 	return pgdb.setResourceAccess(ctx, model.Permission{
@@ -71,6 +84,7 @@ func (pgdb *PgDB) SetNamespaceAccess(ctx context.Context, ns model.Namespace, ac
 		UserID:             toUserID,
 		InitialAccessLevel: accessLevel,
 		CurrentAccessLevel: accessLevel,
+		ExpiresAt:          expiresAt,
 	})
 }
 
@@ -80,6 +94,8 @@ func (pgdb *PgDB) setResourceAccesses(ctx context.Context, perms []model.Permiss
 		Set(`initial_access_level = EXCLUDED.initial_access_level`).
 		Set(`current_access_level = LEAST(EXCLUDED.initial_access_level, EXCLUDED.current_access_level)::ACCESS_LEVEL`).
 		Set(`group_id = EXCLUDED.group_id`).
+		Set(`expires_at = EXCLUDED.expires_at`).
+		Set(`role = EXCLUDED.role`).
 		Insert()
 	if err != nil {
 		return pgdb.handleError(err)
@@ -104,6 +120,8 @@ func (pgdb *PgDB) SetNamespaceAccesses(ctx context.Context, ns model.Namespace,
 			InitialAccessLevel: v.AccessLevel,
 			CurrentAccessLevel: v.AccessLevel,
 			GroupID:            v.GroupID,
+			ExpiresAt:          v.ExpiresAt,
+			Role:               v.Role,
 		}
 	}
 
@@ -131,6 +149,8 @@ func (pgdb *PgDB) SetNamespacesAccesses(ctx context.Context, namespaces []model.
 				InitialAccessLevel: access.AccessLevel,
 				CurrentAccessLevel: access.AccessLevel,
 				GroupID:            access.GroupID,
+				ExpiresAt:          access.ExpiresAt,
+				Role:               access.Role,
 			})
 		}
 	}
@@ -158,3 +178,182 @@ func (pgdb *PgDB) DeleteNamespaceAccess(ctx context.Context, ns model.Namespace,
 
 	return pgdb.deleteResourceAccess(ctx, ns.Resource, model.ResourceNamespace, userID)
 }
+
+func (pgdb *PgDB) SetVolumeAccess(ctx context.Context, vol model.Volume, accessLevel kubeClientModel.AccessLevel, toUserID string, expiresAt *time.Time) error {
+	pgdb.log.WithField("volume_id", vol.ID).Debugf("set volume access %s to %s", accessLevel, toUserID)
+
+	return pgdb.setResourceAccess(ctx, model.Permission{
+		ResourceType:       model.ResourceVolume,
+		ResourceID:         vol.ID,
+		UserID:             toUserID,
+		InitialAccessLevel: accessLevel,
+		CurrentAccessLevel: accessLevel,
+		ExpiresAt:          expiresAt,
+	})
+}
+
+func (pgdb *PgDB) DeleteVolumeAccess(ctx context.Context, vol model.Volume, userID string) error {
+	pgdb.log.WithField("volume_id", vol.ID).Debugf("delete volume access to user %s", userID)
+
+	return pgdb.deleteResourceAccess(ctx, vol.Resource, model.ResourceVolume, userID)
+}
+
+func (pgdb *PgDB) SetProjectAccess(ctx context.Context, project model.Project, accessLevel kubeClientModel.AccessLevel, toUserID string) error {
+	pgdb.log.WithField("project_id", project.ID).Debugf("set project access %s to %s", accessLevel, toUserID)
+
+	return pgdb.setResourceAccess(ctx, model.Permission{
+		ResourceType:       model.ResourceProject,
+		ResourceID:         project.ID,
+		UserID:             toUserID,
+		InitialAccessLevel: accessLevel,
+		CurrentAccessLevel: accessLevel,
+	})
+}
+
+func (pgdb *PgDB) SetProjectAccesses(ctx context.Context, project model.Project, accessList []database.AccessListElement) error {
+	pgdb.log.WithField("project_id", project.ID).Debugf("set project accesses %v", accessList)
+
+	if len(accessList) == 0 {
+		return nil
+	}
+
+	permissions := make([]model.Permission, len(accessList))
+	for i, v := range accessList {
+		permissions[i] = model.Permission{
+			ResourceType:       model.ResourceProject,
+			ResourceID:         project.ID,
+			UserID:             v.ToUserID,
+			InitialAccessLevel: v.AccessLevel,
+			CurrentAccessLevel: v.AccessLevel,
+			GroupID:            v.GroupID,
+			ExpiresAt:          v.ExpiresAt,
+			Role:               v.Role,
+		}
+	}
+
+	return pgdb.setResourceAccesses(ctx, permissions)
+}
+
+func (pgdb *PgDB) DeleteProjectAccess(ctx context.Context, project model.Project, userID string) error {
+	pgdb.log.WithField("project_id", project.ID).Debugf("delete project access to user %s", userID)
+
+	return pgdb.deleteResourceAccess(ctx, project.Resource, model.ResourceProject, userID)
+}
+
+func (pgdb *PgDB) ExpiredPermissions(ctx context.Context, expiredAt time.Time) (ret []model.Permission, err error) {
+	pgdb.log.WithField("expired_at", expiredAt).Debugf("get expired permissions")
+
+	ret = make([]model.Permission, 0)
+	err = pgdb.db.Model(&ret).
+		Where("expires_at <= ?", expiredAt).
+		Where("initial_access_level < ?", kubeClientModel.Owner).
+		Order("resource_type", "resource_id").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) DeleteExpiredPermissions(ctx context.Context, kind model.ResourceType, resourceID string, expiredAt time.Time) (deletedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+		"expired_at":  expiredAt,
+	}).Debugf("delete expired permissions")
+
+	_, err = pgdb.db.Model(&deletedPerms).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Where("expires_at <= ?", expiredAt).
+		Where("initial_access_level < ?", kubeClientModel.Owner). // do not delete owner permission
+		Returning("*").
+		Delete()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) EffectivePermission(ctx context.Context, kind model.ResourceType, resourceID, userID string) (*model.EffectivePermission, error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+		"user_id":     userID,
+	}).Debugf("get effective permission")
+
+	var ret model.EffectivePermission
+	err := pgdb.db.Model(&ret).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Where("user_id = ?", userID).
+		Select()
+	switch err {
+	case nil:
+		return &ret, nil
+	case pg.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, pgdb.handleError(err)
+	}
+}
+
+func (pgdb *PgDB) UserNamespacePermissions(ctx context.Context, ns model.Namespace, userID string) (ret []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"ns_id":   ns.ID,
+		"user_id": userID,
+	}).Debugf("get user permissions to namespace and its project")
+
+	ret = make([]model.Permission, 0)
+	err = pgdb.db.Model(&ret).
+		Where("user_id = ?", userID).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			q = q.WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+				return q.
+					Where("resource_type = ?", model.ResourceNamespace).
+					Where("resource_id = ?", ns.ID), nil
+			})
+			if ns.ProjectID != nil {
+				q = q.WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+					return q.
+						Where("resource_type = ?", model.ResourceProject).
+						Where("resource_id = ?", *ns.ProjectID), nil
+				})
+			}
+			return q, nil
+		}).
+		Where("expires_at IS NULL OR expires_at > now()").
+		Order("resource_type").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UserGroupGrants(ctx context.Context, kind model.ResourceType, resourceID, userID string) (ret []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+		"user_id":     userID,
+	}).Debugf("get group grants of user")
+
+	// accesses user would get from every group granted to resource
+	ret = make([]model.Permission, 0)
+	_, err = pgdb.db.Model(&model.Permission{}).Query(&ret, /* language=sql */
+		`SELECT gp.resource_type, gp.resource_id, gm.user_id, gp.group_id,
+			LEAST(gp.access_level, `+memberAccess+`) AS initial_access_level,
+			LEAST(gp.access_level, `+memberAccess+`) AS current_access_level
+		FROM group_permissions AS gp
+		JOIN group_members AS gm ON gm.group_id = gp.group_id
+		WHERE gp.resource_type = ?0 AND gp.resource_id = ?1 AND gm.user_id = ?2
+		ORDER BY LEAST(gp.access_level, `+memberAccess+`) DESC, gp.group_id`, kind, resourceID, userID)
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
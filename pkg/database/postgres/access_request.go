@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) accessRequestsQuery(ret interface{}, status model.AccessRequestStatus) *orm.Query {
+	q := pgdb.db.Model(ret).
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("ns.label AS resource_label").
+		ColumnExpr("ns.kube_name AS resource_name").
+		Join("JOIN namespaces AS ns").
+		JoinOn("ns.id = ?TableAlias.resource_id").
+		JoinOn("?TableAlias.resource_type = ?", model.ResourceNamespace).
+		JoinOn("NOT ns.deleted").
+		OrderExpr("?TableAlias.create_time DESC")
+	if status != "" {
+		q = q.Where("?TableAlias.status = ?", status)
+	}
+	return q
+}
+
+func (pgdb *PgDB) CreateAccessRequest(ctx context.Context, req *model.AccessRequest) error {
+	pgdb.log.Debugf("create access request %+v", req)
+
+	_, err := pgdb.db.Model(req).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) AccessRequestByID(ctx context.Context, id string) (ret model.AccessRequestWithResource, err error) {
+	pgdb.log.WithField("id", id).Debugf("get access request")
+
+	err = pgdb.accessRequestsQuery(&ret, "").
+		Where("?TableAlias.id = ?", id).
+		First()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("access request %s not exists", id)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) NamespaceAccessRequests(ctx context.Context, ns model.Namespace, status model.AccessRequestStatus) (ret []model.AccessRequestWithResource, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"ns_id":  ns.ID,
+		"status": status,
+	}).Debugf("get namespace access requests")
+
+	ret = make([]model.AccessRequestWithResource, 0)
+	err = pgdb.accessRequestsQuery(&ret, status).
+		Where("?TableAlias.resource_id = ?", ns.ID).
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) OwnerAccessRequests(ctx context.Context, ownerID string, status model.AccessRequestStatus) (ret []model.AccessRequestWithResource, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"owner_id": ownerID,
+		"status":   status,
+	}).Debugf("get access requests to owner resources")
+
+	ret = make([]model.AccessRequestWithResource, 0)
+	q := pgdb.accessRequestsQuery(&ret, status)
+	if ownerID != "" {
+		q = q.Where("ns.owner_user_id = ?", ownerID)
+	}
+	err = q.Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UserAccessRequests(ctx context.Context, userID string, status model.AccessRequestStatus) (ret []model.AccessRequestWithResource, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"status":  status,
+	}).Debugf("get user access requests")
+
+	ret = make([]model.AccessRequestWithResource, 0)
+	err = pgdb.accessRequestsQuery(&ret, status).
+		Where("?TableAlias.user_id = ?", userID).
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) ResolveAccessRequest(ctx context.Context, req *model.AccessRequest) error {
+	pgdb.log.Debugf("resolve access request %+v", req)
+
+	now := time.Now()
+	req.ResolveTime = &now
+	result, err := pgdb.db.Model(req).
+		WherePK().
+		Where("status = ?", model.AccessRequestPending).
+		Set("status = ?status").
+		Set("resolve_time = ?resolve_time").
+		Set("resolved_by = ?resolved_by").
+		Set("comment = ?comment").
+		Returning("*").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrAccessRequestResolved().AddDetailF("access request %s is not pending", req.ID)
+	}
+
+	return nil
+}
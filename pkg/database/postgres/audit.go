@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg/orm"
+)
+
+func (pgdb *PgDB) AddAuditRecord(ctx context.Context, record *model.AuditRecord) error {
+	pgdb.log.Debugf("add audit record %+v", record)
+
+	_, err := pgdb.db.Model(record).
+		Returning("*").
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+type AuditFilter database.AuditFilter
+
+func (f *AuditFilter) Filter(q *orm.Query) (*orm.Query, error) {
+	if f.ResourceType != "" {
+		q = q.Where("resource_type = ?", f.ResourceType)
+	}
+	if f.ResourceID != "" {
+		q = q.Where("resource_id = ?", f.ResourceID)
+	}
+	if f.ActorUserID != "" {
+		q = q.Where("actor_user_id = ?", f.ActorUserID)
+	}
+	if f.From != nil {
+		q = q.Where("create_time >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("create_time < ?", *f.To)
+	}
+	if f.OwnerUserID != "" {
+		// deleted resources stay in tables until purge so their history is available for owner
+		q = q.Where( /* language=sql */
+			`resource_id IN (
+				SELECT id::text FROM namespaces WHERE owner_user_id = ?0
+				UNION ALL SELECT id::text FROM volumes WHERE owner_user_id = ?0
+				UNION ALL SELECT id::text FROM projects WHERE owner_user_id = ?0
+				UNION ALL SELECT id::text FROM organizations WHERE owner_user_id = ?0
+			)`, f.OwnerUserID)
+	}
+	if f.Limit > 0 {
+		q = q.Apply(f.Paginate)
+	}
+
+	return q, nil
+}
+
+func (pgdb *PgDB) AuditRecords(ctx context.Context, filter database.AuditFilter) (ret []model.AuditRecord, err error) {
+	pgdb.log.Debugf("get audit records %+v", filter)
+
+	ret = make([]model.AuditRecord, 0)
+
+	f := AuditFilter(filter)
+	err = pgdb.db.Model(&ret).
+		Apply(f.Filter).
+		Order("create_time DESC", "id DESC").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
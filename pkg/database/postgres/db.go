@@ -1,12 +1,15 @@
 package postgres
 
 import (
+	"context"
 	"io"
 	"strings"
 	"time"
 
 	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/dryrun"
 	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
 	"github.com/containerum/cherry"
 	"github.com/containerum/cherry/adaptors/cherrylog"
 	"github.com/go-pg/migrations"
@@ -74,17 +77,75 @@ func (pgdb *PgDB) handleError(err error) error {
 	}
 }
 
-func (pgdb *PgDB) Transactional(fn func(tx database.DB) error) error {
+// errDryRunRollback used to roll back transaction of dry run request after successful run
+var errDryRunRollback = errors.ErrInternal().AddDetailF("dry run rollback")
+
+func (pgdb *PgDB) Transactional(ctx context.Context, fn func(tx database.DB) error) error {
 	entry := cherrylog.NewLogrusAdapter(pgdb.log.WithField("transaction_id", time.Now().UTC().Unix()))
 	dtx := &PgDB{log: entry}
+	rec := dryrun.FromContext(ctx)
 	err := pgdb.db.(transactional).RunInTransaction(func(tx *pg.Tx) error {
 		dtx.db = tx
-		return fn(dtx)
+		if rec == nil {
+			return fn(dtx)
+		}
+
+		// permission changes are recorded by trigger to temporary table dropped with transaction
+		for _, query := range []string{
+			`SET LOCAL permissions.dry_run = 'on'`,
+			`CREATE TEMPORARY TABLE dry_run_permission_changes (
+				seq SERIAL PRIMARY KEY, is_old BOOLEAN NOT NULL,
+				resource_type TEXT NOT NULL, resource_id UUID NOT NULL, user_id UUID NOT NULL, perm permissions
+			) ON COMMIT DROP`,
+		} {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(dtx); err != nil {
+			return err
+		}
+
+		before, after, err := dryRunPermissionChanges(tx)
+		if err != nil {
+			return err
+		}
+		rec.AddPermissions(before, after)
+
+		return errDryRunRollback
 	})
+	if err == errDryRunRollback {
+		entry.Debugf("dry run transaction rolled back")
+		return nil
+	}
 
 	return dtx.handleError(err)
 }
 
+// dryRunPermissionChanges returns changed permission rows as they were before transaction and as they are now.
+// Row is not in before if it was created by transaction and not in after if it was deleted.
+func dryRunPermissionChanges(tx *pg.Tx) (before, after []model.Permission, err error) {
+	// first recorded row of key is its state before transaction if it is old one
+	if _, err = tx.Query(&before, /* language=sql */
+		`SELECT (c.perm).* FROM (
+			SELECT DISTINCT ON (resource_type, resource_id, user_id) is_old, perm
+			FROM pg_temp.dry_run_permission_changes
+			ORDER BY resource_type, resource_id, user_id, seq
+		) AS c WHERE c.is_old`); err != nil {
+		return
+	}
+
+	// last recorded row of key is its state after transaction if it is new one
+	_, err = tx.Query(&after, /* language=sql */
+		`SELECT (c.perm).* FROM (
+			SELECT DISTINCT ON (resource_type, resource_id, user_id) is_old, perm
+			FROM pg_temp.dry_run_permission_changes
+			ORDER BY resource_type, resource_id, user_id, seq DESC
+		) AS c WHERE NOT c.is_old`)
+	return
+}
+
 func (pgdb *PgDB) Close() error {
 	if cl, ok := pgdb.db.(io.Closer); ok {
 		return cl.Close()
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) AddEvent(ctx context.Context, event *model.Event) error {
+	pgdb.log.Debugf("add event %+v", event)
+
+	_, err := pgdb.db.Model(event).
+		Returning("*").
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) TryLockEventsDispatch(ctx context.Context) (locked bool, err error) {
+	pgdb.log.Debugf("try lock events dispatch")
+
+	// lock held until end of transaction so only one service instance dispatches events
+	_, err = pgdb.db.QueryOne(pg.Scan(&locked),
+		/* language=sql */ `SELECT pg_try_advisory_xact_lock(hashtext('events_outbox'))`)
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) ClaimPendingEvents(ctx context.Context, claimFor time.Duration, limit int) (ret []model.Event, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"claim_for": claimFor,
+		"limit":     limit,
+	}).Debugf("claim pending events")
+
+	// events of resource wait while its previous event is postponed, so failing resource does not block others
+	ret = make([]model.Event, 0)
+	_, err = pgdb.db.Model(&ret).
+		Set("next_attempt_time = ?", time.Now().Add(claimFor)).
+		Where( /* language=sql */
+			`id IN (SELECT e.id FROM events_outbox AS e
+				WHERE e.delivered_at IS NULL AND e.failed_at IS NULL AND e.next_attempt_time <= now()
+				AND NOT EXISTS (SELECT 1 FROM events_outbox AS prev
+					WHERE prev.resource_id = e.resource_id AND prev.id < e.id
+					AND prev.delivered_at IS NULL AND prev.failed_at IS NULL AND prev.next_attempt_time > now())
+				ORDER BY e.id LIMIT ? FOR UPDATE SKIP LOCKED)`, limit).
+		Returning("*").
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return
+}
+
+func (pgdb *PgDB) UpdateEvent(ctx context.Context, event *model.Event) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"id":       event.ID,
+		"attempts": event.Attempts,
+	}).Debugf("update event")
+
+	_, err := pgdb.db.Model(event).
+		WherePK().
+		Set("attempts = ?attempts").
+		Set("next_attempt_time = ?next_attempt_time").
+		Set("last_error = ?last_error").
+		Set("delivered_at = ?delivered_at").
+		Set("failed_at = ?failed_at").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) DeleteDeliveredEvents(ctx context.Context, deliveredBefore time.Time) (deleted int, err error) {
+	pgdb.log.WithField("delivered_before", deliveredBefore).Debugf("delete delivered events")
+
+	result, err := pgdb.db.Model(&model.Event{}).
+		WhereOr("delivered_at < ?", deliveredBefore).
+		WhereOr("failed_at < ?", deliveredBefore).
+		Delete()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	deleted = result.RowsAffected()
+	return
+}
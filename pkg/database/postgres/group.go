@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/sirupsen/logrus"
+)
+
+// access of member role, mapping of group grant has precedence over service level mapping
+const memberAccess = /* language=sql */ `COALESCE((gp.role_mapping->>gm.role)::ACCESS_LEVEL, gm.access_level)`
+
+func (pgdb *PgDB) SetGroupPermission(ctx context.Context, perm *model.GroupPermission) error {
+	pgdb.log.Debugf("set group permission %+v", perm)
+
+	_, err := pgdb.db.Model(perm).
+		OnConflict(`(resource_type, resource_id, group_id) DO UPDATE`).
+		Set(`access_level = EXCLUDED.access_level`).
+		Set(`role_mapping = EXCLUDED.role_mapping`).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) DeleteGroupPermission(ctx context.Context, kind model.ResourceType, resourceID, groupID string) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+		"group_id":    groupID,
+	}).Debugf("delete group permission")
+
+	_, err := pgdb.db.Model(&model.GroupPermission{}).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Where("group_id = ?", groupID).
+		Delete()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+// deleteGroupFromResource deletes group grant and permissions given by group to resource only.
+func (pgdb *PgDB) deleteGroupFromResource(ctx context.Context, kind model.ResourceType, resourceID, groupID string) (deletedPerms []model.Permission, err error) {
+	if err = pgdb.DeleteGroupPermission(ctx, kind, resourceID, groupID); err != nil {
+		return nil, err
+	}
+
+	_, err = pgdb.db.Model(&deletedPerms).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Where("group_id = ?", groupID).
+		Where("initial_access_level < ?", kubeClientModel.Owner).
+		Returning("*").
+		Delete()
+	switch err {
+	case pg.ErrNoRows:
+		err = nil
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) ResourceGroupPermissions(ctx context.Context, kind model.ResourceType, resourceID string) (ret []model.GroupPermission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+	}).Debugf("get resource group permissions")
+
+	ret = make([]model.GroupPermission, 0)
+	err = pgdb.db.Model(&ret).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Order("create_time").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) GroupPermissions(ctx context.Context, groupID string) (ret []model.GroupPermission, err error) {
+	pgdb.log.WithField("group_id", groupID).Debugf("get group permissions")
+
+	ret = make([]model.GroupPermission, 0)
+	err = pgdb.db.Model(&ret).
+		Where("group_id = ?", groupID).
+		Order("create_time").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) GrantedGroupIDs(ctx context.Context) (ret []string, err error) {
+	pgdb.log.Debugf("get granted groups")
+
+	ret = make([]string, 0)
+	err = pgdb.db.Model(&model.GroupPermission{}).
+		ColumnExpr("DISTINCT group_id").
+		Select(&ret)
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) SetGroupMembers(ctx context.Context, groupID string, members []model.GroupMember) error {
+	pgdb.log.WithField("group_id", groupID).Debugf("set group members %v", members)
+
+	_, err := pgdb.db.Model(&model.GroupMember{}).
+		Where("group_id = ?", groupID).
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if len(members) == 0 {
+		return nil
+	}
+
+	_, err = pgdb.db.Model(&members).
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) MaterializeGroupPermissions(ctx context.Context, kind model.ResourceType, resourceID string) (changedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+	}).Debugf("materialize group permissions")
+
+	// for each member the best access among groups granted to resource, member access limited by group grant,
+	// members of group outside of resource organization get nothing
+	const groupAccessesQuery = /* language=sql */ `SELECT DISTINCT ON (gm.user_id) gm.user_id, gp.group_id,
+			LEAST(gp.access_level, ` + memberAccess + `) AS access_level
+		FROM group_permissions AS gp
+		JOIN group_members AS gm ON gm.group_id = gp.group_id
+		LEFT JOIN (
+			SELECT id, organization_id FROM namespaces
+			UNION ALL
+			SELECT id, organization_id FROM projects
+		) AS r ON r.id = gp.resource_id
+		WHERE gp.resource_type = ?0 AND gp.resource_id = ?1
+			AND (r.organization_id IS NULL OR EXISTS (
+				SELECT 1 FROM organization_members AS om
+				WHERE om.organization_id = r.organization_id AND om.user_id = gm.user_id
+			))
+		ORDER BY gm.user_id, LEAST(gp.access_level, ` + memberAccess + `) DESC, gp.group_id`
+
+	var deleted []model.Permission
+	_, err = pgdb.db.Model(&model.Permission{}).Query(&deleted, /* language=sql */
+		`DELETE FROM "?TableName" AS p
+		WHERE p.resource_type = ?0 AND p.resource_id = ?1 AND p.group_id IS NOT NULL AND p.initial_access_level < ?2
+			AND NOT EXISTS (
+				SELECT 1 FROM (`+groupAccessesQuery+`) AS ga
+				WHERE ga.user_id = p.user_id AND ga.access_level > ?3
+			)
+		RETURNING *`, kind, resourceID, kubeClientModel.Owner, kubeClientModel.None)
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	// direct permissions (without group) are not overwritten,
+	// current access level limited by current access level of resource owner like in SetUserAccesses
+	var upserted []model.Permission
+	_, err = pgdb.db.Model(&model.Permission{}).Query(&upserted, /* language=sql */
+		`INSERT INTO "?TableName" AS p (resource_type, resource_id, user_id, initial_access_level, current_access_level, group_id)
+		SELECT ?0, ?1, ga.user_id, ga.access_level, LEAST(ga.access_level, COALESCE(o.current_access_level, ga.access_level)), ga.group_id
+		FROM (`+groupAccessesQuery+`) AS ga
+		LEFT JOIN "?TableName" AS o ON o.resource_type = ?0 AND o.resource_id = ?1 AND o.initial_access_level = ?2
+		WHERE ga.access_level > ?3
+		ON CONFLICT (resource_type, resource_id, user_id) DO UPDATE
+		SET initial_access_level = EXCLUDED.initial_access_level,
+			current_access_level = EXCLUDED.current_access_level,
+			group_id = EXCLUDED.group_id,
+			access_level_change_time = now()
+		WHERE p.group_id IS NOT NULL
+			AND (p.initial_access_level, p.current_access_level, p.group_id) IS DISTINCT FROM
+				(EXCLUDED.initial_access_level, EXCLUDED.current_access_level, EXCLUDED.group_id)
+		RETURNING *`, kind, resourceID, kubeClientModel.Owner, kubeClientModel.None)
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	return append(deleted, upserted...), nil
+}
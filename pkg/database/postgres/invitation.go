@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) CreateInvitation(ctx context.Context, invitation *model.Invitation) error {
+	pgdb.log.Debugf("create invitation %+v", invitation)
+
+	invitation.Email = strings.ToLower(invitation.Email)
+	_, err := pgdb.db.Model(invitation).
+		OnConflict(`(resource_type, resource_id, email) DO UPDATE`).
+		Set(`access_level = EXCLUDED.access_level`).
+		Set(`expires_at = EXCLUDED.expires_at`).
+		Set(`role = EXCLUDED.role`).
+		Set(`created_by = EXCLUDED.created_by`).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) NamespaceInvitations(ctx context.Context, ns model.Namespace) (ret []model.Invitation, err error) {
+	pgdb.log.WithField("ns_id", ns.ID).Debugf("get namespace invitations")
+
+	ret = make([]model.Invitation, 0)
+	err = pgdb.db.Model(&ret).
+		Where("resource_type = ?", model.ResourceNamespace).
+		Where("resource_id = ?", ns.ID).
+		Order("email").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) DeleteNamespaceInvitation(ctx context.Context, ns model.Namespace, email string) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"ns_id": ns.ID,
+		"email": email,
+	}).Debugf("delete namespace invitation")
+
+	result, err := pgdb.db.Model(&model.Invitation{}).
+		Where("resource_type = ?", model.ResourceNamespace).
+		Where("resource_id = ?", ns.ID).
+		Where("email = ?", strings.ToLower(email)).
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("invitation for %s not exists", email)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) AcceptInvitations(ctx context.Context, userID, email string) (accepted []model.Invitation, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"email":   email,
+	}).Debugf("accept invitations")
+
+	email = strings.ToLower(email)
+
+	// existing permissions are not overwritten, invitations to deleted or own namespaces are dropped
+	_, err = pgdb.db.Model(&model.Permission{}).Exec( /* language=sql */
+		`INSERT INTO "?TableName" (resource_type, resource_id, user_id, initial_access_level, current_access_level, expires_at, role)
+		SELECT inv.resource_type, inv.resource_id, ?0, inv.access_level, inv.access_level, inv.expires_at, inv.role
+		FROM invitations AS inv
+		JOIN namespaces AS ns ON ns.id = inv.resource_id AND NOT ns.deleted AND ns.owner_user_id != ?0
+		WHERE inv.email = ?1 AND (inv.expires_at IS NULL OR inv.expires_at > now())
+		ON CONFLICT (resource_type, resource_id, user_id) DO NOTHING`, userID, email)
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	_, err = pgdb.db.Model(&accepted).
+		Where("email = ?", email).
+		Returning("*").
+		Delete()
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	return accepted, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+)
+
+func (pgdb *PgDB) UserLimits(ctx context.Context, userID string) (ret model.UserLimits, err error) {
+	pgdb.log.WithField("user_id", userID).Debugf("get user limits")
+
+	ret.UserID = userID
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case pg.ErrNoRows:
+		// service level limits applied
+		err = nil
+	case nil:
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) SetUserLimits(ctx context.Context, limits *model.UserLimits) error {
+	pgdb.log.Debugf("set user limits %+v", limits)
+
+	_, err := pgdb.db.Model(limits).
+		OnConflict(`(user_id) DO UPDATE`).
+		Set(`namespaces = EXCLUDED.namespaces`).
+		Set(`cpu = EXCLUDED.cpu`).
+		Set(`ram = EXCLUDED.ram`).
+		Set(`max_ext_services = EXCLUDED.max_ext_services`).
+		Set(`max_int_services = EXCLUDED.max_int_services`).
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) UserResourcesUsage(ctx context.Context, userID string) (ret model.ResourcesLimits, err error) {
+	pgdb.log.WithField("user_id", userID).Debugf("get user resources usage")
+
+	// concurrent changes of user namespaces wait for end of transaction
+	_, err = pgdb.db.Exec( /* language=sql */ `SELECT pg_advisory_xact_lock(hashtext(?))`, userID)
+	if err != nil {
+		return ret, pgdb.handleError(err)
+	}
+
+	err = pgdb.db.Model(&model.Namespace{}).
+		ColumnExpr("count(*) AS namespaces").
+		ColumnExpr("coalesce(sum(cpu), 0) AS cpu").
+		ColumnExpr("coalesce(sum(ram), 0) AS ram").
+		ColumnExpr("coalesce(sum(max_ext_services), 0) AS max_ext_services").
+		ColumnExpr("coalesce(sum(max_int_services), 0) AS max_int_services").
+		Where("owner_user_id = ?", userID).
+		Where("NOT deleted").
+		Select(&ret.Namespaces, &ret.CPU, &ret.RAM, &ret.MaxExtServices, &ret.MaxIntServices)
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.Volume{}, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.Volume{}).
+			Exec( /* language=sql */ `CREATE UNIQUE INDEX unique_volume_ns_label ON "?TableName" ("namespace_id", "label") WHERE NOT deleted`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		if _, err := db.Model(&model.Volume{}).
+			Exec( /* language=sql */ `DROP INDEX IF EXISTS unique_volume_ns_label`); err != nil {
+			return err
+		}
+
+		_, err := orm.DropTable(db, &model.Volume{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		// projects created before had no owner permission
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`INSERT INTO "?TableName" (resource_type, resource_id, user_id, initial_access_level, current_access_level)
+			SELECT ?0, p.id, p.owner_user_id, ?1, ?1 FROM projects AS p WHERE NOT p.deleted
+			ON CONFLICT DO NOTHING`, model.ResourceProject, kubeClientModel.Owner); err != nil {
+			return err
+		}
+
+		// project permissions applied to all project namespaces, greater access level wins, direct permission wins on equal levels
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`CREATE OR REPLACE VIEW effective_permissions AS
+			SELECT DISTINCT ON (perms.resource_id, perms.user_id)
+				perms.perm_id, perms.resource_type, perms.resource_id, perms.create_time, perms.user_id,
+				perms.initial_access_level, perms.current_access_level, perms.access_level_change_time,
+				perms.group_id, perms.inherited_from
+			FROM (
+				SELECT p.perm_id, p.resource_type, p.resource_id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, NULL::UUID AS inherited_from
+				FROM "?TableName" AS p
+				UNION ALL
+				SELECT p.perm_id, ?0, ns.id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, p.resource_id
+				FROM "?TableName" AS p
+				JOIN namespaces AS ns ON ns.project_id = p.resource_id AND NOT ns.deleted
+				WHERE p.resource_type = ?1 AND p.initial_access_level != ?2
+			) AS perms
+			ORDER BY perms.resource_id, perms.user_id, perms.current_access_level DESC, perms.inherited_from NULLS FIRST`,
+			model.ResourceNamespace, model.ResourceProject, kubeClientModel.Owner); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		if _, err := db.Exec( /* language=sql */ `DROP VIEW IF EXISTS effective_permissions`); err != nil {
+			return err
+		}
+
+		_, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`DELETE FROM "?TableName" WHERE resource_type = ?`, model.ResourceProject)
+		return err
+	})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.PermissionTombstone{}, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.PermissionTombstone{}).
+			Exec( /* language=sql */ `CREATE INDEX IF NOT EXISTS tombstones_delete_time ON "?TableName" ("delete_time")`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		_, err := orm.DropTable(db, &model.PermissionTombstone{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
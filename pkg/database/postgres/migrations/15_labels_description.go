@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.Namespace{}, &model.Project{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName"
+				ADD COLUMN IF NOT EXISTS labels JSONB,
+				ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT ''`); err != nil {
+				return err
+			}
+		}
+
+		if _, err := db.Model(&model.Namespace{}).Exec( /* language=sql */
+			`CREATE INDEX IF NOT EXISTS namespaces_labels ON "?TableName" USING GIN (labels)`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.Namespace{}, &model.Project{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS labels, DROP COLUMN IF EXISTS description`); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.Permission{}, &model.PermissionTombstone{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ`); err != nil {
+				return err
+			}
+		}
+
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`CREATE INDEX IF NOT EXISTS permissions_expires_at ON "?TableName" ("expires_at") WHERE expires_at IS NOT NULL`); err != nil {
+			return err
+		}
+
+		if _, err := db.Exec( /* language=sql */ `DROP VIEW IF EXISTS effective_permissions`); err != nil {
+			return err
+		}
+
+		// same as in 13_project_permissions but with expiration, expired permissions ignored even if not revoked yet
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`CREATE VIEW effective_permissions AS
+			SELECT DISTINCT ON (perms.resource_id, perms.user_id)
+				perms.perm_id, perms.resource_type, perms.resource_id, perms.create_time, perms.user_id,
+				perms.initial_access_level, perms.current_access_level, perms.access_level_change_time,
+				perms.group_id, perms.expires_at, perms.inherited_from
+			FROM (
+				SELECT p.perm_id, p.resource_type, p.resource_id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, p.expires_at, NULL::UUID AS inherited_from
+				FROM "?TableName" AS p
+				UNION ALL
+				SELECT p.perm_id, ?0, ns.id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, p.expires_at, p.resource_id
+				FROM "?TableName" AS p
+				JOIN namespaces AS ns ON ns.project_id = p.resource_id AND NOT ns.deleted
+				WHERE p.resource_type = ?1 AND p.initial_access_level != ?2
+			) AS perms
+			WHERE perms.expires_at IS NULL OR perms.expires_at > now()
+			ORDER BY perms.resource_id, perms.user_id, perms.current_access_level DESC, perms.inherited_from NULLS FIRST`,
+			model.ResourceNamespace, model.ResourceProject, kubeClientModel.Owner); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		if _, err := db.Exec( /* language=sql */ `DROP VIEW IF EXISTS effective_permissions`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`CREATE VIEW effective_permissions AS
+			SELECT DISTINCT ON (perms.resource_id, perms.user_id)
+				perms.perm_id, perms.resource_type, perms.resource_id, perms.create_time, perms.user_id,
+				perms.initial_access_level, perms.current_access_level, perms.access_level_change_time,
+				perms.group_id, perms.inherited_from
+			FROM (
+				SELECT p.perm_id, p.resource_type, p.resource_id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, NULL::UUID AS inherited_from
+				FROM "?TableName" AS p
+				UNION ALL
+				SELECT p.perm_id, ?0, ns.id, p.create_time, p.user_id,
+					p.initial_access_level, p.current_access_level, p.access_level_change_time,
+					p.group_id, p.resource_id
+				FROM "?TableName" AS p
+				JOIN namespaces AS ns ON ns.project_id = p.resource_id AND NOT ns.deleted
+				WHERE p.resource_type = ?1 AND p.initial_access_level != ?2
+			) AS perms
+			ORDER BY perms.resource_id, perms.user_id, perms.current_access_level DESC, perms.inherited_from NULLS FIRST`,
+			model.ResourceNamespace, model.ResourceProject, kubeClientModel.Owner); err != nil {
+			return err
+		}
+
+		for _, m := range []interface{}{&model.Permission{}, &model.PermissionTombstone{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS expires_at`); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
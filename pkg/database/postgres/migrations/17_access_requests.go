@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.AccessRequest{}, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+			return err
+		}
+
+		// only one pending request allowed for user and resource
+		if _, err := db.Model(&model.AccessRequest{}).Exec( /* language=sql */
+			`CREATE UNIQUE INDEX IF NOT EXISTS access_requests_pending ON "?TableName" ("resource_id", "user_id") WHERE status = ?`,
+			model.AccessRequestPending); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.AccessRequest{}).Exec( /* language=sql */
+			`CREATE INDEX IF NOT EXISTS access_requests_user_id ON "?TableName" ("user_id")`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		_, err := orm.DropTable(db, &model.AccessRequest{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
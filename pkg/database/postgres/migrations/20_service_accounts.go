@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.ServiceAccount{}, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.ServiceAccount{}).Exec( /* language=sql */
+			`CREATE UNIQUE INDEX IF NOT EXISTS service_accounts_resource_name ON "?TableName" ("resource_type", "resource_id", "name")`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		_, err := orm.DropTable(db, &model.ServiceAccount{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
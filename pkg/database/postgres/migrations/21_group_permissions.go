@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.GroupPermission{}, &model.GroupMember{}} {
+			if _, err := orm.CreateTable(db, m, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := db.Model(&model.GroupMember{}).Exec( /* language=sql */
+			`CREATE INDEX IF NOT EXISTS group_members_user_id ON "?TableName" ("user_id")`); err != nil {
+			return err
+		}
+
+		// group grants and membership restored from permissions copied to group members before
+		if _, err := db.Model(&model.GroupPermission{}).Exec( /* language=sql */
+			`INSERT INTO "?TableName" (resource_type, resource_id, group_id, access_level)
+			SELECT resource_type, resource_id, group_id, max(initial_access_level)
+			FROM permissions
+			WHERE group_id IS NOT NULL
+			GROUP BY resource_type, resource_id, group_id
+			ON CONFLICT DO NOTHING`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.GroupMember{}).Exec( /* language=sql */
+			`INSERT INTO "?TableName" (group_id, user_id, access_level)
+			SELECT group_id, user_id, max(initial_access_level)
+			FROM permissions
+			WHERE group_id IS NOT NULL
+			GROUP BY group_id, user_id
+			ON CONFLICT DO NOTHING`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.GroupPermission{}, &model.GroupMember{}} {
+			if _, err := orm.DropTable(db, m, &orm.DropTableOptions{IfExists: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := db.Model(&model.GroupMember{}).Exec( /* language=sql */
+			`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS role TEXT`); err != nil {
+			return err
+		}
+
+		// real roles will be fetched from user manager by groups reconciliation
+		if _, err := db.Model(&model.GroupMember{}).Exec( /* language=sql */
+			`UPDATE "?TableName" SET role = CASE access_level
+				WHEN 'owner' THEN 'owner'
+				WHEN 'write' THEN 'master'
+				WHEN 'readdelete' THEN 'member'
+				WHEN 'read' THEN 'guest'
+				ELSE 'none'
+			END
+			WHERE role IS NULL`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.GroupPermission{}).Exec( /* language=sql */
+			`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS role_mapping JSONB`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		if _, err := db.Model(&model.GroupPermission{}).Exec( /* language=sql */
+			`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS role_mapping`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.GroupMember{}).Exec( /* language=sql */
+			`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS role`); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
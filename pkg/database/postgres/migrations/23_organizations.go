@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.Organization{}, &model.OrganizationMember{}} {
+			if _, err := orm.CreateTable(db, m, &orm.CreateTableOptions{IfNotExists: true, FKConstraints: true}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := db.Model(&model.OrganizationMember{}).Exec( /* language=sql */
+			`ALTER TABLE "?TableName" ADD FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE CASCADE`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.OrganizationMember{}).Exec( /* language=sql */
+			`CREATE INDEX IF NOT EXISTS organization_members_user_id ON "?TableName" ("user_id")`); err != nil {
+			return err
+		}
+
+		// resources stay with their owners when organization deleted
+		for _, m := range []interface{}{&model.Namespace{}, &model.Project{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS organization_id UUID`); err != nil {
+				return err
+			}
+
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" ADD FOREIGN KEY (organization_id) REFERENCES organizations (id) ON DELETE SET NULL`); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.Namespace{}, &model.Project{}} {
+			if _, err := db.Model(m).Exec( /* language=sql */
+				`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS organization_id`); err != nil {
+				return err
+			}
+		}
+
+		for _, m := range []interface{}{&model.OrganizationMember{}, &model.Organization{}} {
+			if _, err := orm.DropTable(db, m, &orm.DropTableOptions{IfExists: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.AuditRecord{}, &orm.CreateTableOptions{IfNotExists: true}); err != nil {
+			return err
+		}
+
+		for _, index := range []string{
+			`CREATE INDEX IF NOT EXISTS audit_log_resource ON "?TableName" ("resource_id", "create_time")`,
+			`CREATE INDEX IF NOT EXISTS audit_log_actor ON "?TableName" ("actor_user_id", "create_time")`,
+			`CREATE INDEX IF NOT EXISTS audit_log_create_time ON "?TableName" ("create_time")`,
+		} {
+			if _, err := db.Model(&model.AuditRecord{}).Exec(index); err != nil {
+				return err
+			}
+		}
+
+		// log is append-only, records can not be changed or deleted even by mistake in code
+		if _, err := db.Exec( /* language=sql */
+			`CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+			BEGIN
+				RAISE EXCEPTION 'audit log is append-only';
+			END;
+			$$ LANGUAGE plpgsql`); err != nil {
+			return err
+		}
+
+		if _, err := db.Model(&model.AuditRecord{}).Exec( /* language=sql */
+			`CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON "?TableName"
+			FOR EACH STATEMENT EXECUTE PROCEDURE audit_log_append_only()`); err != nil {
+			return err
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		if _, err := orm.DropTable(db, &model.AuditRecord{}, &orm.DropTableOptions{IfExists: true}); err != nil {
+			return err
+		}
+
+		_, err := db.Exec( /* language=sql */ `DROP FUNCTION IF EXISTS audit_log_append_only()`)
+		return err
+	})
+}
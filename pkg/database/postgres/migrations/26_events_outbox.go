@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.Event{}, &orm.CreateTableOptions{IfNotExists: true}); err != nil {
+			return err
+		}
+
+		for _, index := range []string{
+			`CREATE INDEX IF NOT EXISTS events_outbox_pending ON "?TableName" ("id") WHERE "delivered_at" IS NULL`,
+			`CREATE INDEX IF NOT EXISTS events_outbox_delivered_at ON "?TableName" ("delivered_at")`,
+		} {
+			if _, err := db.Model(&model.Event{}).Exec(index); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		_, err := orm.DropTable(db, &model.Event{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
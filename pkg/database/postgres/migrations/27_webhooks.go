@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.WebhookSubscription{}, &model.WebhookDelivery{}} {
+			if _, err := orm.CreateTable(db, m, &orm.CreateTableOptions{IfNotExists: true}); err != nil {
+				return err
+			}
+		}
+
+		for _, query := range []string{
+			`ALTER TABLE "?TableName" ADD FOREIGN KEY (namespace_id) REFERENCES namespaces (id) ON DELETE CASCADE`,
+			`CREATE INDEX IF NOT EXISTS webhook_subscriptions_owner_user_id ON "?TableName" ("owner_user_id")`,
+			`CREATE INDEX IF NOT EXISTS webhook_subscriptions_namespace_id ON "?TableName" ("namespace_id")`,
+		} {
+			if _, err := db.Model(&model.WebhookSubscription{}).Exec(query); err != nil {
+				return err
+			}
+		}
+
+		for _, query := range []string{
+			`ALTER TABLE "?TableName" ADD FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE`,
+			`CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_id ON "?TableName" ("subscription_id", "id")`,
+			`CREATE INDEX IF NOT EXISTS webhook_deliveries_pending ON "?TableName" ("next_attempt_time") WHERE "status" = 'pending'`,
+		} {
+			if _, err := db.Model(&model.WebhookDelivery{}).Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		for _, m := range []interface{}{&model.WebhookDelivery{}, &model.WebhookSubscription{}} {
+			if _, err := orm.DropTable(db, m, &orm.DropTableOptions{IfExists: true}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
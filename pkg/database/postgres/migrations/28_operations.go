@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+	"github.com/go-pg/pg/orm"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		if _, err := orm.CreateTable(db, &model.Operation{}, &orm.CreateTableOptions{IfNotExists: true}); err != nil {
+			return err
+		}
+
+		for _, query := range []string{
+			`CREATE INDEX IF NOT EXISTS operations_unfinished ON "?TableName" ("update_time") WHERE "status" IN ('running', 'compensating')`,
+			`CREATE INDEX IF NOT EXISTS operations_resource_id ON "?TableName" ("resource_id")`,
+		} {
+			if _, err := db.Model(&model.Operation{}).Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		_, err := orm.DropTable(db, &model.Operation{}, &orm.DropTableOptions{IfExists: true})
+		return err
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		// dry run transaction enables recording and creates temporary table, other transactions only check setting
+		if _, err := db.Exec( /* language=sql */
+			`CREATE OR REPLACE FUNCTION record_dry_run_permission_change() RETURNS TRIGGER AS $$
+			BEGIN
+				IF current_setting('permissions.dry_run', true) IS DISTINCT FROM 'on' THEN
+					RETURN NULL;
+				END IF;
+				-- update is recorded as delete of old row and insert of new one, so changed key is handled too
+				IF TG_OP IN ('UPDATE', 'DELETE') THEN
+					INSERT INTO pg_temp.dry_run_permission_changes (is_old, resource_type, resource_id, user_id, perm)
+					VALUES (true, OLD.resource_type, OLD.resource_id, OLD.user_id, OLD);
+				END IF;
+				IF TG_OP IN ('UPDATE', 'INSERT') THEN
+					INSERT INTO pg_temp.dry_run_permission_changes (is_old, resource_type, resource_id, user_id, perm)
+					VALUES (false, NEW.resource_type, NEW.resource_id, NEW.user_id, NEW);
+				END IF;
+				RETURN NULL;
+			END;
+			$$ LANGUAGE plpgsql`); err != nil {
+			return err
+		}
+
+		_, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`CREATE TRIGGER permissions_dry_run AFTER INSERT OR UPDATE OR DELETE ON "?TableName"
+			FOR EACH ROW EXECUTE PROCEDURE record_dry_run_permission_change()`)
+		return err
+	}, func(db migrations.DB) error {
+		if _, err := db.Model(&model.Permission{}).Exec( /* language=sql */
+			`DROP TRIGGER IF EXISTS permissions_dry_run ON "?TableName"`); err != nil {
+			return err
+		}
+
+		_, err := db.Exec( /* language=sql */ `DROP FUNCTION IF EXISTS record_dry_run_permission_change()`)
+		return err
+	})
+}
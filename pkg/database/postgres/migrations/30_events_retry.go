@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	migrations.Register(func(db migrations.DB) error {
+		for _, query := range []string{
+			`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS next_attempt_time TIMESTAMPTZ NOT NULL DEFAULT now()`,
+			`ALTER TABLE "?TableName" ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ`,
+			`DROP INDEX IF EXISTS events_outbox_pending`,
+			`CREATE INDEX IF NOT EXISTS events_outbox_pending ON "?TableName" ("id") WHERE "delivered_at" IS NULL AND "failed_at" IS NULL`,
+			// used to find previous pending events of resource
+			`CREATE INDEX IF NOT EXISTS events_outbox_pending_resource ON "?TableName" ("resource_id", "id") WHERE "delivered_at" IS NULL AND "failed_at" IS NULL`,
+		} {
+			if _, err := db.Model(&model.Event{}).Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(db migrations.DB) error {
+		for _, query := range []string{
+			`DROP INDEX IF EXISTS events_outbox_pending_resource`,
+			`DROP INDEX IF EXISTS events_outbox_pending`,
+			`CREATE INDEX IF NOT EXISTS events_outbox_pending ON "?TableName" ("id") WHERE "delivered_at" IS NULL`,
+			`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS failed_at`,
+			`ALTER TABLE "?TableName" DROP COLUMN IF EXISTS next_attempt_time`,
+		} {
+			if _, err := db.Model(&model.Event{}).Exec(query); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"git.containerum.net/ch/permissions/pkg/model"
 	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/go-pg/pg"
-	"github.com/go-pg/pg/orm"
 	"github.com/sirupsen/logrus"
 )
 
@@ -39,6 +38,18 @@ func (pgdb *PgDB) NamespaceByName(ctx context.Context, userID, name string, isAd
 			Where("coalesce(permission.current_access_level, ?0) > ?0", kubeClientModel.None).
 			Where("NOT ?TableAlias.deleted").
 			Select()
+		if err == pg.ErrNoRows {
+			// organization admins see organization namespaces with owner permission
+			err = pgdb.db.Model(&ret).
+				ColumnExpr("?TableAlias.*").
+				Column("Permission").
+				Where("kube_name = ?", name).
+				Where("permission.resource_id = ?TableAlias.id").
+				Where("permission.initial_access_level = ?", kubeClientModel.Owner).
+				Where("?TableAlias.organization_id IN (?)", pgdb.organizationAdminOf(userID)).
+				Where("NOT ?TableAlias.deleted").
+				Select()
+		}
 	}
 	switch err {
 	case pg.ErrNoRows:
@@ -79,15 +90,15 @@ func (pgdb *PgDB) NamespacePermissions(ctx context.Context, ns *model.NamespaceW
 		"label":         ns.Label,
 	}).Debugf("get namespace permissions")
 
-	err := pgdb.db.Model(ns).
-		WherePK().
-		Column("Permissions").
-		Relation("Permissions", func(q *orm.Query) (*orm.Query, error) {
-			return q.Where("initial_access_level != ?", kubeClientModel.Owner), nil
-		}).
+	// select from view to get also permissions inherited from project
+	var permissions []model.EffectivePermission
+	err := pgdb.db.Model(&permissions).
+		Where("resource_id = ?", ns.ID).
+		Where("initial_access_level != ?", kubeClientModel.Owner).
 		Select()
-	if len(ns.Permissions) == 0 {
-		ns.Permissions = make([]model.Permission, 0)
+	ns.Permissions = make([]model.Permission, len(permissions))
+	for i := range permissions {
+		ns.Permissions[i] = permissions[i].Permission
 	}
 	switch err {
 	case pg.ErrNoRows:
@@ -223,6 +234,18 @@ func (pgdb *PgDB) ResizeNamespace(ctx context.Context, namespace model.Namespace
 	return nil
 }
 
+func (pgdb *PgDB) UpdateNamespaceMeta(ctx context.Context, namespace *model.Namespace) error {
+	pgdb.log.Debugf("update namespace meta %+v", namespace)
+
+	_, err := pgdb.db.Model(namespace).
+		WherePK().
+		Set("labels = ?labels").
+		Set("description = ?description").
+		Returning("*").
+		Update()
+	return pgdb.handleError(err)
+}
+
 func (pgdb *PgDB) DeleteNamespace(ctx context.Context, namespace *model.Namespace) error {
 	pgdb.log.Debugf("delete namespace %+v", namespace)
 
@@ -272,18 +295,86 @@ func (pgdb *PgDB) DeleteAllUserNamespaces(ctx context.Context, userID string) (d
 	return
 }
 
-func (pgdb *PgDB) DeleteGroupFromNamespace(ctx context.Context, namespace, groupID string) (deletedPerms []model.Permission, err error) {
-	pgdb.log.WithFields(logrus.Fields{
-		"namespace": namespace,
-		"group_id":  groupID,
-	}).Debugf("delete group from namespace")
+func (pgdb *PgDB) DeletedNamespaceByName(ctx context.Context, name string) (ret model.Namespace, err error) {
+	pgdb.log.WithField("name", name).Debugf("get deleted namespace by name")
+
+	err = pgdb.db.Model(&ret).
+		Where("kube_name = ?", name).
+		Where("deleted").
+		First()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("deleted namespace with id %s not exists", name)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) RestoreNamespace(ctx context.Context, namespace *model.Namespace) error {
+	pgdb.log.Debugf("restore namespace %+v", namespace)
 
-	_, err = pgdb.db.Model(&deletedPerms).
-		Where("group_id = ?", groupID).
-		Where("resource_type = ?", model.ResourceNamespace).
+	cnt, err := pgdb.db.Model(namespace).
+		Where("owner_user_id = ?owner_user_id").
+		Where("label = ?label").
+		Where("NOT deleted").
+		Count()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("namespace %s already exists", namespace.Label)
+	}
+
+	namespace.Deleted = false
+	namespace.DeleteTime = nil
+	result, err := pgdb.db.Model(namespace).
+		Where("deleted").
+		WherePK().
+		Set("deleted = ?deleted").
+		Set("delete_time = ?delete_time").
+		// project could be deleted while namespace was deleted
+		Set("project_id = (SELECT p.id FROM projects AS p WHERE p.id = ?TableAlias.project_id AND NOT p.deleted)").
 		Returning("*").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("deleted namespace %s not exists", namespace.Label)
+	}
+
+	_, err = pgdb.db.Model(&model.Permission{}).Exec( /* language=sql */
+		`INSERT INTO "?TableName" (perm_id, resource_type, resource_id, create_time, user_id,
+			initial_access_level, current_access_level, access_level_change_time, group_id, expires_at, role)
+		SELECT perm_id, resource_type, resource_id, create_time, user_id,
+			initial_access_level, current_access_level, access_level_change_time, group_id, expires_at, role
+		FROM permission_tombstones WHERE resource_id = ?
+		ON CONFLICT DO NOTHING`, namespace.ID)
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	_, err = pgdb.db.Model(&model.PermissionTombstone{}).
+		Where("resource_id = ?", namespace.ID).
 		Delete()
+	return pgdb.handleError(err)
+}
 
+func (pgdb *PgDB) DeletedNamespacePermission(ctx context.Context, ns model.Namespace, userID string) (ret model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"namespace": ns.KubeName,
+		"user_id":   userID,
+	}).Debugf("get deleted namespace permission")
+
+	var tombstone model.PermissionTombstone
+	err = pgdb.db.Model(&tombstone).
+		Where("resource_id = ?", ns.ID).
+		Where("user_id = ?", userID).
+		Order("current_access_level DESC").
+		First()
 	switch err {
 	case pg.ErrNoRows:
 		err = nil
@@ -291,5 +382,173 @@ func (pgdb *PgDB) DeleteGroupFromNamespace(ctx context.Context, namespace, group
 		err = pgdb.handleError(err)
 	}
 
+	ret = tombstone.Permission
 	return
 }
+
+func (pgdb *PgDB) PurgeTombstones(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	pgdb.log.WithField("deleted_before", deletedBefore).Debugf("purge permission tombstones")
+
+	result, err := pgdb.db.Model(&model.PermissionTombstone{}).
+		Where("delete_time < ?", deletedBefore).
+		Delete()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	purged = result.RowsAffected()
+	return
+}
+
+func (pgdb *PgDB) transferResourceOwner(ctx context.Context, resource model.Resource, kind model.ResourceType, newOwnerID string, oldOwnerAccess kubeClientModel.AccessLevel) error {
+	// new owner permission will be inserted so drop existing one
+	_, err := pgdb.db.Model(&model.Permission{}).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resource.ID).
+		Where("user_id = ?", newOwnerID).
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	// old owner permission must be demoted before insert, only one owner allowed
+	oldOwnerPerm := pgdb.db.Model(&model.Permission{}).
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resource.ID).
+		Where("user_id = ?", resource.OwnerUserID)
+	if oldOwnerAccess == kubeClientModel.None {
+		_, err = oldOwnerPerm.Delete()
+	} else {
+		_, err = oldOwnerPerm.
+			Set("initial_access_level = ?", oldOwnerAccess).
+			Set("current_access_level = LEAST(current_access_level, ?)::ACCESS_LEVEL", oldOwnerAccess).
+			Set("access_level_change_time = now()").
+			Update()
+	}
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	_, err = pgdb.db.Model(&model.Permission{
+		ResourceType:       kind,
+		ResourceID:         resource.ID,
+		UserID:             newOwnerID,
+		InitialAccessLevel: kubeClientModel.Owner,
+		CurrentAccessLevel: kubeClientModel.Owner,
+	}).Insert()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) TransferNamespace(ctx context.Context, namespace *model.Namespace, newOwnerID string, oldOwnerAccess kubeClientModel.AccessLevel) (transferredVolumes []model.Volume, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"new_owner_id":     newOwnerID,
+		"old_owner_access": oldOwnerAccess,
+	}).Debugf("transfer namespace %+v", namespace)
+
+	cnt, err := pgdb.db.Model(&model.Namespace{}).
+		Where("owner_user_id = ?", newOwnerID).
+		Where("label = ?", namespace.Label).
+		Where("NOT deleted").
+		Count()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+	if cnt > 0 {
+		err = errors.ErrResourceAlreadyExists().AddDetailF("namespace %s already exists for new owner", namespace.Label)
+		return
+	}
+
+	transferredVolumes = make([]model.Volume, 0)
+	err = pgdb.db.Model(&transferredVolumes).
+		Where("namespace_id = ?", namespace.ID).
+		Where("NOT deleted").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	if err = pgdb.transferResourceOwner(ctx, namespace.Resource, model.ResourceNamespace, newOwnerID, oldOwnerAccess); err != nil {
+		return
+	}
+
+	for _, vol := range transferredVolumes {
+		if err = pgdb.transferResourceOwner(ctx, vol.Resource, model.ResourceVolume, newOwnerID, oldOwnerAccess); err != nil {
+			return
+		}
+	}
+
+	_, err = pgdb.db.Model(&model.Volume{}).
+		Where("namespace_id = ?", namespace.ID).
+		Where("NOT deleted").
+		Set("owner_user_id = ?", newOwnerID).
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	// project belongs to previous owner
+	namespace.OwnerUserID = newOwnerID
+	namespace.ProjectID = nil
+	_, err = pgdb.db.Model(namespace).
+		WherePK().
+		Set("owner_user_id = ?owner_user_id").
+		Set("project_id = ?project_id").
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	for i := range transferredVolumes {
+		transferredVolumes[i].OwnerUserID = newOwnerID
+	}
+
+	return
+}
+
+func (pgdb *PgDB) DeleteGroupFromNamespace(ctx context.Context, ns model.Namespace, groupID string) (deletedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"namespace_id": ns.ID,
+		"group_id":     groupID,
+	}).Debugf("delete group from namespace")
+
+	return pgdb.deleteGroupFromResource(ctx, model.ResourceNamespace, ns.ID, groupID)
+}
+
+func (pgdb *PgDB) CopyNamespacePermissions(ctx context.Context, from, to model.Namespace) (copiedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"from": from.ID,
+		"to":   to.ID,
+	}).Debugf("copy namespace permissions")
+
+	_, err = pgdb.db.Model(&model.GroupPermission{}).Exec( /* language=sql */
+		`INSERT INTO "?TableName" (resource_type, resource_id, group_id, access_level, role_mapping)
+		SELECT resource_type, ?2, group_id, access_level, role_mapping
+		FROM "?TableName"
+		WHERE resource_type = ?0 AND resource_id = ?1
+		ON CONFLICT DO NOTHING`, model.ResourceNamespace, from.ID, to.ID)
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	// owner of new namespace already has permission, service accounts are bound to source namespace
+	_, err = pgdb.db.Model(&model.Permission{}).Query(&copiedPerms, /* language=sql */
+		`INSERT INTO "?TableName" (resource_type, resource_id, user_id,
+			initial_access_level, current_access_level, group_id, expires_at, role)
+		SELECT resource_type, ?2, user_id,
+			initial_access_level, current_access_level, group_id, expires_at, role
+		FROM "?TableName"
+		WHERE resource_type = ?0 AND resource_id = ?1 AND initial_access_level < ?3 AND user_id != ?4
+			AND user_id NOT IN (SELECT id FROM service_accounts)
+		ON CONFLICT DO NOTHING
+		RETURNING *`, model.ResourceNamespace, from.ID, to.ID, kubeClientModel.Owner, to.OwnerUserID)
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	return copiedPerms, nil
+}
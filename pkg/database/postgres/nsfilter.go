@@ -27,6 +27,32 @@ func (f *NamespaceFilter) Filter(q *orm.Query) (*orm.Query, error) {
 	if f.NotOwned {
 		q = q.Where("permission.initial_access_level != ?", kubeClientModel.Owner)
 	}
+	for _, req := range f.LabelSelector {
+		switch req.Operator {
+		case database.LabelEquals:
+			q = q.Where("?TableAlias.labels ->> ? = ?", req.Key, req.Values[0])
+		case database.LabelNotEquals:
+			q = q.Where("?TableAlias.labels ->> ? IS DISTINCT FROM ?", req.Key, req.Values[0])
+		case database.LabelIn:
+			q = q.WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+				for _, v := range req.Values {
+					q = q.WhereOr("?TableAlias.labels ->> ? = ?", req.Key, v)
+				}
+				return q, nil
+			})
+		case database.LabelNotIn:
+			for _, v := range req.Values {
+				q = q.Where("?TableAlias.labels ->> ? IS DISTINCT FROM ?", req.Key, v)
+			}
+		case database.LabelExists:
+			q = q.Where("?TableAlias.labels ->> ? IS NOT NULL", req.Key)
+		case database.LabelNotExists:
+			q = q.Where("?TableAlias.labels ->> ? IS NULL", req.Key)
+		}
+	}
+	if f.OrganizationID != "" {
+		q = q.Where("?TableAlias.organization_id = ?", f.OrganizationID)
+	}
 	if f.Limit > 0 {
 		q = q.Apply(f.Paginate)
 	}
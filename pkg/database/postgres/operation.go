@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) CreateOperation(ctx context.Context, op *model.Operation) error {
+	pgdb.log.Debugf("create operation %+v", op)
+
+	_, err := pgdb.db.Model(op).
+		Returning("*").
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) OperationByID(ctx context.Context, id string) (ret model.Operation, err error) {
+	pgdb.log.WithField("id", id).Debugf("get operation")
+
+	ret.ID = id
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case nil:
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("operation %s not exists", id)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) NamespaceCleanupRunning(ctx context.Context, ns model.Namespace) (bool, error) {
+	pgdb.log.WithField("namespace_id", ns.ID).Debugf("check namespace cleanup running")
+
+	cnt, err := pgdb.db.Model(&model.Operation{}).
+		Where("status = ?", model.OperationRunning).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.
+				WhereOr("type = ? AND resource_id = ?", model.OperationNamespaceCleanup, ns.ID).
+				WhereOr("type = ? AND resource_id = ?", model.OperationUserNamespacesCleanup, ns.OwnerUserID), nil
+		}).
+		Count()
+	if err != nil {
+		return false, pgdb.handleError(err)
+	}
+
+	return cnt > 0, nil
+}
+
+func (pgdb *PgDB) UpdateOperation(ctx context.Context, op *model.Operation, expectedStatus model.OperationStatus) (updated bool, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"id":              op.ID,
+		"status":          op.Status,
+		"expected_status": expectedStatus,
+	}).Debugf("update operation")
+
+	// operation taken by other executor is not updated
+	result, err := pgdb.db.Model(op).
+		WherePK().
+		Where("status = ?", expectedStatus).
+		Set("status = ?status").
+		Set("steps = ?steps").
+		Set("attempts = ?attempts").
+		Set("last_error = ?last_error").
+		Set("update_time = now()").
+		Returning("update_time").
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	updated = result.RowsAffected() > 0
+	return
+}
+
+func (pgdb *PgDB) ClaimStaleOperations(ctx context.Context, updatedBefore time.Time, limit int) (ret []model.Operation, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"updated_before": updatedBefore,
+		"limit":          limit,
+	}).Debugf("claim stale operations")
+
+	// update time is refreshed so other service instances do not take claimed operations until lease timeout
+	ret = make([]model.Operation, 0)
+	_, err = pgdb.db.Model(&ret).
+		Set("update_time = now()").
+		Where( /* language=sql */
+			`id IN (SELECT id FROM operations WHERE status IN (?, ?) AND update_time < ? ORDER BY update_time LIMIT ? FOR UPDATE SKIP LOCKED)`,
+			model.OperationRunning, model.OperationCompensating, updatedBefore, limit).
+		Returning("*").
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) DeleteFinishedOperations(ctx context.Context, finishedBefore time.Time) (deleted int, err error) {
+	pgdb.log.WithField("finished_before", finishedBefore).Debugf("delete finished operations")
+
+	result, err := pgdb.db.Model(&model.Operation{}).
+		Where("status IN (?, ?)", model.OperationCompleted, model.OperationCompensated).
+		Where("update_time < ?", finishedBefore).
+		Delete()
+	if err != nil {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	deleted = result.RowsAffected()
+	return
+}
@@ -0,0 +1,283 @@
+package postgres
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+// organizationAdminOf returns subquery selecting organizations where user is admin.
+func (pgdb *PgDB) organizationAdminOf(userID string) *orm.Query {
+	return pgdb.db.Model(&model.OrganizationMember{}).
+		Column("organization_id").
+		Where("user_id = ?", userID).
+		Where("role = ?", model.OrganizationRoleAdmin)
+}
+
+func (pgdb *PgDB) CreateOrganization(ctx context.Context, org *model.Organization) error {
+	pgdb.log.Debugf("create organization %+v", org)
+
+	_, err := pgdb.db.Model(org).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) OrganizationByID(ctx context.Context, id string) (ret model.Organization, err error) {
+	pgdb.log.WithField("id", id).Debugf("get organization")
+
+	ret.ID = id
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case pg.ErrNoRows:
+		return ret, errors.ErrResourceNotExists().AddDetailF("organization %s not exists", id)
+	case nil:
+	default:
+		return ret, pgdb.handleError(err)
+	}
+
+	ret.Members = make([]model.OrganizationMember, 0)
+	err = pgdb.db.Model(&ret.Members).
+		Where("organization_id = ?", id).
+		Order("create_time").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UserOrganizations(ctx context.Context, userID string) (ret []model.Organization, err error) {
+	pgdb.log.WithField("user_id", userID).Debugf("get user organizations")
+
+	ret = make([]model.Organization, 0)
+	err = pgdb.db.Model(&ret).
+		Where("id IN (?)", pgdb.db.Model(&model.OrganizationMember{}).
+			Column("organization_id").
+			Where("user_id = ?", userID)).
+		Order("label").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) DeleteOrganization(ctx context.Context, org model.Organization) error {
+	pgdb.log.WithField("id", org.ID).Debugf("delete organization")
+
+	_, err := pgdb.db.Model(&org).
+		WherePK().
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) OrganizationMember(ctx context.Context, orgID, userID string) (ret model.OrganizationMember, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"organization_id": orgID,
+		"user_id":         userID,
+	}).Debugf("get organization member")
+
+	ret.OrganizationID = orgID
+	ret.UserID = userID
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrNotOrganizationMember().AddDetailF("user %s is not a member of organization %s", userID, orgID)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) SetOrganizationMember(ctx context.Context, member *model.OrganizationMember) error {
+	pgdb.log.Debugf("set organization member %+v", member)
+
+	_, err := pgdb.db.Model(member).
+		OnConflict(`(organization_id, user_id) DO UPDATE`).
+		Set(`role = EXCLUDED.role`).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) DeleteOrganizationMember(ctx context.Context, member model.OrganizationMember) (deletedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"organization_id": member.OrganizationID,
+		"user_id":         member.UserID,
+	}).Debugf("delete organization member")
+
+	// organization resources can be shared only with members
+	_, err = pgdb.db.Model(&deletedPerms).
+		Where("user_id = ?", member.UserID).
+		Where("initial_access_level < ?", kubeClientModel.Owner).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.
+				WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+					return q.
+						Where("resource_type = ?", model.ResourceNamespace).
+						Where("resource_id IN (?)", pgdb.db.Model(&model.Namespace{}).
+							Column("id").
+							Where("organization_id = ?", member.OrganizationID)), nil
+				}).
+				WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+					return q.
+						Where("resource_type = ?", model.ResourceProject).
+						Where("resource_id IN (?)", pgdb.db.Model(&model.Project{}).
+							Column("id").
+							Where("organization_id = ?", member.OrganizationID)), nil
+				}), nil
+		}).
+		Returning("*").
+		Delete()
+	if err != nil && err != pg.ErrNoRows {
+		return nil, pgdb.handleError(err)
+	}
+
+	_, err = pgdb.db.Model(&member).
+		WherePK().
+		Delete()
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	return deletedPerms, nil
+}
+
+func (pgdb *PgDB) OrganizationOwnedResourcesCount(ctx context.Context, orgID, userID string) (int, error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"organization_id": orgID,
+		"user_id":         userID,
+	}).Debugf("count organization resources owned by user")
+
+	total := 0
+	for _, m := range []interface{}{&model.Namespace{}, &model.Project{}} {
+		cnt, err := pgdb.db.Model(m).
+			Where("organization_id = ?", orgID).
+			Where("owner_user_id = ?", userID).
+			Where("NOT deleted").
+			Count()
+		if err != nil {
+			return 0, pgdb.handleError(err)
+		}
+		total += cnt
+	}
+
+	return total, nil
+}
+
+func (pgdb *PgDB) ResourceNonMembers(ctx context.Context, orgID string, kind model.ResourceType, resourceID string) (ret []string, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"organization_id": orgID,
+		"kind":            kind,
+		"resource_id":     resourceID,
+	}).Debugf("get users with access to resource not in organization")
+
+	ret = make([]string, 0)
+	err = pgdb.db.Model(&model.Permission{}).
+		Column("user_id").
+		Where("resource_type = ?", kind).
+		Where("resource_id = ?", resourceID).
+		Where("user_id NOT IN (?)", pgdb.db.Model(&model.OrganizationMember{}).
+			Column("user_id").
+			Where("organization_id = ?", orgID)).
+		// service accounts are bound to resource itself
+		Where("user_id NOT IN (?)", pgdb.db.Model(&model.ServiceAccount{}).
+			Column("id")).
+		Select(&ret)
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) SetNamespaceOrganization(ctx context.Context, ns *model.Namespace, orgID *string) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"namespace":       ns.KubeName,
+		"organization_id": orgID,
+	}).Debugf("set namespace organization")
+
+	ns.OrganizationID = orgID
+	result, err := pgdb.db.Model(ns).
+		Where("NOT deleted").
+		WherePK().
+		Set("organization_id = ?organization_id").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("namespace %s not exists", ns.Label)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) SetProjectOrganization(ctx context.Context, project *model.Project, orgID *string) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"project_id":      project.ID,
+		"organization_id": orgID,
+	}).Debugf("set project organization")
+
+	project.OrganizationID = orgID
+	result, err := pgdb.db.Model(project).
+		Where("NOT deleted").
+		WherePK().
+		Set("organization_id = ?organization_id").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("project %s not exists", project.Label)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) OrganizationProjects(ctx context.Context, orgID string) (ret []model.Project, err error) {
+	pgdb.log.WithField("organization_id", orgID).Debugf("get organization projects")
+
+	ret = make([]model.Project, 0)
+	err = pgdb.db.Model(&ret).
+		ColumnExpr("?TableAlias.*").
+		Column("Namespaces").
+		Relation("Namespaces", func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("NOT namespaces.deleted"), nil
+		}).
+		Where("?TableAlias.organization_id = ?", orgID).
+		Where("NOT ?TableAlias.deleted").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
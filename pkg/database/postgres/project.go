@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/errors"
 	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/sirupsen/logrus"
@@ -33,7 +35,12 @@ func (pgdb *PgDB) ProjectByID(ctx context.Context, project string) (p model.Proj
 		Relation("Namespaces", func(q *orm.Query) (*orm.Query, error) {
 			return q.Where("NOT namespaces.deleted"), nil
 		}).
+		Column("Permissions").
+		Relation("Permissions", func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("initial_access_level != ?", kubeClientModel.Owner), nil
+		}).
 		WherePK().
+		Where("NOT ?TableAlias.deleted").
 		Select()
 	switch err {
 	case pg.ErrNoRows:
@@ -45,20 +52,40 @@ func (pgdb *PgDB) ProjectByID(ctx context.Context, project string) (p model.Proj
 	return
 }
 
-func (pgdb *PgDB) DeleteGroupFromProject(ctx context.Context, projectID, groupID string) (deletedPerms []model.Permission, err error) {
-	pgdb.log.WithFields(logrus.Fields{
-		"project_id": projectID,
-		"group_id":   groupID,
-	}).Debugf("delete group from project")
+func (pgdb *PgDB) UserProjects(ctx context.Context, userID string) (ret []model.Project, err error) {
+	pgdb.log.WithField("user_id", userID).Debugf("get user projects")
+
+	ret = make([]model.Project, 0)
 
-	_, err = pgdb.db.Model(&deletedPerms).
-		Where("group_id = ?", groupID).
+	accessibleProjects := pgdb.db.Model(&model.Permission{}).
+		Column("resource_id").
+		Where("user_id = ?", userID).
+		Where("resource_type = ?", model.ResourceProject).
+		Where("current_access_level > ?", kubeClientModel.None)
+
+	accessibleNamespaces := pgdb.db.Model(&model.Permission{}).
+		Column("resource_id").
+		Where("user_id = ?", userID).
 		Where("resource_type = ?", model.ResourceNamespace).
-		Where("resource_id IN (?)", pgdb.db.Model(&model.Namespace{ProjectID: &projectID}).
-			Where("project_id = ?project_id")).
-		Returning("*").
-		Delete()
+		Where("current_access_level > ?", kubeClientModel.None)
 
+	err = pgdb.db.Model(&ret).
+		ColumnExpr("?TableAlias.*").
+		Column("Namespaces").
+		Relation("Namespaces", func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("NOT namespaces.deleted"), nil
+		}).
+		Where("NOT ?TableAlias.deleted").
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.
+				WhereOr("?TableAlias.owner_user_id = ?", userID).
+				WhereOr("?TableAlias.id IN (?)", accessibleProjects).
+				WhereOr("?TableAlias.id IN (?)", pgdb.db.Model(&model.Namespace{}).
+					Column("project_id").
+					Where("id IN (?)", accessibleNamespaces).
+					Where("NOT deleted")), nil
+		}).
+		Select()
 	switch err {
 	case pg.ErrNoRows:
 		err = nil
@@ -68,3 +95,103 @@ func (pgdb *PgDB) DeleteGroupFromProject(ctx context.Context, projectID, groupID
 
 	return
 }
+
+func (pgdb *PgDB) RenameProject(ctx context.Context, project *model.Project, newLabel string) error {
+	pgdb.log.WithField("new_label", newLabel).Debugf("rename project %+v", project)
+
+	cnt, err := pgdb.db.Model(project).
+		Where("owner_user_id = ?owner_user_id").
+		Where("label = ?", newLabel).
+		Where("NOT deleted").
+		Count()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("project %s already exists", newLabel)
+	}
+
+	_, err = pgdb.db.Model(project).
+		WherePK().
+		Set("label = ?", newLabel).
+		Returning("*").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) UpdateProjectMeta(ctx context.Context, project *model.Project) error {
+	pgdb.log.Debugf("update project meta %+v", project)
+
+	_, err := pgdb.db.Model(project).
+		WherePK().
+		Set("labels = ?labels").
+		Set("description = ?description").
+		Returning("*").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) DeleteProject(ctx context.Context, project *model.Project) error {
+	pgdb.log.Debugf("delete project %+v", project)
+
+	_, err := pgdb.db.Model(&model.Namespace{}).
+		Where("project_id = ?", project.ID).
+		Set("project_id = NULL").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	project.Deleted = true
+	now := time.Now().UTC()
+	project.DeleteTime = &now
+
+	result, err := pgdb.db.Model(project).
+		Where("NOT deleted").
+		WherePK().
+		Set("deleted = ?deleted").
+		Set("delete_time = ?delete_time").
+		Returning("*").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("project %s not exists", project.Label)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) SetNamespaceProject(ctx context.Context, ns *model.Namespace, projectID *string) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"namespace":  ns.KubeName,
+		"project_id": projectID,
+	}).Debugf("set namespace project")
+
+	ns.ProjectID = projectID
+	result, err := pgdb.db.Model(ns).
+		Where("NOT deleted").
+		WherePK().
+		Set("project_id = ?project_id").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("namespace %s not exists", ns.Label)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) DeleteGroupFromProject(ctx context.Context, project model.Project, groupID string) (deletedPerms []model.Permission, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"project_id": project.ID,
+		"group_id":   groupID,
+	}).Debugf("delete group from project")
+
+	return pgdb.deleteGroupFromResource(ctx, model.ResourceProject, project.ID, groupID)
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+)
+
+func (pgdb *PgDB) CreateRole(ctx context.Context, role *model.Role) error {
+	pgdb.log.Debugf("create role %+v", role)
+
+	cnt, err := pgdb.db.Model(role).WherePK().Count()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("role %s already exists", role.Name)
+	}
+
+	_, err = pgdb.db.Model(role).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) RoleByName(ctx context.Context, name string) (ret model.Role, err error) {
+	pgdb.log.WithField("name", name).Debugf("get role")
+
+	ret.Name = name
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("role %s not exists", name)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) AllRoles(ctx context.Context) (ret []model.Role, err error) {
+	pgdb.log.Debugf("get all roles")
+
+	ret = make([]model.Role, 0)
+	err = pgdb.db.Model(&ret).
+		Order("name").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UpdateRole(ctx context.Context, role *model.Role) (updatedPerms []model.Permission, err error) {
+	pgdb.log.Debugf("update role %+v", role)
+
+	result, err := pgdb.db.Model(role).
+		WherePK().
+		Set("description = ?description").
+		Set("access_level = ?access_level").
+		Set("actions = ?actions").
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return nil, errors.ErrResourceNotExists().AddDetailF("role %s not exists", role.Name)
+	}
+
+	// keep access level of granted permissions in sync with role
+	_, err = pgdb.db.Model(&updatedPerms).
+		Where("role = ?", role.Name).
+		Set("initial_access_level = ?", role.AccessLevel).
+		Set("current_access_level = ?", role.AccessLevel).
+		Returning("*").
+		Update()
+	if err != nil {
+		return nil, pgdb.handleError(err)
+	}
+
+	return updatedPerms, nil
+}
+
+func (pgdb *PgDB) DeleteRole(ctx context.Context, name string) error {
+	pgdb.log.WithField("name", name).Debugf("delete role")
+
+	// permissions keep access level of role, reference removed by foreign key
+	result, err := pgdb.db.Model(&model.Role{Name: name}).
+		WherePK().
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("role %s not exists", name)
+	}
+
+	_, err = pgdb.db.Model(&model.PermissionTombstone{}).
+		Where("role = ?", name).
+		Set("role = NULL").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) serviceAccountsQuery(ret interface{}) *orm.Query {
+	return pgdb.db.Model(ret).
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("perm.current_access_level AS access_level").
+		Join("LEFT JOIN permissions AS perm").
+		JoinOn("perm.user_id = ?TableAlias.id").
+		JoinOn("perm.resource_id = ?TableAlias.resource_id").
+		JoinOn("perm.resource_type = ?TableAlias.resource_type")
+}
+
+func (pgdb *PgDB) CreateServiceAccount(ctx context.Context, sa *model.ServiceAccount) error {
+	pgdb.log.Debugf("create service account %+v", sa)
+
+	_, err := pgdb.db.Model(sa).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) ResourceServiceAccounts(ctx context.Context, kind model.ResourceType, resourceID string) (ret []model.ServiceAccountWithAccess, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+	}).Debugf("get resource service accounts")
+
+	ret = make([]model.ServiceAccountWithAccess, 0)
+	err = pgdb.serviceAccountsQuery(&ret).
+		Where("?TableAlias.resource_type = ?", kind).
+		Where("?TableAlias.resource_id = ?", resourceID).
+		OrderExpr("?TableAlias.name").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) ServiceAccountByName(ctx context.Context, kind model.ResourceType, resourceID, name string) (ret model.ServiceAccountWithAccess, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"kind":        kind,
+		"resource_id": resourceID,
+		"name":        name,
+	}).Debugf("get service account by name")
+
+	err = pgdb.serviceAccountsQuery(&ret).
+		Where("?TableAlias.resource_type = ?", kind).
+		Where("?TableAlias.resource_id = ?", resourceID).
+		Where("?TableAlias.name = ?", name).
+		First()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("service account %s not exists", name)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) ServiceAccountByTokenHash(ctx context.Context, tokenHash string) (ret model.ServiceAccountWithAccess, err error) {
+	pgdb.log.Debugf("get service account by token")
+
+	err = pgdb.serviceAccountsQuery(&ret).
+		Where("?TableAlias.token_hash = ?", tokenHash).
+		First()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("service account with such token not exists")
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) RotateServiceAccountToken(ctx context.Context, sa *model.ServiceAccount, tokenHash string) error {
+	pgdb.log.WithField("id", sa.ID).Debugf("rotate service account token")
+
+	now := time.Now()
+	sa.TokenHash = tokenHash
+	sa.TokenRotateTime = &now
+	_, err := pgdb.db.Model(sa).
+		WherePK().
+		Set("token_hash = ?token_hash").
+		Set("token_rotate_time = ?token_rotate_time").
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) DeleteServiceAccount(ctx context.Context, sa model.ServiceAccount) error {
+	pgdb.log.WithField("id", sa.ID).Debugf("delete service account")
+
+	for _, m := range []interface{}{&model.Permission{}, &model.PermissionTombstone{}} {
+		if _, err := pgdb.db.Model(m).Where("user_id = ?", sa.ID).Delete(); err != nil {
+			return pgdb.handleError(err)
+		}
+	}
+
+	_, err := pgdb.db.Model(&sa).
+		WherePK().
+		Delete()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) VolumeByID(ctx context.Context, userID, id string, isAdmin bool) (ret model.VolumeWithPermissions, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"id":      id,
+		"user_id": userID,
+	}).Debugf("get volume by id")
+
+	ret.ID = id
+	if isAdmin {
+		err = pgdb.db.Model(&ret).
+			ColumnExpr("?TableAlias.*").
+			Column("Permission", "Namespace").
+			WherePK().
+			Where("NOT ?TableAlias.deleted").
+			First()
+	} else {
+		err = pgdb.db.Model(&ret).
+			ColumnExpr("?TableAlias.*").
+			Column("Permission", "Namespace").
+			WherePK().
+			Where("permission.resource_id = ?TableAlias.id").
+			Where("permission.user_id = ?", userID).
+			Where("coalesce(permission.current_access_level, ?0) > ?0", kubeClientModel.None).
+			Where("NOT ?TableAlias.deleted").
+			Select()
+	}
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("volume with id %s not exists", id)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) VolumePermissions(ctx context.Context, vol *model.VolumeWithPermissions) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"owner_user_id": vol.OwnerUserID,
+		"label":         vol.Label,
+	}).Debugf("get volume permissions")
+
+	err := pgdb.db.Model(vol).
+		WherePK().
+		Column("Permissions").
+		Relation("Permissions", func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("initial_access_level != ?", kubeClientModel.Owner), nil
+		}).
+		Select()
+	if len(vol.Permissions) == 0 {
+		vol.Permissions = make([]model.Permission, 0)
+	}
+	switch err {
+	case pg.ErrNoRows:
+		return nil
+	default:
+		return pgdb.handleError(err)
+	}
+}
+
+func (pgdb *PgDB) UserVolumes(ctx context.Context, userID string, filter database.NamespaceFilter) (ret []model.VolumeWithPermissions, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"filters": filter,
+	}).Debugf("get user volumes")
+
+	ret = make([]model.VolumeWithPermissions, 0)
+
+	f := NamespaceFilter(filter)
+	err = pgdb.db.Model(&ret).
+		ColumnExpr("?TableAlias.*").
+		Column("Permission", "Namespace").
+		Where("permission.user_id = ?", userID).
+		Apply(f.Filter).
+		Select()
+	switch err {
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("user has no volumes")
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) NamespaceVolumes(ctx context.Context, userID string, ns model.Namespace, isAdmin bool) (ret []model.VolumeWithPermissions, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"namespace": ns.KubeName,
+	}).Debugf("get namespace volumes")
+
+	ret = make([]model.VolumeWithPermissions, 0)
+
+	q := pgdb.db.Model(&ret).
+		ColumnExpr("?TableAlias.*").
+		Where("?TableAlias.namespace_id = ?", ns.ID).
+		Where("NOT ?TableAlias.deleted")
+	if isAdmin {
+		q = q.Column("Namespace")
+	} else {
+		q = q.Column("Permission", "Namespace").
+			Where("permission.user_id = ?", userID)
+	}
+	err = q.Select()
+	switch err {
+	case pg.ErrNoRows:
+		err = nil
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) CreateVolume(ctx context.Context, volume *model.Volume) error {
+	pgdb.log.Debugf("create volume %+v", volume)
+
+	_, err := pgdb.db.Model(volume).
+		Returning("*").
+		Insert()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return err
+}
+
+func (pgdb *PgDB) RenameVolume(ctx context.Context, volume *model.Volume, newLabel string) error {
+	pgdb.log.WithField("new_label", newLabel).Debugf("rename volume %+v", volume)
+
+	cnt, err := pgdb.db.Model(volume).
+		Where("namespace_id = ?namespace_id").
+		Where("label = ?", newLabel).
+		Where("NOT deleted").
+		Count()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("volume %s already exists", newLabel)
+	}
+
+	_, err = pgdb.db.Model(volume).
+		WherePK().
+		Set("label = ?", newLabel).
+		Returning("*").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) DeleteVolume(ctx context.Context, volume *model.Volume) error {
+	pgdb.log.Debugf("delete volume %+v", volume)
+
+	volume.Deleted = true
+	now := time.Now().UTC()
+	volume.DeleteTime = &now
+
+	result, err := pgdb.db.Model(volume).
+		Where("NOT deleted").
+		WherePK().
+		Set("deleted = ?deleted").
+		Set("delete_time = ?delete_time").
+		Returning("*").
+		Update()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	if result.RowsAffected() <= 0 {
+		return errors.ErrResourceNotExists().AddDetailF("volume %s not exists", volume.Label)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) DeleteNamespaceVolumes(ctx context.Context, ns model.Namespace) (deleted []model.Volume, err error) {
+	pgdb.log.WithField("namespace", ns.KubeName).Debugf("delete namespace volumes")
+
+	deleted = make([]model.Volume, 0)
+
+	_, err = pgdb.db.Model(&deleted).
+		Where("namespace_id = ?", ns.ID).
+		Where("NOT deleted").
+		Set("deleted = TRUE").
+		// volumes deleted with namespace are marked by namespace delete time to restore them together
+		Set("delete_time = COALESCE(?, now())", ns.DeleteTime).
+		Returning("*").
+		Update()
+	switch err {
+	case pg.ErrNoRows:
+		err = nil
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) RestoreNamespaceVolumes(ctx context.Context, ns model.Namespace) (restored []model.Volume, err error) {
+	pgdb.log.WithField("namespace", ns.KubeName).Debugf("restore namespace volumes")
+
+	restored = make([]model.Volume, 0)
+	if ns.DeleteTime == nil {
+		return
+	}
+
+	_, err = pgdb.db.Model(&restored).
+		Where("namespace_id = ?", ns.ID).
+		Where("deleted").
+		Where("delete_time = ?", *ns.DeleteTime).
+		Set("deleted = FALSE").
+		Set("delete_time = NULL").
+		Returning("*").
+		Update()
+	switch err {
+	case pg.ErrNoRows:
+		err = nil
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+)
+
+func (pgdb *PgDB) CreateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	pgdb.log.Debugf("create webhook subscription %+v", sub)
+
+	_, err := pgdb.db.Model(sub).
+		Returning("*").
+		Insert()
+	if err != nil {
+		return pgdb.handleError(err)
+	}
+
+	return nil
+}
+
+func (pgdb *PgDB) WebhookSubscriptionByID(ctx context.Context, id string) (ret model.WebhookSubscription, err error) {
+	pgdb.log.WithField("id", id).Debugf("get webhook subscription")
+
+	ret.ID = id
+	err = pgdb.db.Model(&ret).
+		WherePK().
+		Select()
+	switch err {
+	case nil:
+	case pg.ErrNoRows:
+		err = errors.ErrResourceNotExists().AddDetailF("webhook subscription %s not exists", id)
+	default:
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UserWebhookSubscriptions(ctx context.Context, userID string) (ret []model.WebhookSubscription, err error) {
+	pgdb.log.WithField("user_id", userID).Debugf("get user webhook subscriptions")
+
+	ret = make([]model.WebhookSubscription, 0)
+	err = pgdb.db.Model(&ret).
+		Where("owner_user_id = ?", userID).
+		Order("create_time").
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) NamespaceWebhookSubscriptions(ctx context.Context, nsID string) (ret []model.WebhookSubscription, err error) {
+	pgdb.log.WithField("namespace_id", nsID).Debugf("get namespace webhook subscriptions")
+
+	// subscriptions of previous owners of transferred namespace are ignored
+	ret = make([]model.WebhookSubscription, 0)
+	err = pgdb.db.Model(&ret).
+		Where( /* language=sql */
+			`owner_user_id = (SELECT owner_user_id FROM namespaces WHERE id = ?0) AND (namespace_id = ?0 OR namespace_id IS NULL)`, nsID).
+		Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UpdateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	pgdb.log.Debugf("update webhook subscription %+v", sub)
+
+	_, err := pgdb.db.Model(sub).
+		WherePK().
+		Column("url", "secret", "event_types").
+		Returning("*").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) DeleteWebhookSubscription(ctx context.Context, sub model.WebhookSubscription) error {
+	pgdb.log.WithField("id", sub.ID).Debugf("delete webhook subscription")
+
+	// delivery log is deleted by foreign key
+	_, err := pgdb.db.Model(&sub).
+		WherePK().
+		Delete()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) AddWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	pgdb.log.Debugf("add webhook deliveries %+v", deliveries)
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := pgdb.db.Model(&deliveries).
+		Returning("*").
+		Insert()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) ClaimPendingWebhookDeliveries(ctx context.Context, claimFor time.Duration, limit int) (ret []model.WebhookDelivery, err error) {
+	pgdb.log.WithFields(logrus.Fields{
+		"claim_for": claimFor,
+		"limit":     limit,
+	}).Debugf("claim pending webhook deliveries")
+
+	// locked deliveries are being claimed by other service instance
+	ret = make([]model.WebhookDelivery, 0)
+	err = pgdb.db.Model(&ret).
+		Relation("Subscription").
+		Where("?TableAlias.status = ?", model.WebhookDeliveryPending).
+		Where("?TableAlias.next_attempt_time <= now()").
+		Order("webhook_delivery.id").
+		Limit(limit).
+		For("UPDATE OF ?TableAlias SKIP LOCKED").
+		Select()
+	if err != nil || len(ret) == 0 {
+		err = pgdb.handleError(err)
+		return
+	}
+
+	ids := make([]int64, len(ret))
+	for i := range ret {
+		ids[i] = ret[i].ID
+	}
+
+	// claimed deliveries are not taken by other service instances while they are sent
+	_, err = pgdb.db.Model(&model.WebhookDelivery{}).
+		Set("next_attempt_time = ?", time.Now().Add(claimFor)).
+		Where("id IN (?)", pg.In(ids)).
+		Update()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
+
+func (pgdb *PgDB) UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	pgdb.log.WithFields(logrus.Fields{
+		"id":       delivery.ID,
+		"status":   delivery.Status,
+		"attempts": delivery.Attempts,
+	}).Debugf("update webhook delivery")
+
+	_, err := pgdb.db.Model(delivery).
+		WherePK().
+		Column("status", "attempts", "next_attempt_time", "response_status", "last_error", "delivery_time").
+		Update()
+	return pgdb.handleError(err)
+}
+
+func (pgdb *PgDB) WebhookDeliveries(ctx context.Context, subscriptionID string, pager orm.Pager) (ret []model.WebhookDelivery, err error) {
+	pgdb.log.WithField("subscription_id", subscriptionID).Debugf("get webhook deliveries")
+
+	ret = make([]model.WebhookDelivery, 0)
+	q := pgdb.db.Model(&ret).
+		Where("subscription_id = ?", subscriptionID).
+		Order("id DESC")
+	if pager.Limit > 0 {
+		q = q.Apply(pager.Paginate)
+	}
+	err = q.Select()
+	if err != nil {
+		err = pgdb.handleError(err)
+	}
+
+	return
+}
@@ -3,13 +3,15 @@ package database
 import (
 	"context"
 	"io"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/model"
 	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg/orm"
 )
 
 type AccessWithLabel struct {
-	model.Permission `pg:",override"`
+	model.EffectivePermission `pg:",override"`
 
 	Label string `sql:"label"`
 }
@@ -18,15 +20,27 @@ type AccessListElement struct {
 	AccessLevel kubeClientModel.AccessLevel
 	ToUserID    string
 	GroupID     *string
+	ExpiresAt   *time.Time
+	Role        *string
 }
 
 type DB interface {
 	UserAccesses(ctx context.Context, userID string) ([]AccessWithLabel, error)
 	SetUserAccesses(ctx context.Context, userID string, level kubeClientModel.AccessLevel) error
-	SetNamespaceAccess(ctx context.Context, ns model.Namespace, accessLevel kubeClientModel.AccessLevel, toUserID string) error
+	SetNamespaceAccess(ctx context.Context, ns model.Namespace, accessLevel kubeClientModel.AccessLevel, toUserID string, expiresAt *time.Time) error
 	SetNamespaceAccesses(ctx context.Context, ns model.Namespace, accessList []AccessListElement) error
 	SetNamespacesAccesses(ctx context.Context, namespaces []model.Namespace, accessList []AccessListElement) error
 	DeleteNamespaceAccess(ctx context.Context, ns model.Namespace, userID string) error
+	EffectivePermission(ctx context.Context, kind model.ResourceType, resourceID, userID string) (*model.EffectivePermission, error)
+	UserNamespacePermissions(ctx context.Context, ns model.Namespace, userID string) ([]model.Permission, error)
+	UserGroupGrants(ctx context.Context, kind model.ResourceType, resourceID, userID string) ([]model.Permission, error)
+	SetVolumeAccess(ctx context.Context, vol model.Volume, accessLevel kubeClientModel.AccessLevel, toUserID string, expiresAt *time.Time) error
+	DeleteVolumeAccess(ctx context.Context, vol model.Volume, userID string) error
+	SetProjectAccess(ctx context.Context, project model.Project, accessLevel kubeClientModel.AccessLevel, toUserID string) error
+	SetProjectAccesses(ctx context.Context, project model.Project, accessList []AccessListElement) error
+	DeleteProjectAccess(ctx context.Context, project model.Project, userID string) error
+	ExpiredPermissions(ctx context.Context, expiredAt time.Time) (ret []model.Permission, err error)
+	DeleteExpiredPermissions(ctx context.Context, kind model.ResourceType, resourceID string, expiredAt time.Time) (deletedPerms []model.Permission, err error)
 
 	NamespaceByName(ctx context.Context, userID, name string, isAdmin bool) (ret model.NamespaceWithPermissions, err error)
 	NamespacePermissions(ctx context.Context, ns *model.NamespaceWithPermissions) error
@@ -35,16 +49,125 @@ type DB interface {
 	CreateNamespace(ctx context.Context, namespace *model.Namespace) error
 	RenameNamespace(ctx context.Context, namespace *model.Namespace, newLabel string) error
 	ResizeNamespace(ctx context.Context, namespace model.Namespace) error
+	UpdateNamespaceMeta(ctx context.Context, namespace *model.Namespace) error
 	DeleteNamespace(ctx context.Context, namespace *model.Namespace) error
 	DeleteAllUserNamespaces(ctx context.Context, userID string) (deleted []model.Namespace, err error)
-	DeleteGroupFromNamespace(ctx context.Context, namespace, groupID string) (deletedPerms []model.Permission, err error)
+	DeletedNamespaceByName(ctx context.Context, name string) (ret model.Namespace, err error)
+	RestoreNamespace(ctx context.Context, namespace *model.Namespace) error
+	CopyNamespacePermissions(ctx context.Context, from, to model.Namespace) (copiedPerms []model.Permission, err error)
+	DeletedNamespacePermission(ctx context.Context, ns model.Namespace, userID string) (ret model.Permission, err error)
+	PurgeTombstones(ctx context.Context, deletedBefore time.Time) (purged int, err error)
+	TransferNamespace(ctx context.Context, namespace *model.Namespace, newOwnerID string, oldOwnerAccess kubeClientModel.AccessLevel) (transferredVolumes []model.Volume, err error)
+	DeleteGroupFromNamespace(ctx context.Context, ns model.Namespace, groupID string) (deletedPerms []model.Permission, err error)
 	GroupNamespaces(ctx context.Context, groupID string) (ret []model.NamespaceWithPermissions, err error)
 
+	VolumeByID(ctx context.Context, userID, id string, isAdmin bool) (ret model.VolumeWithPermissions, err error)
+	VolumePermissions(ctx context.Context, vol *model.VolumeWithPermissions) error
+	UserVolumes(ctx context.Context, userID string, filter NamespaceFilter) (ret []model.VolumeWithPermissions, err error)
+	NamespaceVolumes(ctx context.Context, userID string, ns model.Namespace, isAdmin bool) (ret []model.VolumeWithPermissions, err error)
+	CreateVolume(ctx context.Context, volume *model.Volume) error
+	RenameVolume(ctx context.Context, volume *model.Volume, newLabel string) error
+	DeleteVolume(ctx context.Context, volume *model.Volume) error
+	DeleteNamespaceVolumes(ctx context.Context, ns model.Namespace) (deleted []model.Volume, err error)
+	RestoreNamespaceVolumes(ctx context.Context, ns model.Namespace) (restored []model.Volume, err error)
+
 	CreateProject(ctx context.Context, project *model.Project) error
 	ProjectByID(ctx context.Context, project string) (model.Project, error)
-	DeleteGroupFromProject(ctx context.Context, projectID, groupID string) (deletedPerms []model.Permission, err error)
+	UserProjects(ctx context.Context, userID string) ([]model.Project, error)
+	RenameProject(ctx context.Context, project *model.Project, newLabel string) error
+	UpdateProjectMeta(ctx context.Context, project *model.Project) error
+	DeleteProject(ctx context.Context, project *model.Project) error
+	SetNamespaceProject(ctx context.Context, ns *model.Namespace, projectID *string) error
+	DeleteGroupFromProject(ctx context.Context, project model.Project, groupID string) (deletedPerms []model.Permission, err error)
+
+	CreateAccessRequest(ctx context.Context, req *model.AccessRequest) error
+	AccessRequestByID(ctx context.Context, id string) (model.AccessRequestWithResource, error)
+	NamespaceAccessRequests(ctx context.Context, ns model.Namespace, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	OwnerAccessRequests(ctx context.Context, ownerID string, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	UserAccessRequests(ctx context.Context, userID string, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	ResolveAccessRequest(ctx context.Context, req *model.AccessRequest) error
+
+	CreateInvitation(ctx context.Context, invitation *model.Invitation) error
+	NamespaceInvitations(ctx context.Context, ns model.Namespace) ([]model.Invitation, error)
+	DeleteNamespaceInvitation(ctx context.Context, ns model.Namespace, email string) error
+	AcceptInvitations(ctx context.Context, userID, email string) (accepted []model.Invitation, err error)
+
+	CreateRole(ctx context.Context, role *model.Role) error
+	RoleByName(ctx context.Context, name string) (model.Role, error)
+	AllRoles(ctx context.Context) ([]model.Role, error)
+	UpdateRole(ctx context.Context, role *model.Role) (updatedPerms []model.Permission, err error)
+	DeleteRole(ctx context.Context, name string) error
+
+	CreateServiceAccount(ctx context.Context, sa *model.ServiceAccount) error
+	ResourceServiceAccounts(ctx context.Context, kind model.ResourceType, resourceID string) ([]model.ServiceAccountWithAccess, error)
+	ServiceAccountByName(ctx context.Context, kind model.ResourceType, resourceID, name string) (model.ServiceAccountWithAccess, error)
+	ServiceAccountByTokenHash(ctx context.Context, tokenHash string) (model.ServiceAccountWithAccess, error)
+	RotateServiceAccountToken(ctx context.Context, sa *model.ServiceAccount, tokenHash string) error
+	DeleteServiceAccount(ctx context.Context, sa model.ServiceAccount) error
+
+	SetGroupPermission(ctx context.Context, perm *model.GroupPermission) error
+	DeleteGroupPermission(ctx context.Context, kind model.ResourceType, resourceID, groupID string) error
+	ResourceGroupPermissions(ctx context.Context, kind model.ResourceType, resourceID string) ([]model.GroupPermission, error)
+	GroupPermissions(ctx context.Context, groupID string) ([]model.GroupPermission, error)
+	GrantedGroupIDs(ctx context.Context) ([]string, error)
+	SetGroupMembers(ctx context.Context, groupID string, members []model.GroupMember) error
+	MaterializeGroupPermissions(ctx context.Context, kind model.ResourceType, resourceID string) (changedPerms []model.Permission, err error)
+
+	CreateOrganization(ctx context.Context, org *model.Organization) error
+	OrganizationByID(ctx context.Context, id string) (model.Organization, error)
+	UserOrganizations(ctx context.Context, userID string) ([]model.Organization, error)
+	DeleteOrganization(ctx context.Context, org model.Organization) error
+	OrganizationMember(ctx context.Context, orgID, userID string) (model.OrganizationMember, error)
+	SetOrganizationMember(ctx context.Context, member *model.OrganizationMember) error
+	DeleteOrganizationMember(ctx context.Context, member model.OrganizationMember) (deletedPerms []model.Permission, err error)
+	OrganizationOwnedResourcesCount(ctx context.Context, orgID, userID string) (int, error)
+	ResourceNonMembers(ctx context.Context, orgID string, kind model.ResourceType, resourceID string) ([]string, error)
+	SetNamespaceOrganization(ctx context.Context, ns *model.Namespace, orgID *string) error
+	SetProjectOrganization(ctx context.Context, project *model.Project, orgID *string) error
+	OrganizationProjects(ctx context.Context, orgID string) ([]model.Project, error)
+
+	UserLimits(ctx context.Context, userID string) (model.UserLimits, error)
+	SetUserLimits(ctx context.Context, limits *model.UserLimits) error
+	UserResourcesUsage(ctx context.Context, userID string) (model.ResourcesLimits, error)
+
+	AddAuditRecord(ctx context.Context, record *model.AuditRecord) error
+	AuditRecords(ctx context.Context, filter AuditFilter) ([]model.AuditRecord, error)
+
+	AddEvent(ctx context.Context, event *model.Event) error
+	// TryLockEventsDispatch takes lock for events dispatch until end of transaction, returns false if lock is taken by other transaction
+	TryLockEventsDispatch(ctx context.Context) (bool, error)
+	// ClaimPendingEvents returns events ready to send postponing them for claim period.
+	// Event is not returned while previous event of the same resource is postponed.
+	ClaimPendingEvents(ctx context.Context, claimFor time.Duration, limit int) ([]model.Event, error)
+	UpdateEvent(ctx context.Context, event *model.Event) error
+	// DeleteDeliveredEvents deletes events delivered or failed before given time
+	DeleteDeliveredEvents(ctx context.Context, deliveredBefore time.Time) (int, error)
+
+	CreateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	WebhookSubscriptionByID(ctx context.Context, id string) (model.WebhookSubscription, error)
+	UserWebhookSubscriptions(ctx context.Context, userID string) ([]model.WebhookSubscription, error)
+	// NamespaceWebhookSubscriptions returns subscriptions of namespace owner to namespace and to all its namespaces
+	NamespaceWebhookSubscriptions(ctx context.Context, nsID string) ([]model.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, sub model.WebhookSubscription) error
+	AddWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	// ClaimPendingWebhookDeliveries returns deliveries ready for next attempt postponing them for claim period
+	ClaimPendingWebhookDeliveries(ctx context.Context, claimFor time.Duration, limit int) ([]model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	WebhookDeliveries(ctx context.Context, subscriptionID string, pager orm.Pager) ([]model.WebhookDelivery, error)
+
+	CreateOperation(ctx context.Context, op *model.Operation) error
+	OperationByID(ctx context.Context, id string) (model.Operation, error)
+	// NamespaceCleanupRunning returns true if cleanup of deleted namespace or all namespaces of its owner is not finished
+	NamespaceCleanupRunning(ctx context.Context, ns model.Namespace) (bool, error)
+	// UpdateOperation saves operation state if operation has expected status, returns false otherwise
+	UpdateOperation(ctx context.Context, op *model.Operation, expectedStatus model.OperationStatus) (bool, error)
+	// ClaimStaleOperations refreshes update time of unfinished operations not updated since given time and returns them
+	ClaimStaleOperations(ctx context.Context, updatedBefore time.Time, limit int) ([]model.Operation, error)
+	DeleteFinishedOperations(ctx context.Context, finishedBefore time.Time) (int, error)
 
-	Transactional(fn func(tx DB) error) error
+	// Transactional runs fn in transaction. Transaction of dry run request is always rolled back.
+	Transactional(ctx context.Context, fn func(tx DB) error) error
 
 	io.Closer
 }
@@ -0,0 +1,136 @@
+// Package dryrun collects changes made by request executed in dry run mode.
+// Request context carries recorder, database rolls back transactions and external clients skip calls if it is set.
+package dryrun
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+)
+
+type recorderKey struct{}
+
+// Recorder accumulates changes of dry run request.
+type Recorder struct {
+	mu   sync.Mutex
+	diff model.DryRunDiff
+
+	refreshedUsers map[string]bool
+}
+
+// NewContext returns context of dry run request and recorder attached to it.
+func NewContext(ctx context.Context) (context.Context, *Recorder) {
+	rec := &Recorder{
+		diff: model.DryRunDiff{
+			PermissionsAdded:   []model.Permission{},
+			PermissionsChanged: []model.PermissionChange{},
+			PermissionsRemoved: []model.Permission{},
+			RefreshedUsers:     []string{},
+			SideEffects:        []model.SideEffect{},
+		},
+		refreshedUsers: make(map[string]bool),
+	}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+// FromContext returns recorder of dry run request or nil if request is not a dry run.
+func FromContext(ctx context.Context) *Recorder {
+	rec, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return rec
+}
+
+type permissionKey struct {
+	kind       model.ResourceType
+	resourceID string
+	userID     string
+}
+
+func keyOf(perm model.Permission) permissionKey {
+	return permissionKey{kind: perm.ResourceType, resourceID: perm.ResourceID, userID: perm.UserID}
+}
+
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// samePermission compares meaningful fields of permission rows.
+// Rows may be recreated with new id and timestamps without real change of access.
+func samePermission(a, b model.Permission) bool {
+	return a.InitialAccessLevel == b.InitialAccessLevel &&
+		a.CurrentAccessLevel == b.CurrentAccessLevel &&
+		equalStrings(a.GroupID, b.GroupID) &&
+		equalStrings(a.Role, b.Role) &&
+		equalTimes(a.ExpiresAt, b.ExpiresAt)
+}
+
+// AddPermissions records difference between permission rows before and after transaction.
+func (r *Recorder) AddPermissions(before, after []model.Permission) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	beforeByKey := make(map[permissionKey]model.Permission, len(before))
+	for _, v := range before {
+		beforeByKey[keyOf(v)] = v
+	}
+
+	for _, v := range after {
+		old, exists := beforeByKey[keyOf(v)]
+		delete(beforeByKey, keyOf(v))
+		switch {
+		case !exists:
+			r.diff.PermissionsAdded = append(r.diff.PermissionsAdded, v)
+		case !samePermission(old, v):
+			r.diff.PermissionsChanged = append(r.diff.PermissionsChanged, model.PermissionChange{Before: old, After: v})
+		}
+	}
+
+	for _, v := range before {
+		if _, removed := beforeByKey[keyOf(v)]; removed {
+			r.diff.PermissionsRemoved = append(r.diff.PermissionsRemoved, v)
+		}
+	}
+}
+
+// RefreshUser records update of user accesses in auth.
+func (r *Recorder) RefreshUser(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.refreshedUsers[userID] {
+		return
+	}
+	r.refreshedUsers[userID] = true
+	r.diff.RefreshedUsers = append(r.diff.RefreshedUsers, userID)
+}
+
+// SideEffect records skipped call of external service.
+func (r *Recorder) SideEffect(service, action, resource string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.diff.SideEffects = append(r.diff.SideEffects, model.SideEffect{
+		Service:  service,
+		Action:   action,
+		Resource: resource,
+	})
+}
+
+// Diff returns changes recorded so far.
+func (r *Recorder) Diff() model.DryRunDiff {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.diff
+}
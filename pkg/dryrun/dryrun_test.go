@@ -0,0 +1,126 @@
+package dryrun
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+)
+
+func testPermission(resourceID, userID string, access kubeClientModel.AccessLevel) model.Permission {
+	return model.Permission{
+		ResourceType:       model.ResourceNamespace,
+		ResourceID:         resourceID,
+		UserID:             userID,
+		InitialAccessLevel: access,
+		CurrentAccessLevel: access,
+	}
+}
+
+func TestRecorderAddPermissions(t *testing.T) {
+	groupID := "group"
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	read := testPermission("ns", "user", kubeClientModel.Read)
+	write := testPermission("ns", "user", kubeClientModel.Write)
+	other := testPermission("ns", "other", kubeClientModel.Read)
+
+	recreated := read
+	recreated.ID = "new-id"
+	recreated.CreateTime = &expiresAt
+
+	withGroup := read
+	withGroup.GroupID = &groupID
+
+	expiring := read
+	expiring.ExpiresAt = &expiresAt
+
+	sameExpiration := read
+	shiftedExpiresAt := expiresAt.In(time.FixedZone("UTC+3", 3*60*60))
+	sameExpiration.ExpiresAt = &shiftedExpiresAt
+
+	for _, tc := range []struct {
+		name          string
+		before, after []model.Permission
+		added         []model.Permission
+		changed       []model.PermissionChange
+		removed       []model.Permission
+	}{
+		{name: "no changes"},
+		{
+			name:  "added",
+			after: []model.Permission{read},
+			added: []model.Permission{read},
+		},
+		{
+			name:    "removed",
+			before:  []model.Permission{read, other},
+			after:   []model.Permission{other},
+			removed: []model.Permission{read},
+		},
+		{
+			name:    "access changed",
+			before:  []model.Permission{read},
+			after:   []model.Permission{write},
+			changed: []model.PermissionChange{{Before: read, After: write}},
+		},
+		{
+			name:    "group changed",
+			before:  []model.Permission{read},
+			after:   []model.Permission{withGroup},
+			changed: []model.PermissionChange{{Before: read, After: withGroup}},
+		},
+		{
+			name:    "expiration changed",
+			before:  []model.Permission{read},
+			after:   []model.Permission{expiring},
+			changed: []model.PermissionChange{{Before: read, After: expiring}},
+		},
+		{
+			name:   "same expiration in other location",
+			before: []model.Permission{expiring},
+			after:  []model.Permission{sameExpiration},
+		},
+		{
+			name:   "recreated without change",
+			before: []model.Permission{read},
+			after:  []model.Permission{recreated},
+		},
+		{
+			name:    "replaced by other user",
+			before:  []model.Permission{read},
+			after:   []model.Permission{other},
+			added:   []model.Permission{other},
+			removed: []model.Permission{read},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, rec := NewContext(context.Background())
+			rec.AddPermissions(tc.before, tc.after)
+			diff := rec.Diff()
+
+			if tc.added == nil {
+				tc.added = []model.Permission{}
+			}
+			if tc.changed == nil {
+				tc.changed = []model.PermissionChange{}
+			}
+			if tc.removed == nil {
+				tc.removed = []model.Permission{}
+			}
+
+			if !reflect.DeepEqual(tc.added, diff.PermissionsAdded) {
+				t.Errorf("expected added %+v, got %+v", tc.added, diff.PermissionsAdded)
+			}
+			if !reflect.DeepEqual(tc.changed, diff.PermissionsChanged) {
+				t.Errorf("expected changed %+v, got %+v", tc.changed, diff.PermissionsChanged)
+			}
+			if !reflect.DeepEqual(tc.removed, diff.PermissionsRemoved) {
+				t.Errorf("expected removed %+v, got %+v", tc.removed, diff.PermissionsRemoved)
+			}
+		})
+	}
+}
@@ -170,6 +170,63 @@ func ErrStorageDelete(params ...func(*cherry.Err)) *cherry.Err {
 	}
 	return err
 }
+
+func ErrRestorePeriodExpired(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Resource restore period expired", StatusHTTP: 410, ID: cherry.ErrID{SID: "permissions", Kind: 0xe}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+func ErrAccessRequestResolved(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Access request already resolved", StatusHTTP: 409, ID: cherry.ErrID{SID: "permissions", Kind: 0xf}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+func ErrNotOrganizationMember(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "User is not a member of organization", StatusHTTP: 403, ID: cherry.ErrID{SID: "permissions", Kind: 0x10}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+func ErrOperationAborted(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Operation aborted", StatusHTTP: 409, ID: cherry.ErrID{SID: "permissions", Kind: 0x11}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+func ErrCleanupNotFinished(params ...func(*cherry.Err)) *cherry.Err {
+	err := &cherry.Err{Message: "Cleanup of deleted resource is not finished", StatusHTTP: 409, ID: cherry.ErrID{SID: "permissions", Kind: 0x12}, Details: []string(nil), Fields: cherry.Fields(nil)}
+	for _, param := range params {
+		param(err)
+	}
+	for i, detail := range err.Details {
+		det := renderTemplate(detail)
+		err.Details[i] = det
+	}
+	return err
+}
+
 func renderTemplate(templText string) string {
 	buf := &bytes.Buffer{}
 	templ, err := template.New("").Parse(templText)
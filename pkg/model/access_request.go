@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg/orm"
+)
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending   AccessRequestStatus = "pending"
+	AccessRequestApproved  AccessRequestStatus = "approved"
+	AccessRequestDenied    AccessRequestStatus = "denied"
+	AccessRequestCancelled AccessRequestStatus = "cancelled"
+)
+
+// AccessRequest represents user request for access to resource.
+// Request can be resolved (approved, denied or cancelled) only once, when it is pending.
+//
+// swagger:model
+type AccessRequest struct {
+	tableName struct{} `sql:"access_requests"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull" json:"kind,omitempty"`
+
+	// swagger:strfmt uuid
+	ResourceID string `sql:"resource_id,type:uuid,notnull" json:"resource_id,omitempty"`
+
+	// swagger:strfmt uuid
+	UserID string `sql:"user_id,type:uuid,notnull" json:"user_id,omitempty"`
+
+	// swagger:strfmt email
+	UserLogin string `sql:"-" json:"user_login,omitempty"`
+
+	AccessLevel model.AccessLevel `sql:"access_level,type:ACCESS_LEVEL,notnull" json:"access"` // WARN: custom type here, do not forget create it
+
+	Justification string `sql:"justification,notnull" json:"justification,omitempty"`
+
+	Status AccessRequestStatus `sql:"status,notnull" json:"status"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+
+	ResolveTime *time.Time `sql:"resolve_time" json:"resolve_time,omitempty"`
+
+	// swagger:strfmt uuid
+	ResolvedBy *string `sql:"resolved_by,type:uuid" json:"resolved_by,omitempty"`
+
+	// Comment from user who resolved request
+	Comment string `sql:"comment,notnull" json:"comment,omitempty"`
+}
+
+func (ar *AccessRequest) BeforeInsert(db orm.DB) error {
+	cnt, err := db.Model(ar).
+		Where("resource_id = ?resource_id").
+		Where("user_id = ?user_id").
+		Where("status = ?", AccessRequestPending).
+		Count()
+	if err != nil {
+		return err
+	}
+
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("user already has pending access request for resource")
+	}
+
+	return nil
+}
+
+func (ar *AccessRequest) Mask() {
+	ar.ResourceID = ""
+	ar.UserID = ""
+	ar.ResolvedBy = nil
+}
+
+// AccessRequestWithResource is a response object for access request with requested resource information
+//
+// swagger:model
+type AccessRequestWithResource struct {
+	AccessRequest `pg:",override"`
+
+	ResourceLabel string `sql:"resource_label" json:"resource_label,omitempty"`
+
+	// Resource identifier used in API
+	ResourceName string `sql:"resource_name" json:"resource_name,omitempty"`
+}
+
+// CreateAccessRequestRequest contains parameters for requesting access to resource
+//
+// swagger:model
+type CreateAccessRequestRequest struct {
+	AccessLevel model.AccessLevel `json:"access" binding:"required"`
+
+	Justification string `json:"justification"`
+}
+
+// ResolveAccessRequestRequest contains parameters for approving or denying access request
+//
+// swagger:model
+type ResolveAccessRequestRequest struct {
+	Comment string `json:"comment"`
+}
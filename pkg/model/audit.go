@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AuditResourceOrganization ResourceType = "Organization"
+	AuditResourceRole         ResourceType = "Role"
+	AuditResourceUser         ResourceType = "User"
+	AuditResourceGroup        ResourceType = "Group"
+	AuditResourceWebhook      ResourceType = "Webhook"
+)
+
+// AuditState contains access level or quota of resource before or after change
+type AuditState map[string]interface{}
+
+// AuditRecord represents single change in append-only audit log
+//
+// swagger:model
+type AuditRecord struct {
+	tableName struct{} `sql:"audit_log"`
+
+	ID int64 `sql:"id,pk" json:"id"`
+
+	CreateTime time.Time `sql:"create_time,default:now(),notnull" json:"create_time"`
+
+	// ID of request from X-Request-ID header
+	RequestID string `sql:"request_id" json:"request_id,omitempty"`
+
+	// User made request, empty for changes made by service jobs
+	//
+	// swagger:strfmt uuid
+	ActorUserID string `sql:"actor_user_id,type:uuid" json:"actor_user_id,omitempty"`
+
+	// User on behalf of which admin made request, empty if user was not substituted
+	//
+	// swagger:strfmt uuid
+	EffectiveUserID string `sql:"effective_user_id,type:uuid" json:"effective_user_id,omitempty"`
+
+	// Name of changing action, i.e. "SetNamespaceAccess"
+	Action string `sql:"action,notnull" json:"action"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull" json:"kind"`
+
+	ResourceID string `sql:"resource_id,notnull" json:"resource_id"`
+
+	// User or group whose access was changed
+	Target string `sql:"target" json:"target,omitempty"`
+
+	Before AuditState `sql:"before,type:jsonb" json:"before,omitempty"`
+
+	After AuditState `sql:"after,type:jsonb" json:"after,omitempty"`
+}
+
+// AuditQuery contains filters for audit log records, empty fields are not applied
+type AuditQuery struct {
+	ResourceType ResourceType
+	ResourceID   string
+
+	// Login of user made changes
+	Actor string
+
+	From *time.Time
+	To   *time.Time
+
+	Page    int
+	PerPage int
+}
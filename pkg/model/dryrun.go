@@ -0,0 +1,37 @@
+package model
+
+// PermissionChange contains permission row before and after change
+//
+// swagger:model
+type PermissionChange struct {
+	Before Permission `json:"before"`
+	After  Permission `json:"after"`
+}
+
+// SideEffect describes call to external service which was skipped in dry run mode
+//
+// swagger:model
+type SideEffect struct {
+	// Service name, i.e. "kube-api" or "billing"
+	Service string `json:"service"`
+
+	// Method of service client
+	Action string `json:"action"`
+
+	// Namespace, volume or user affected by call
+	Resource string `json:"resource,omitempty"`
+}
+
+// DryRunDiff contains changes which would be made by request if it was not a dry run
+//
+// swagger:model
+type DryRunDiff struct {
+	PermissionsAdded   []Permission       `json:"permissions_added"`
+	PermissionsChanged []PermissionChange `json:"permissions_changed"`
+	PermissionsRemoved []Permission       `json:"permissions_removed"`
+
+	// IDs of users whose accesses in auth tokens would be refreshed
+	RefreshedUsers []string `json:"refreshed_users"`
+
+	SideEffects []SideEffect `json:"side_effects"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+)
+
+type EventType string
+
+const (
+	EventNamespaceCreated     EventType = "namespace.created"
+	EventNamespaceDeleted     EventType = "namespace.deleted"
+	EventNamespaceResized     EventType = "namespace.resized"
+	EventNamespaceTransferred EventType = "namespace.transferred"
+	EventAccessChanged        EventType = "access.changed"
+)
+
+// EventPayload contains event details depending on event type
+type EventPayload map[string]interface{}
+
+// Event is a domain event stored in outbox until it is delivered to all sinks.
+// Event may be delivered more than once so consumers should deduplicate events by ID.
+//
+// swagger:model
+type Event struct {
+	tableName struct{} `sql:"events_outbox"`
+
+	ID int64 `sql:"id,pk" json:"id"`
+
+	CreateTime time.Time `sql:"create_time,default:now(),notnull" json:"create_time"`
+
+	Type EventType `sql:"type,notnull" json:"type"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull" json:"kind"`
+
+	// Events of one resource are delivered in order of creation
+	ResourceID string `sql:"resource_id,notnull" json:"resource_id"`
+
+	// ID of request made change
+	RequestID string `sql:"request_id" json:"request_id,omitempty"`
+
+	Payload EventPayload `sql:"payload,type:jsonb" json:"payload,omitempty"`
+
+	Attempts int `sql:"attempts,notnull,default:0" json:"-"`
+
+	// Event is not sent before this time, claimed event is postponed until it is sent or claim expires
+	NextAttemptTime time.Time `sql:"next_attempt_time,default:now(),notnull" json:"-"`
+
+	LastError string `sql:"last_error" json:"-"`
+
+	DeliveredAt *time.Time `sql:"delivered_at" json:"-"`
+
+	// Event is not sent anymore after attempts limit is reached, next events of resource are sent without it
+	FailedAt *time.Time `sql:"failed_at" json:"-"`
+}
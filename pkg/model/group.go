@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/containerum/kube-client/pkg/model"
+)
+
+// GroupPermission represents access to resource granted to user group.
+// Group members get permissions with access of their group role but not greater than granted one.
+// Direct permission of user always has precedence over group permissions,
+// if user is a member of several groups the greatest access is used.
+//
+// swagger:model
+type GroupPermission struct {
+	tableName struct{} `sql:"group_permissions"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id,omitempty"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull,unique:unique_group_access" json:"kind,omitempty"`
+
+	// swagger:strfmt uuid
+	ResourceID string `sql:"resource_id,type:uuid,notnull,unique:unique_group_access" json:"resource_id,omitempty"`
+
+	// swagger:strfmt uuid
+	GroupID string `sql:"group_id,type:uuid,notnull,unique:unique_group_access" json:"group_id"`
+
+	AccessLevel model.AccessLevel `sql:"access_level,type:ACCESS_LEVEL,notnull" json:"access"` // WARN: custom type here, do not forget create it
+
+	// Overrides service level mapping of member roles to access levels for this resource
+	RoleMapping GroupRoleMapping `sql:"role_mapping" json:"role_mapping,omitempty"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+// GroupRoleMapping maps roles of group members to access levels.
+// Roles not present in mapping are mapped by service level mapping.
+//
+// swagger:model
+type GroupRoleMapping map[model.UserGroupAccess]model.AccessLevel
+
+// IsValid checks if mapping contains only known roles and access levels which can be given to group members.
+func (m GroupRoleMapping) IsValid() bool {
+	for role, level := range m {
+		switch role {
+		case model.OwnerAccess, model.AdminAccess, model.MasterAccess, model.MemberAccess, model.GuestAccess, model.NoAccess:
+		default:
+			return false
+		}
+
+		switch level {
+		case model.Write, model.ReadDelete, model.Read, model.None:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// GroupMember is a local copy of group membership from user manager used to build permissions of group members.
+type GroupMember struct {
+	tableName struct{} `sql:"group_members"`
+
+	// swagger:strfmt uuid
+	GroupID string `sql:"group_id,pk,type:uuid" json:"group_id"`
+
+	// swagger:strfmt uuid
+	UserID string `sql:"user_id,pk,type:uuid" json:"user_id"`
+
+	// Role of member in group
+	Role model.UserGroupAccess `sql:"role" json:"role"`
+
+	// Access of member role by service level mapping
+	AccessLevel model.AccessLevel `sql:"access_level,type:ACCESS_LEVEL,notnull" json:"access"` // WARN: custom type here, do not forget create it
+
+	UpdateTime *time.Time `sql:"update_time,default:now(),notnull" json:"update_time,omitempty"`
+}
+
+// GroupChangedHookRequest is a notification about changed members or member roles of group
+//
+// swagger:model
+type GroupChangedHookRequest struct {
+	// swagger:strfmt uuid
+	GroupID string `json:"group_id" binding:"required,uuid"`
+}
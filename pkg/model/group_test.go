@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/containerum/kube-client/pkg/model"
+)
+
+func TestGroupRoleMappingIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mapping GroupRoleMapping
+		valid   bool
+	}{
+		{name: "empty", mapping: GroupRoleMapping{}, valid: true},
+		{
+			name: "all roles",
+			mapping: GroupRoleMapping{
+				model.OwnerAccess:  model.Write,
+				model.AdminAccess:  model.Write,
+				model.MasterAccess: model.ReadDelete,
+				model.MemberAccess: model.Read,
+				model.GuestAccess:  model.None,
+				model.NoAccess:     model.None,
+			},
+			valid: true,
+		},
+		{name: "unknown role", mapping: GroupRoleMapping{"superuser": model.Read}, valid: false},
+		{name: "owner access level", mapping: GroupRoleMapping{model.OwnerAccess: model.Owner}, valid: false},
+		{name: "unknown access level", mapping: GroupRoleMapping{model.MemberAccess: "full"}, valid: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if valid := tc.mapping.IsValid(); valid != tc.valid {
+				t.Errorf("expected valid %t, got %t", tc.valid, valid)
+			}
+		})
+	}
+}
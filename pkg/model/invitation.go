@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/containerum/kube-client/pkg/model"
+)
+
+// Invitation represents access to resource granted for not registered user.
+// Invitation turns into permission when user with such email appears.
+//
+// swagger:model
+type Invitation struct {
+	tableName struct{} `sql:"invitations"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id,omitempty"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull,unique:unique_invitation" json:"kind,omitempty"`
+
+	// swagger:strfmt uuid
+	ResourceID string `sql:"resource_id,type:uuid,notnull,unique:unique_invitation" json:"resource_id,omitempty"`
+
+	// swagger:strfmt email
+	Email string `sql:"email,notnull,unique:unique_invitation" json:"email"`
+
+	AccessLevel model.AccessLevel `sql:"access_level,type:ACCESS_LEVEL,notnull" json:"access"` // WARN: custom type here, do not forget create it
+
+	// Custom role of permission created from invitation
+	Role *string `sql:"role" json:"role,omitempty"`
+
+	// Expiration time of permission created from invitation
+	ExpiresAt *time.Time `sql:"expires_at" json:"expires_at,omitempty"`
+
+	// swagger:strfmt uuid
+	CreatedBy string `sql:"created_by,type:uuid,notnull" json:"created_by,omitempty"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+func (i *Invitation) Mask() {
+	i.ID = ""
+	i.ResourceID = ""
+	i.CreatedBy = ""
+}
+
+// UserCreatedHookRequest is a notification about registered user
+//
+// swagger:model
+type UserCreatedHookRequest struct {
+	// swagger:strfmt uuid
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+// DeleteInvitationRequest contains parameters for revoking invitation
+//
+// swagger:model
+type DeleteInvitationRequest = DeleteUserAccessRequest
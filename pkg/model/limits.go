@@ -0,0 +1,72 @@
+package model
+
+// ResourcesLimits contains aggregate amounts of resources of user namespaces.
+// Zero value of limit means no limit.
+//
+// swagger:model
+type ResourcesLimits struct {
+	Namespaces     int `json:"namespaces"`
+	CPU            int `json:"cpu"`
+	RAM            int `json:"ram"`
+	MaxExtServices int `json:"max_external_services"`
+	MaxIntServices int `json:"max_internal_services"`
+}
+
+// Override returns limits with fields replaced by non-nil fields of user limits
+func (l ResourcesLimits) Override(ul UserLimits) ResourcesLimits {
+	if ul.Namespaces != nil {
+		l.Namespaces = *ul.Namespaces
+	}
+	if ul.CPU != nil {
+		l.CPU = *ul.CPU
+	}
+	if ul.RAM != nil {
+		l.RAM = *ul.RAM
+	}
+	if ul.MaxExtServices != nil {
+		l.MaxExtServices = *ul.MaxExtServices
+	}
+	if ul.MaxIntServices != nil {
+		l.MaxIntServices = *ul.MaxIntServices
+	}
+	return l
+}
+
+// UserLimits contains limits set for user by admin. Service level limits used for not set fields.
+//
+// swagger:model
+type UserLimits struct {
+	tableName struct{} `sql:"user_limits"`
+
+	// swagger:strfmt uuid
+	UserID string `sql:"user_id,pk,type:uuid" json:"user_id,omitempty"`
+
+	Namespaces     *int `sql:"namespaces" json:"namespaces"`
+	CPU            *int `sql:"cpu" json:"cpu"`
+	RAM            *int `sql:"ram" json:"ram"`
+	MaxExtServices *int `sql:"max_ext_services" json:"max_external_services"`
+	MaxIntServices *int `sql:"max_int_services" json:"max_internal_services"`
+}
+
+// UserLimitsResponse contains limits of user and current consumption of resources
+//
+// swagger:model
+type UserLimitsResponse struct {
+	Limits ResourcesLimits `json:"limits"`
+	Usage  ResourcesLimits `json:"usage"`
+}
+
+// SetUserLimitsRequest contains parameters for setting user limits.
+// Null or missing field resets limit to service default.
+//
+// swagger:model
+type SetUserLimitsRequest struct {
+	// swagger:strfmt email
+	UserName string `json:"username" binding:"required,email"`
+
+	Namespaces     *int `json:"namespaces"`
+	CPU            *int `json:"cpu"`
+	RAM            *int `json:"ram"`
+	MaxExtServices *int `json:"max_external_services"`
+	MaxIntServices *int `json:"max_internal_services"`
+}
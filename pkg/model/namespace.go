@@ -27,6 +27,11 @@ type Namespace struct {
 	KubeName       string  `sql:"kube_name,unique:kube_name,notnull" json:"kube_name"`
 	// swagger:strfmt uuid
 	ProjectID *string `sql:"project_id,type:uuid" json:"project_id,omitempty"`
+	// swagger:strfmt uuid
+	OrganizationID *string `sql:"organization_id,type:uuid" json:"organization_id,omitempty"`
+
+	Labels      map[string]string `sql:"labels,type:jsonb" json:"labels,omitempty"`
+	Description string            `sql:"description,notnull" json:"description,omitempty"`
 }
 
 func (ns *Namespace) BeforeInsert(db orm.DB) error {
@@ -68,7 +73,7 @@ func (ns *Namespace) AfterInsert(db orm.DB) error {
 type NamespaceWithPermissions struct {
 	Namespace `pg:",override"`
 
-	Permission Permission `pg:"fk:resource_id" sql:"-" json:",inline"`
+	Permission EffectivePermission `pg:"fk:resource_id" sql:"-" json:",inline"`
 
 	Permissions []Permission `pg:"polymorphic:resource_" sql:"-" json:"users"`
 }
@@ -102,6 +107,28 @@ func (np *NamespaceWithPermissions) ToKube() model.Namespace {
 	return ns
 }
 
+// NamespaceResponse is a namespace representation returned to user
+//
+// swagger:model
+type NamespaceResponse struct {
+	model.Namespace
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+
+	// swagger:strfmt uuid
+	OrganizationID *string `json:"organization_id,omitempty"`
+}
+
+func (np *NamespaceWithPermissions) ToResponse() NamespaceResponse {
+	return NamespaceResponse{
+		Namespace:      np.ToKube(),
+		Labels:         np.Labels,
+		Description:    np.Description,
+		OrganizationID: np.OrganizationID,
+	}
+}
+
 // NamespaceAdminCreateRequest contains parameters for creating namespace without billing
 //
 // swagger:model
@@ -147,3 +174,36 @@ type NamespaceResizeRequest struct {
 	// swagger:strfmt uuid
 	TariffID string `json:"tariff_id" binding:"required,uuid"`
 }
+
+// NamespaceTransferRequest contains parameters for transferring namespace to another owner
+//
+// swagger:model
+type NamespaceTransferRequest struct {
+	// swagger:strfmt email
+	Username string `json:"username" binding:"required,email"`
+
+	// Access level kept by previous owner, service default used if not set
+	OldOwnerAccess *model.AccessLevel `json:"old_owner_access,omitempty"`
+}
+
+// NamespaceCloneRequest contains parameters for creating namespace with quota and accesses of existing one
+//
+// swagger:model
+type NamespaceCloneRequest struct {
+	Label string `json:"label" binding:"required"`
+}
+
+// ResourceUpdateMetaRequest contains parameters for changing resource labels and description
+//
+// swagger:model
+type ResourceUpdateMetaRequest struct {
+	// Replaces all resource labels if set
+	Labels map[string]string `json:"labels"`
+
+	Description *string `json:"description"`
+}
+
+// NamespaceUpdateMetaRequest contains parameters for changing namespace labels and description
+//
+// swagger:model
+type NamespaceUpdateMetaRequest = ResourceUpdateMetaRequest
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"time"
+)
+
+type OperationType string
+
+const (
+	OperationNamespaceCreate   OperationType = "namespace.create"
+	OperationNamespaceResize   OperationType = "namespace.resize"
+	OperationNamespaceClone    OperationType = "namespace.clone"
+	OperationNamespaceRestore  OperationType = "namespace.restore"
+	OperationNamespaceTransfer OperationType = "namespace.transfer"
+	// Cleanup of deleted namespace data in other services
+	OperationNamespaceCleanup OperationType = "namespace.cleanup"
+	// Cleanup of all deleted user namespaces data in other services
+	OperationUserNamespacesCleanup OperationType = "user_namespaces.cleanup"
+)
+
+// IsCleanup returns true for operations which are resumed instead of compensated if not finished.
+func (t OperationType) IsCleanup() bool {
+	return t == OperationNamespaceCleanup || t == OperationUserNamespacesCleanup
+}
+
+type OperationStatus string
+
+const (
+	// Operation steps are being executed
+	OperationRunning OperationStatus = "running"
+	// All steps executed and database changes committed, cleanup finished
+	OperationCompleted OperationStatus = "completed"
+	// Operation failed and changes made by steps are being reverted
+	OperationCompensating OperationStatus = "compensating"
+	// Changes made by steps reverted
+	OperationCompensated OperationStatus = "compensated"
+	// Cleanup steps not done after runs limit, operation is not retried anymore
+	OperationFailed OperationStatus = "failed"
+)
+
+type OperationStepStatus string
+
+const (
+	OperationStepPending     OperationStepStatus = "pending"
+	OperationStepStarted     OperationStepStatus = "started"
+	OperationStepDone        OperationStepStatus = "done"
+	OperationStepFailed      OperationStepStatus = "failed"
+	OperationStepCompensated OperationStepStatus = "compensated"
+)
+
+// OperationStep is a state of operation step making change in external service
+//
+// swagger:model
+type OperationStep struct {
+	Name string `json:"name"`
+
+	Status OperationStepStatus `json:"status"`
+
+	// Number of step execution attempts
+	Attempts int `json:"attempts,omitempty"`
+
+	// Error of step execution or compensation
+	Error string `json:"error,omitempty"`
+}
+
+// CleanupTarget describes deleted namespaces which data is deleted by cleanup hooks
+//
+// swagger:model
+type CleanupTarget struct {
+	// swagger:strfmt uuid
+	UserID string `json:"user_id"`
+
+	// Deleted namespace, all namespaces of user are deleted if empty
+	NamespaceID string `json:"namespace_id,omitempty"`
+
+	KubeName string `json:"kube_name,omitempty"`
+
+	// IDs of billing resources of deleted namespaces and their volumes
+	ResourceIDs []string `json:"resource_ids"`
+}
+
+// OperationData contains state of resource needed to revert changes made by operation steps
+//
+// swagger:model
+type OperationData struct {
+	// Namespace state set by operation
+	Namespace *Namespace `json:"namespace,omitempty"`
+
+	// Namespace state before operation
+	OldNamespace *Namespace `json:"old_namespace,omitempty"`
+
+	// Volumes created by operation
+	Volumes []Volume `json:"volumes,omitempty"`
+
+	// Deleted namespaces for cleanup operations
+	Cleanup *CleanupTarget `json:"cleanup,omitempty"`
+}
+
+// Operation is a persisted state of multi-step change of database and external services.
+// Operation is completed in transaction with database changes. If transaction fails changes made by steps are compensated.
+// Abandoned operation with all steps done is resumed by applying database changes from its data if it is possible.
+// Cleanup operation is created in transaction with database changes and its steps are retried until done.
+//
+// swagger:model
+type Operation struct {
+	tableName struct{} `sql:"operations"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id"`
+
+	Type OperationType `sql:"type,notnull" json:"type"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull" json:"kind"`
+
+	// swagger:strfmt uuid
+	ResourceID string `sql:"resource_id,type:uuid,notnull" json:"resource_id"`
+
+	// swagger:strfmt uuid
+	UserID string `sql:"user_id,type:uuid,notnull" json:"user_id"`
+
+	// ID of request started operation
+	RequestID string `sql:"request_id" json:"request_id,omitempty"`
+
+	Status OperationStatus `sql:"status,notnull" json:"status"`
+
+	// Steps in order of execution, compensated in reverse order
+	Steps []OperationStep `sql:"steps,type:jsonb,notnull" json:"steps"`
+
+	Data OperationData `sql:"data,type:jsonb" json:"-"`
+
+	// Number of failed compensation attempts or unfinished cleanup runs, cleanup is failed after runs limit
+	Attempts int `sql:"attempts,notnull,default:0" json:"attempts"`
+
+	LastError string `sql:"last_error" json:"last_error,omitempty"`
+
+	CreateTime time.Time `sql:"create_time,default:now(),notnull" json:"create_time"`
+
+	// Operation not updated for a long time is considered abandoned and resumed or compensated by recovery job
+	UpdateTime time.Time `sql:"update_time,default:now(),notnull" json:"update_time"`
+}
+
+// StepsDone returns true if all operation steps are done.
+func (op *Operation) StepsDone() bool {
+	for _, step := range op.Steps {
+		if step.Status != OperationStepDone {
+			return false
+		}
+	}
+	return true
+}
+
+// Step returns operation step by name or nil if operation has no such step.
+func (op *Operation) Step(name string) *OperationStep {
+	for i := range op.Steps {
+		if op.Steps[i].Name == name {
+			return &op.Steps[i]
+		}
+	}
+	return nil
+}
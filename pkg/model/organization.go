@@ -0,0 +1,118 @@
+package model
+
+import (
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"github.com/go-pg/pg/orm"
+)
+
+type OrganizationRole string
+
+const (
+	// Organization admins can list, share and delete all namespaces and projects of organization
+	OrganizationRoleAdmin  OrganizationRole = "admin"
+	OrganizationRoleMember OrganizationRole = "member"
+)
+
+func (r OrganizationRole) IsValid() bool {
+	return r == OrganizationRoleAdmin || r == OrganizationRoleMember
+}
+
+// Organization represents tenant owning namespaces and projects of its members.
+// Resources of organization can be shared only with organization members.
+//
+// swagger:model
+type Organization struct {
+	tableName struct{} `sql:"organizations"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id,omitempty"`
+
+	Label string `sql:"label,notnull,unique" json:"label"`
+
+	// swagger:strfmt uuid
+	OwnerUserID string `sql:"owner_user_id,type:uuid,notnull" json:"owner_user_id,omitempty"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+
+	Members []OrganizationMember `sql:"-" json:"members,omitempty"`
+}
+
+func (o *Organization) BeforeInsert(db orm.DB) error {
+	cnt, err := db.Model(o).
+		Where("label = ?label").
+		Count()
+	if err != nil {
+		return err
+	}
+
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("organization %s already exists", o.Label)
+	}
+
+	return nil
+}
+
+func (o *Organization) AfterInsert(db orm.DB) error {
+	return db.Insert(&OrganizationMember{
+		OrganizationID: o.ID,
+		UserID:         o.OwnerUserID,
+		Role:           OrganizationRoleAdmin,
+	})
+}
+
+func (o *Organization) Mask() {
+	o.OwnerUserID = ""
+	o.CreateTime = nil
+	for i := range o.Members {
+		o.Members[i].Mask()
+	}
+}
+
+// OrganizationMember represents membership of user in organization
+//
+// swagger:model
+type OrganizationMember struct {
+	tableName struct{} `sql:"organization_members"`
+
+	// swagger:strfmt uuid
+	OrganizationID string `sql:"organization_id,pk,type:uuid" json:"organization_id,omitempty"`
+
+	// swagger:strfmt uuid
+	UserID string `sql:"user_id,pk,type:uuid" json:"user_id,omitempty"`
+
+	// swagger:strfmt email
+	UserLogin string `sql:"-" json:"user_login,omitempty"`
+
+	Role OrganizationRole `sql:"role,notnull" json:"role"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+func (m *OrganizationMember) Mask() {
+	m.UserID = ""
+	m.CreateTime = nil
+}
+
+// OrganizationCreateRequest contains parameters for creating organization
+//
+// swagger:model
+type OrganizationCreateRequest struct {
+	Label string `json:"label" binding:"required"`
+}
+
+// OrganizationSetMemberRequest contains parameters for adding member to organization or changing member role
+//
+// swagger:model
+type OrganizationSetMemberRequest struct {
+	// swagger:strfmt email
+	Username string `json:"username" binding:"required,email"`
+
+	Role OrganizationRole `json:"role" binding:"required"`
+}
+
+// OrganizationDeleteMemberRequest contains parameters for deleting member from organization
+//
+// swagger:model
+type OrganizationDeleteMemberRequest = DeleteUserAccessRequest
@@ -13,6 +13,7 @@ type ResourceType string
 const (
 	ResourceNamespace ResourceType = "Namespace"
 	ResourceVolume    ResourceType = "Volume"
+	ResourceProject   ResourceType = "Project"
 )
 
 // Permission represents information about user permission to resource
@@ -44,6 +45,12 @@ type Permission struct {
 	AccessLevelChangeTime *time.Time `sql:"access_level_change_time,default:now(),notnull" json:"access_level_change_time,omitempty"`
 
 	GroupID *string `sql:"group_id,type:uuid" json:"group_id,omitempty"`
+
+	// Permission will be revoked after this time
+	ExpiresAt *time.Time `sql:"expires_at" json:"expires_at,omitempty"`
+
+	// Custom role granted with permission, built-in role for access level used if not set
+	Role *string `sql:"role" json:"role,omitempty"`
 }
 
 func (p *Permission) BeforeInsert(db orm.DB) error {
@@ -106,6 +113,29 @@ func (p *Permission) Mask() {
 	p.GroupID = nil
 }
 
+// EffectivePermission represents user permission to resource with project permissions applied to project namespaces.
+// Direct permission wins if it gives greater access than project one.
+//
+// swagger:model
+type EffectivePermission struct {
+	tableName struct{} `sql:"effective_permissions"` // WARN: this is a view, do not write to it
+
+	Permission
+
+	// swagger:strfmt uuid
+	InheritedFrom *string `sql:"inherited_from,type:uuid" json:"inherited_from,omitempty"`
+}
+
+// PermissionTombstone keeps permission of deleted resource to allow restoring it.
+// Tombstones are purged after retention period.
+type PermissionTombstone struct {
+	tableName struct{} `sql:"permission_tombstones"`
+
+	Permission
+
+	DeleteTime time.Time `sql:"delete_time,default:now(),notnull"`
+}
+
 // SetUserAccessRequest is a request object for setting user accesses
 //
 // swagger:model SetResourcesAccessesRequest
@@ -116,7 +146,17 @@ type SetUserAccessesRequest struct {
 // SetUserAccessRequest is a request object for setting access to resource for user
 //
 // swagger:model SetResourceAccessRequest
-type SetUserAccessRequest = model.ResourceUpdateUserAccess
+type SetUserAccessRequest struct {
+	// swagger:strfmt email
+	Username string            `json:"username"`
+	Access   model.AccessLevel `json:"access,omitempty"`
+
+	// Custom role name, access level of role used if set
+	Role *string `json:"role,omitempty"`
+
+	// Access will be revoked after this time if set
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
 
 // DeleteUserAccessRequest is a request object for deleting access to resource for user
 //
@@ -125,3 +165,105 @@ type DeleteUserAccessRequest struct {
 	// swagger:strfmt email
 	UserName string `json:"username"`
 }
+
+// BatchAccessChange describes change of user or group access to namespace.
+// Exactly one of username and group_id must be set.
+//
+// swagger:model
+type BatchAccessChange struct {
+	// Namespace ID
+	Namespace string `json:"namespace" binding:"required"`
+
+	// swagger:strfmt email
+	Username string `json:"username,omitempty"`
+
+	// swagger:strfmt uuid
+	GroupID string `json:"group_id,omitempty"`
+
+	// Access level of user or maximal access of group members, not used when revoking
+	Access model.AccessLevel `json:"access,omitempty"`
+
+	// Custom role name for user, access level of role used if set
+	Role *string `json:"role,omitempty"`
+
+	// User access will be revoked after this time if set
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Revoke access of user or group instead of setting it
+	Revoke bool `json:"revoke,omitempty"`
+}
+
+// BatchAccessRequest contains namespace access changes applied by one request
+//
+// swagger:model
+type BatchAccessRequest struct {
+	Changes []BatchAccessChange `json:"changes" binding:"required,dive"`
+
+	// Apply every change separately and report failed ones.
+	// By default all changes applied in one transaction and nothing changed if any change failed.
+	BestEffort bool `json:"best_effort,omitempty"`
+}
+
+type AccessSourceType string
+
+const (
+	AccessSourceOwner   AccessSourceType = "owner"
+	AccessSourceDirect  AccessSourceType = "direct"
+	AccessSourceGroup   AccessSourceType = "group"
+	AccessSourceProject AccessSourceType = "project"
+	// Access was limited by admin or billing
+	AccessSourceLimit AccessSourceType = "limit"
+)
+
+// AccessSource describes one of reasons of user access to resource
+//
+// swagger:model
+type AccessSource struct {
+	Type AccessSourceType `json:"type"`
+
+	// Access given by source, for limit it is access after limiting
+	Access model.AccessLevel `json:"access"`
+
+	// Access granted before limiting
+	GrantedAccess model.AccessLevel `json:"granted_access,omitempty"`
+
+	// swagger:strfmt uuid
+	GroupID *string `json:"group_id,omitempty"`
+
+	// swagger:strfmt uuid
+	ProjectID *string `json:"project_id,omitempty"`
+
+	Role *string `json:"role,omitempty"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Effective access of user is given by this source
+	Effective bool `json:"effective,omitempty"`
+}
+
+// AccessExplanation describes effective access of user to resource and all sources of it
+//
+// swagger:model
+type AccessExplanation struct {
+	// swagger:strfmt uuid
+	ResourceID string `json:"resource_id,omitempty"`
+
+	// swagger:strfmt uuid
+	UserID string `json:"user_id,omitempty"`
+
+	// swagger:strfmt email
+	UserLogin string `json:"user_login,omitempty"`
+
+	Access model.AccessLevel `json:"access"`
+
+	Sources []AccessSource `json:"sources"`
+}
+
+func (e *AccessExplanation) Mask() {
+	e.ResourceID = ""
+	e.UserID = ""
+	for i := range e.Sources {
+		e.Sources[i].GroupID = nil
+		e.Sources[i].ProjectID = nil
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"git.containerum.net/ch/permissions/pkg/errors"
 	"github.com/containerum/kube-client/pkg/model"
 	"github.com/go-pg/pg/orm"
@@ -11,7 +13,15 @@ type Project struct {
 
 	Resource
 
+	Labels      map[string]string `sql:"labels,type:jsonb" json:"labels,omitempty"`
+	Description string            `sql:"description,notnull" json:"description,omitempty"`
+
+	// swagger:strfmt uuid
+	OrganizationID *string `sql:"organization_id,type:uuid" json:"organization_id,omitempty"`
+
 	Namespaces []Namespace `sql:"-" pg:"fk:project_id" json:"namespaces,omitempty"`
+
+	Permissions []Permission `pg:"polymorphic:resource_" sql:"-" json:"users,omitempty"`
 }
 
 func (p *Project) BeforeInsert(db orm.DB) error {
@@ -31,6 +41,26 @@ func (p *Project) BeforeInsert(db orm.DB) error {
 	return nil
 }
 
+func (p *Project) AfterInsert(db orm.DB) error {
+	return db.Insert(&Permission{
+		ResourceID:         p.ID,
+		UserID:             p.OwnerUserID,
+		ResourceType:       ResourceProject,
+		InitialAccessLevel: model.Owner,
+		CurrentAccessLevel: model.Owner,
+	})
+}
+
+func (p *Project) Mask() {
+	p.Resource.Mask()
+	for i := range p.Namespaces {
+		p.Namespaces[i].Mask()
+	}
+	for i := range p.Permissions {
+		p.Permissions[i].Mask()
+	}
+}
+
 // ProjectCreateRequest contains parameters for creating project
 //
 // swagger:model
@@ -38,11 +68,39 @@ type ProjectCreateRequest struct {
 	Label string `json:"label" binding:"required"`
 }
 
+// ProjectRenameRequest contains parameters for renaming project
+//
+// swagger:model
+type ProjectRenameRequest = model.ResourceUpdateName
+
+// ProjectUpdateMetaRequest contains parameters for changing project labels and description
+//
+// swagger:model
+type ProjectUpdateMetaRequest = ResourceUpdateMetaRequest
+
+// ProjectAddNamespaceRequest contains parameters for moving namespace to project
+//
+// swagger:model
+type ProjectAddNamespaceRequest struct {
+	Namespace string `json:"namespace" binding:"required"`
+}
+
+// DeleteMemberFromProjectRequest contains parameters for deleting user from project
+//
+// swagger:model
+type DeleteMemberFromProjectRequest = DeleteUserAccessRequest
+
 // ProjectAddGroupRequest contains parameters for adding permissions for group
 //
 // swagger:model
 type ProjectAddGroupRequest struct {
 	GroupID string `json:"group" binding:"required"`
+
+	// Maximal access of group members, write if not set
+	AccessLevel *model.AccessLevel `json:"access,omitempty"`
+
+	// Mapping of member roles to access levels for this binding, overrides service level mapping
+	RoleMapping GroupRoleMapping `json:"role_mapping,omitempty"`
 }
 
 // SetGroupMemberAccessRequest contains parameters for setting access to member of group
@@ -52,6 +110,9 @@ type SetGroupMemberAccessRequest struct {
 	//swagger:strfmt email
 	Username    string            `json:"username" binding:"required,email"`
 	AccessLevel model.AccessLevel `json:"access" binding:"required"`
+
+	// Access will be revoked after this time if set
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // AddMemberToProjectRequest contains parameters for adding user to project
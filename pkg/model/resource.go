@@ -40,7 +40,18 @@ func (r *Resource) AfterUpdate(db orm.DB) error {
 	if r.Deleted {
 		now := time.Now()
 		r.DeleteTime = &now
-		_, err := db.Model(&Permission{ResourceID: r.ID}).
+		// keep permissions to restore resource later
+		_, err := db.Model(&PermissionTombstone{}).Exec( /* language=sql */
+			`INSERT INTO "?TableName" (perm_id, resource_type, resource_id, create_time, user_id,
+				initial_access_level, current_access_level, access_level_change_time, group_id, expires_at, role)
+			SELECT perm_id, resource_type, resource_id, create_time, user_id,
+				initial_access_level, current_access_level, access_level_change_time, group_id, expires_at, role
+			FROM permissions WHERE resource_id = ?
+			ON CONFLICT DO NOTHING`, r.ID)
+		if err != nil {
+			return err
+		}
+		_, err = db.Model(&Permission{ResourceID: r.ID}).
 			Where("resource_id = ?resource_id").
 			Delete()
 		return err
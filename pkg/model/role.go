@@ -0,0 +1,118 @@
+package model
+
+import (
+	"time"
+
+	"github.com/containerum/kube-client/pkg/model"
+)
+
+// Action is an operation on resource which can be allowed by role.
+type Action string
+
+const (
+	ActionNamespaceRename   Action = "namespace.rename"
+	ActionNamespaceResize   Action = "namespace.resize"
+	ActionNamespaceDelete   Action = "namespace.delete"
+	ActionNamespaceRestore  Action = "namespace.restore"
+	ActionNamespaceTransfer Action = "namespace.transfer"
+	ActionVolumeCreate      Action = "volume.create"
+	ActionVolumeRename      Action = "volume.rename"
+	ActionVolumeDelete      Action = "volume.delete"
+	ActionAccessManage      Action = "access.manage"
+	ActionGroupManage       Action = "group.manage"
+)
+
+// AllActions contains all known actions.
+var AllActions = []Action{
+	ActionNamespaceRename,
+	ActionNamespaceResize,
+	ActionNamespaceDelete,
+	ActionNamespaceRestore,
+	ActionNamespaceTransfer,
+	ActionVolumeCreate,
+	ActionVolumeRename,
+	ActionVolumeDelete,
+	ActionAccessManage,
+	ActionGroupManage,
+}
+
+// IsValid checks if action is known.
+func (a Action) IsValid() bool {
+	for _, v := range AllActions {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of allowed actions granted to user with permission.
+// Access level of role is written to permission so kube and other services still see plain access level.
+//
+// swagger:model
+type Role struct {
+	tableName struct{} `sql:"roles"`
+
+	Name string `sql:"name,pk,type:text" json:"name"`
+
+	Description string `sql:"description,notnull" json:"description,omitempty"`
+
+	AccessLevel model.AccessLevel `sql:"access_level,type:ACCESS_LEVEL,notnull" json:"access"` // WARN: custom type here, do not forget create it
+
+	Actions []Action `sql:"actions,type:jsonb,notnull" json:"actions"`
+
+	// Role is defined by service and can`t be changed
+	BuiltIn bool `sql:"-" json:"built_in,omitempty"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+// Allows checks if role allows to do action.
+func (r *Role) Allows(action Action) bool {
+	for _, v := range r.Actions {
+		if v == action {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltInRoles are roles corresponding to access levels.
+// Users with write access can manage namespace volumes, with read-delete access can delete them.
+// Only owner can manage namespace itself and its accesses.
+var BuiltInRoles = []Role{
+	{Name: string(model.Owner), AccessLevel: model.Owner, Actions: AllActions, BuiltIn: true},
+	{Name: string(model.Write), AccessLevel: model.Write, Actions: []Action{ActionVolumeCreate, ActionVolumeRename, ActionVolumeDelete}, BuiltIn: true},
+	{Name: string(model.ReadDelete), AccessLevel: model.ReadDelete, Actions: []Action{ActionVolumeDelete}, BuiltIn: true},
+	{Name: string(model.Read), AccessLevel: model.Read, Actions: []Action{}, BuiltIn: true},
+	{Name: string(model.None), AccessLevel: model.None, Actions: []Action{}, BuiltIn: true},
+}
+
+// BuiltInRole returns built-in role by its name.
+func BuiltInRole(name string) (Role, bool) {
+	for _, v := range BuiltInRoles {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Role{}, false
+}
+
+// RoleCreateRequest contains parameters for creating custom role
+//
+// swagger:model
+type RoleCreateRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description"`
+	AccessLevel model.AccessLevel `json:"access" binding:"required"`
+	Actions     []Action          `json:"actions"`
+}
+
+// RoleUpdateRequest contains parameters for updating custom role. Only set fields will be updated.
+//
+// swagger:model
+type RoleUpdateRequest struct {
+	Description *string            `json:"description,omitempty"`
+	AccessLevel *model.AccessLevel `json:"access,omitempty"`
+	Actions     []Action           `json:"actions,omitempty"`
+}
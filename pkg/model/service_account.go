@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg/orm"
+)
+
+// ServiceAccount is a non-human principal bound to namespace or project.
+// Its ID is used as user ID in permissions so accesses are delivered to auth service like for users.
+//
+// swagger:model
+type ServiceAccount struct {
+	tableName struct{} `sql:"service_accounts"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id,omitempty"`
+
+	Name string `sql:"name,notnull" json:"name"`
+
+	ResourceType ResourceType `sql:"resource_type,notnull" json:"kind,omitempty"`
+
+	// swagger:strfmt uuid
+	ResourceID string `sql:"resource_id,type:uuid,notnull" json:"resource_id,omitempty"`
+
+	// SHA-256 of current token, token itself is shown only on create and rotate
+	TokenHash string `sql:"token_hash,unique,notnull" json:"-"`
+
+	TokenRotateTime *time.Time `sql:"token_rotate_time,default:now(),notnull" json:"token_rotate_time,omitempty"`
+
+	// swagger:strfmt uuid
+	CreatedBy string `sql:"created_by,type:uuid,notnull" json:"created_by,omitempty"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+func (sa *ServiceAccount) BeforeInsert(db orm.DB) error {
+	cnt, err := db.Model(sa).
+		Where("resource_type = ?resource_type").
+		Where("resource_id = ?resource_id").
+		Where("name = ?name").
+		Count()
+	if err != nil {
+		return err
+	}
+
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("service account %s already exists", sa.Name)
+	}
+
+	return nil
+}
+
+func (sa *ServiceAccount) Mask() {
+	sa.ResourceID = ""
+	sa.CreatedBy = ""
+}
+
+// ServiceAccountWithAccess contains service account with its access level to bound resource.
+//
+// swagger:model
+type ServiceAccountWithAccess struct {
+	ServiceAccount `pg:",override"`
+
+	AccessLevel model.AccessLevel `sql:"access_level" json:"access,omitempty"`
+}
+
+// ServiceAccountCredentials is a response with service account token. Token must be saved by client, it can`t be got later.
+//
+// swagger:model
+type ServiceAccountCredentials struct {
+	ServiceAccountWithAccess
+
+	Token string `json:"token"`
+}
+
+// ServiceAccountCreateRequest contains parameters for creating service account
+//
+// swagger:model
+type ServiceAccountCreateRequest struct {
+	Name   string            `json:"name" binding:"required"`
+	Access model.AccessLevel `json:"access" binding:"required"`
+}
+
+// ServiceAccountSetAccessRequest contains access level granted to service account
+//
+// swagger:model
+type ServiceAccountSetAccessRequest struct {
+	Access model.AccessLevel `json:"access" binding:"required"`
+}
+
+// ServiceAccountAuthenticateRequest contains service account token to check
+//
+// swagger:model
+type ServiceAccountAuthenticateRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"github.com/containerum/kube-client/pkg/model"
+	"github.com/go-pg/pg/orm"
+)
+
+// Volume describes volume
+//
+// swagger:model
+type Volume struct {
+	tableName struct{} `sql:"volumes"`
+
+	Resource
+
+	// swagger:strfmt uuid
+	TariffID *string `sql:"tariff_id,type:uuid" json:"tariff_id,omitempty"`
+	Capacity int     `sql:"capacity,notnull" json:"capacity"`
+	// swagger:strfmt uuid
+	NamespaceID string `sql:"namespace_id,type:uuid,notnull" json:"namespace_id,omitempty"`
+
+	Namespace *Namespace `sql:"-" json:"-"`
+}
+
+func (v *Volume) BeforeInsert(db orm.DB) error {
+	cnt, err := db.Model(v).
+		Where("namespace_id = ?namespace_id").
+		Where("label = ?label").
+		Where("NOT deleted").
+		Count()
+	if err != nil {
+		return err
+	}
+
+	if cnt > 0 {
+		return errors.ErrResourceAlreadyExists().AddDetailF("volume %s already exists", v.Label)
+	}
+
+	return nil
+}
+
+func (v *Volume) AfterInsert(db orm.DB) error {
+	return db.Insert(&Permission{
+		ResourceID:         v.ID,
+		UserID:             v.OwnerUserID,
+		ResourceType:       ResourceVolume,
+		InitialAccessLevel: model.Owner,
+		CurrentAccessLevel: model.Owner,
+	})
+}
+
+// VolumeWithPermissions is a response object for get requests
+//
+// swagger:model VolumeWithPermissions
+type VolumeWithPermissions struct {
+	Volume `pg:",override"`
+
+	Permission Permission `pg:"fk:resource_id" sql:"-" json:",inline"`
+
+	Permissions []Permission `pg:"polymorphic:resource_" sql:"-" json:"users"`
+}
+
+func (vp *VolumeWithPermissions) Mask() {
+	vp.Resource.Mask()
+	vp.Permission.Mask()
+	for i := range vp.Permissions {
+		vp.Permissions[i].Mask()
+	}
+}
+
+// VolumeCreateRequest contains parameters for creating volume
+//
+// swagger:model
+type VolumeCreateRequest struct {
+	// swagger:strfmt uuid
+	TariffID string `json:"tariff_id" binding:"required,uuid"`
+
+	Label string `json:"label" binding:"required"`
+}
+
+// VolumeRenameRequest contains parameters for renaming volume
+//
+// swagger:model
+type VolumeRenameRequest = model.ResourceUpdateName
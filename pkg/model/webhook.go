@@ -0,0 +1,124 @@
+package model
+
+import (
+	"time"
+)
+
+// EventPing is a type of test delivery sent to check webhook setup
+const EventPing EventType = "ping"
+
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventNamespaceCreated, EventNamespaceDeleted, EventNamespaceResized, EventNamespaceTransferred, EventAccessChanged:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebhookSubscription describes URL receiving events about namespace or about all namespaces of user
+//
+// swagger:model
+type WebhookSubscription struct {
+	tableName struct{} `sql:"webhook_subscriptions"`
+
+	// swagger:strfmt uuid
+	ID string `sql:"id,pk,type:uuid,default:uuid_generate_v4()" json:"id,omitempty"`
+
+	// swagger:strfmt uuid
+	OwnerUserID string `sql:"owner_user_id,type:uuid,notnull" json:"owner_user_id,omitempty"`
+
+	// Namespace which events are delivered, events of all owner namespaces are delivered if empty
+	NamespaceID *string `sql:"namespace_id" json:"namespace_id,omitempty"`
+
+	URL string `sql:"url,notnull" json:"url"`
+
+	// Key of HMAC-SHA256 signature in X-Webhook-Signature header, shown only on create
+	Secret string `sql:"secret,notnull" json:"secret,omitempty"`
+
+	// Types of delivered events, all events are delivered if empty
+	EventTypes []EventType `sql:"event_types,type:jsonb,notnull" json:"event_types"`
+
+	CreateTime *time.Time `sql:"create_time,default:now(),notnull" json:"create_time,omitempty"`
+}
+
+// Accepts returns true if subscription filters pass event type.
+func (s *WebhookSubscription) Accepts(eventType EventType) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, v := range s.EventTypes {
+		if v == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is a record of delivery log. Pending deliveries are retried with exponential backoff.
+//
+// swagger:model
+type WebhookDelivery struct {
+	tableName struct{} `sql:"webhook_deliveries"`
+
+	ID int64 `sql:"id,pk" json:"id"`
+
+	// swagger:strfmt uuid
+	SubscriptionID string `sql:"subscription_id,type:uuid,notnull" json:"subscription_id"`
+
+	Subscription *WebhookSubscription `pg:"fk:subscription_id" sql:"-" json:"-"`
+
+	EventType EventType `sql:"event_type,notnull" json:"event_type"`
+
+	// Delivered event, ping event has only subscription_id in payload
+	Event *Event `sql:"event,type:jsonb" json:"event,omitempty"`
+
+	Status WebhookDeliveryStatus `sql:"status,notnull" json:"status"`
+
+	Attempts int `sql:"attempts,notnull,default:0" json:"attempts"`
+
+	NextAttemptTime time.Time `sql:"next_attempt_time,default:now(),notnull" json:"next_attempt_time"`
+
+	// HTTP status of last response, zero if request failed
+	ResponseStatus int `sql:"response_status" json:"response_status,omitempty"`
+
+	LastError string `sql:"last_error" json:"last_error,omitempty"`
+
+	CreateTime time.Time `sql:"create_time,default:now(),notnull" json:"create_time"`
+
+	DeliveryTime *time.Time `sql:"delivery_time" json:"delivery_time,omitempty"`
+}
+
+// WebhookSubscriptionCreateRequest contains parameters of new webhook subscription
+//
+// swagger:model
+type WebhookSubscriptionCreateRequest struct {
+	// Namespace which events are delivered, events of all user namespaces are delivered if empty
+	Namespace string `json:"namespace,omitempty"`
+
+	URL string `json:"url" binding:"required,url"`
+
+	// Generated if empty
+	Secret string `json:"secret,omitempty"`
+
+	EventTypes []EventType `json:"event_types,omitempty"`
+}
+
+// WebhookSubscriptionUpdateRequest contains changed parameters of webhook subscription, omitted fields are not changed
+//
+// swagger:model
+type WebhookSubscriptionUpdateRequest struct {
+	URL *string `json:"url,omitempty" binding:"omitempty,url"`
+
+	Secret *string `json:"secret,omitempty"`
+
+	EventTypes []EventType `json:"event_types,omitempty"`
+}
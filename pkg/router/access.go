@@ -47,7 +47,7 @@ func (ah *accessHandlers) setNamespaceAccessHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := ah.acts.SetNamespaceAccess(ctx.Request.Context(), ctx.Param("id"), req.Username, req.Access); err != nil {
+	if err := ah.acts.SetNamespaceAccess(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
 		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
 		return
 	}
@@ -81,6 +81,75 @@ func (ah *accessHandlers) getNamespaceAccessHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, ret)
 }
 
+func (ah *accessHandlers) explainNamespaceAccessHandler(ctx *gin.Context) {
+	ret, err := ah.acts.ExplainNamespaceAccess(ctx.Request.Context(), ctx.Param("id"), ctx.Param("user"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (ah *accessHandlers) setVolumeAccessHandler(ctx *gin.Context) {
+	var req model.SetUserAccessRequest
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ah.acts.SetVolumeAccess(ctx.Request.Context(), ctx.Param("id"), req.Username, req.Access, req.ExpiresAt); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ah *accessHandlers) deleteVolumeAccessHandler(ctx *gin.Context) {
+	var req model.DeleteUserAccessRequest
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ah.acts.DeleteVolumeAccess(ctx.Request.Context(), ctx.Param("id"), req.UserName); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ah *accessHandlers) getVolumeAccessHandler(ctx *gin.Context) {
+	ret, err := ah.acts.GetVolumeAccess(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (ah *accessHandlers) batchNamespaceAccessesHandler(ctx *gin.Context) {
+	var req model.BatchAccessRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := ah.acts.BatchNamespaceAccesses(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, ret)
+}
+
 func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	handlers := &accessHandlers{acts: acts, tv: r.tv}
 
@@ -116,6 +185,7 @@ func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	//    required: true
 	//    schema:
 	//      $ref: "#/definitions/SetResourcesAccessesRequest"
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//	 '200':
 	//	   description: access set
@@ -123,9 +193,38 @@ func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	//	   $ref: '#/responses/error'
 	r.engine.PUT("/admin/accesses", handlers.setUserAccessesHandler)
 
+	// swagger:operation POST /accesses/batch Permissions BatchNamespaceAccesses
+	//
+	// Set or revoke accesses of users and groups to several namespaces by one request.
+	// Every change requires access.manage (for user) or group.manage (for group) action in namespace.
+	// By default changes applied in one transaction: if any change fails, nothing is changed
+	// and other changes reported as failed with "not applied: batch rolled back" message.
+	// In best effort mode every change applied separately.
+	// Accesses of every affected user are sent to auth once.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/BatchAccessRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '202':
+	//     description: changes result
+	//     schema:
+	//       $ref: '#/definitions/ImportResponse'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/accesses/batch", handlers.batchNamespaceAccessesHandler)
+
 	// swagger:operation PUT /namespaces/{id}/accesses Permissions SetNamespaceAccess
 	//
-	// Grant namespace permission to user.
+	// Grant namespace permission to user. Invitation is created if user is not registered yet.
 	//
 	// ---
 	// parameters:
@@ -138,6 +237,7 @@ func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	//    schema:
 	//      $ref: "#/definitions/SetResourceAccessRequest"
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//	 '200':
 	//	   description: access set
@@ -160,6 +260,7 @@ func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	//    schema:
 	//      $ref: "#/definitions/DeleteResourceAccessRequest"
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//	 '200':
 	//	   description: access deleted
@@ -185,4 +286,92 @@ func (r *Router) SetupAccessRoutes(acts server.AccessActions) {
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.GET("/namespaces/:id/accesses", handlers.getNamespaceAccessHandler)
+
+	// swagger:operation GET /namespaces/{id}/accesses/{user}/explain Permissions ExplainNamespaceAccess
+	//
+	// Get effective access of user to namespace with all its sources:
+	// owner, direct, group and project permissions and limits set by admin or billing.
+	// Group grants overridden by direct permission or greater group access are listed too.
+	// Access of other user can be explained only by user allowed to manage namespace accesses.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/UserLogin'
+	// responses:
+	//   '200':
+	//     description: access explanation
+	//     schema:
+	//       $ref: '#/definitions/AccessExplanation'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/namespaces/:id/accesses/:user/explain", handlers.explainNamespaceAccessHandler)
+
+	// swagger:operation PUT /volumes/{id}/accesses Permissions SetVolumeAccess
+	//
+	// Grant volume permission to user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: "#/definitions/SetResourceAccessRequest"
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//	 '200':
+	//	   description: access set
+	//	 default:
+	//	   $ref: '#/responses/error'
+	r.engine.PUT("/volumes/:id/accesses", handlers.setVolumeAccessHandler)
+
+	// swagger:operation DELETE /volumes/{id}/accesses Permissions DeleteVolumeAccess
+	//
+	// Delete volume permission to user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: "#/definitions/DeleteResourceAccessRequest"
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//	 '200':
+	//	   description: access deleted
+	//	 default:
+	//	   $ref: '#/responses/error'
+	r.engine.DELETE("/volumes/:id/accesses", handlers.deleteVolumeAccessHandler)
+
+	// swagger:operation GET /volumes/{id}/accesses Permissions GetVolumeWithPermissions
+	//
+	// Get volume with user permissions.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	// responses:
+	//   '200':
+	//     description: volume response
+	//     schema:
+	//       $ref: '#/definitions/VolumeWithPermissions'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/volumes/:id/accesses", handlers.getVolumeAccessHandler)
 }
@@ -0,0 +1,274 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type accessRequestHandlers struct {
+	tv   *TranslateValidate
+	acts server.AccessRequestActions
+}
+
+func (ah *accessRequestHandlers) createNamespaceAccessRequestHandler(ctx *gin.Context) {
+	var req model.CreateAccessRequestRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := ah.acts.CreateNamespaceAccessRequest(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (ah *accessRequestHandlers) getNamespaceAccessRequestsHandler(ctx *gin.Context) {
+	ret, err := ah.acts.GetNamespaceAccessRequests(ctx.Request.Context(), ctx.Param("id"), model.AccessRequestStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"access_requests": ret})
+}
+
+func (ah *accessRequestHandlers) getAccessRequestsInboxHandler(ctx *gin.Context) {
+	ret, err := ah.acts.GetAccessRequestsInbox(ctx.Request.Context(), model.AccessRequestStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"access_requests": ret})
+}
+
+func (ah *accessRequestHandlers) getUserAccessRequestsHandler(ctx *gin.Context) {
+	ret, err := ah.acts.GetUserAccessRequests(ctx.Request.Context(), model.AccessRequestStatus(ctx.Query("status")))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"access_requests": ret})
+}
+
+func (ah *accessRequestHandlers) approveAccessRequestHandler(ctx *gin.Context) {
+	var req model.ResolveAccessRequestRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ah.acts.ApproveAccessRequest(ctx.Request.Context(), ctx.Param("request"), req); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ah *accessRequestHandlers) denyAccessRequestHandler(ctx *gin.Context) {
+	var req model.ResolveAccessRequestRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ah.acts.DenyAccessRequest(ctx.Request.Context(), ctx.Param("request"), req); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ah *accessRequestHandlers) cancelAccessRequestHandler(ctx *gin.Context) {
+	if err := ah.acts.CancelAccessRequest(ctx.Request.Context(), ctx.Param("request")); err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupAccessRequestRoutes(acts server.AccessRequestActions) {
+	handlers := &accessRequestHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /namespaces/{id}/access-requests AccessRequests CreateNamespaceAccessRequest
+	//
+	// Request access to namespace. Request will be reviewed by users allowed to manage namespace access.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/CreateAccessRequestRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: access request created
+	//     schema:
+	//       $ref: '#/definitions/AccessRequest'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/access-requests", handlers.createNamespaceAccessRequestHandler)
+
+	// swagger:operation GET /namespaces/{id}/access-requests AccessRequests GetNamespaceAccessRequests
+	//
+	// Get access requests to namespace (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/AccessRequestStatus'
+	// responses:
+	//   '200':
+	//     description: access requests
+	//     schema:
+	//       type: object
+	//       properties:
+	//         access_requests:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/AccessRequestWithResource'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/namespaces/:id/access-requests", handlers.getNamespaceAccessRequestsHandler)
+
+	// swagger:operation GET /access-requests/inbox AccessRequests GetAccessRequestsInbox
+	//
+	// Get access requests to resources owned by user. Admin gets requests to all resources.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AccessRequestStatus'
+	// responses:
+	//   '200':
+	//     description: access requests
+	//     schema:
+	//       type: object
+	//       properties:
+	//         access_requests:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/AccessRequestWithResource'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/access-requests/inbox", handlers.getAccessRequestsInboxHandler)
+
+	// swagger:operation GET /access-requests/sent AccessRequests GetUserAccessRequests
+	//
+	// Get access requests created by user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AccessRequestStatus'
+	// responses:
+	//   '200':
+	//     description: access requests
+	//     schema:
+	//       type: object
+	//       properties:
+	//         access_requests:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/AccessRequestWithResource'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/access-requests/sent", handlers.getUserAccessRequestsHandler)
+
+	// swagger:operation POST /access-requests/{request}/approve AccessRequests ApproveAccessRequest
+	//
+	// Approve pending access request and grant requested access (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AccessRequestID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ResolveAccessRequestRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: access request approved
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/access-requests/:request/approve", handlers.approveAccessRequestHandler)
+
+	// swagger:operation POST /access-requests/{request}/deny AccessRequests DenyAccessRequest
+	//
+	// Deny pending access request (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AccessRequestID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ResolveAccessRequestRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: access request denied
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/access-requests/:request/deny", handlers.denyAccessRequestHandler)
+
+	// swagger:operation POST /access-requests/{request}/cancel AccessRequests CancelAccessRequest
+	//
+	// Cancel pending access request (request author only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AccessRequestID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: access request cancelled
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/access-requests/:request/cancel", handlers.cancelAccessRequestHandler)
+}
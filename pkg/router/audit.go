@@ -0,0 +1,147 @@
+package router
+
+import (
+	"net/http"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/cherry"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+type auditHandlers struct {
+	tv   *TranslateValidate
+	acts server.AuditActions
+}
+
+func parseAuditTime(ctx *gin.Context, param string) (*time.Time, *cherry.Err) {
+	value := ctx.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.ErrRequestValidationFailed().AddDetailF("invalid %s time %q, RFC3339 expected", param, value)
+	}
+	return &t, nil
+}
+
+func getAuditQuery(ctx *gin.Context) (query model.AuditQuery, cherryErr *cherry.Err) {
+	var err error
+	query.Page, query.PerPage, err = getPaginationParams(ctx.Request.URL.Query())
+	if err != nil {
+		return query, errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+	if query.From, cherryErr = parseAuditTime(ctx, "from"); cherryErr != nil {
+		return query, cherryErr
+	}
+	if query.To, cherryErr = parseAuditTime(ctx, "to"); cherryErr != nil {
+		return query, cherryErr
+	}
+	query.ResourceType = model.ResourceType(ctx.Query("kind"))
+	query.ResourceID = ctx.Query("resource_id")
+	query.Actor = ctx.Query("actor")
+	return query, nil
+}
+
+func (ah *auditHandlers) getAuditRecordsHandler(ctx *gin.Context) {
+	query, cherryErr := getAuditQuery(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+
+	ret, err := ah.acts.GetAuditRecords(ctx.Request.Context(), query)
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"records": ret})
+}
+
+func (ah *auditHandlers) getOwnerAuditRecordsHandler(ctx *gin.Context) {
+	query, cherryErr := getAuditQuery(ctx)
+	if cherryErr != nil {
+		gonic.Gonic(cherryErr, ctx)
+		return
+	}
+
+	ret, err := ah.acts.GetOwnerAuditRecords(ctx.Request.Context(), query)
+	if err != nil {
+		ctx.AbortWithStatusJSON(ah.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"records": ret})
+}
+
+func (r *Router) SetupAuditRoutes(acts server.AuditActions) {
+	handlers := &auditHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation GET /admin/audit Audit GetAuditRecords
+	//
+	// Get records of audit log (admin only).
+	// Records are sorted from newest to oldest.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AuditKind'
+	//  - $ref: '#/parameters/AuditResourceID'
+	//  - $ref: '#/parameters/AuditActor'
+	//  - $ref: '#/parameters/AuditFrom'
+	//  - $ref: '#/parameters/AuditTo'
+	//  - $ref: '#/parameters/PageNum'
+	//  - $ref: '#/parameters/PerPageLimit'
+	// responses:
+	//   '200':
+	//     description: audit records
+	//     schema:
+	//       type: object
+	//       properties:
+	//         records:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/AuditRecord'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/admin/audit", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.getAuditRecordsHandler)
+
+	// swagger:operation GET /audit Audit GetOwnerAuditRecords
+	//
+	// Get records of audit log about resources owned by user.
+	// Records are sorted from newest to oldest.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/AuditKind'
+	//  - $ref: '#/parameters/AuditResourceID'
+	//  - $ref: '#/parameters/AuditActor'
+	//  - $ref: '#/parameters/AuditFrom'
+	//  - $ref: '#/parameters/AuditTo'
+	//  - $ref: '#/parameters/PageNum'
+	//  - $ref: '#/parameters/PerPageLimit'
+	// responses:
+	//   '200':
+	//     description: audit records
+	//     schema:
+	//       type: object
+	//       properties:
+	//         records:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/AuditRecord'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/audit", handlers.getOwnerAuditRecordsHandler)
+}
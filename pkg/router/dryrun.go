@@ -0,0 +1,76 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunWriter keeps response body of handler to replace it with changes made by request.
+type dryRunWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *dryRunWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *dryRunWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *dryRunWriter) WriteHeaderNow() {}
+
+func (w *dryRunWriter) Written() bool {
+	return false
+}
+
+func (w *dryRunWriter) Size() int {
+	return w.body.Len()
+}
+
+// dryRunMiddleware runs mutating requests with "dry_run=true" query parameter in dry run mode.
+// Successful response is replaced by diff of changes, error response passed as is.
+func dryRunMiddleware(ctx *gin.Context) {
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return
+	}
+
+	param, set := ctx.GetQuery("dry_run")
+	if !set {
+		return
+	}
+	enabled, err := strconv.ParseBool(param)
+	if err != nil {
+		ctx.AbortWithStatusJSON(errors.ErrRequestValidationFailed().StatusHTTP, errors.ErrRequestValidationFailed().AddDetailF("invalid dry_run value %q", param))
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	reqCtx, rec := dryrun.NewContext(ctx.Request.Context())
+	ctx.Request = ctx.Request.WithContext(reqCtx)
+
+	writer := &dryRunWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	defer func() {
+		ctx.Writer = writer.ResponseWriter
+	}()
+
+	ctx.Next()
+
+	ctx.Writer = writer.ResponseWriter
+	if ctx.IsAborted() || ctx.Writer.Status() >= http.StatusMultipleChoices {
+		ctx.Writer.Write(writer.body.Bytes())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rec.Diff())
+}
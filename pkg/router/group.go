@@ -0,0 +1,59 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type groupHandlers struct {
+	tv   *TranslateValidate
+	acts server.GroupActions
+}
+
+func (gh *groupHandlers) groupChangedHookHandler(ctx *gin.Context) {
+	var req model.GroupChangedHookRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(gh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := gh.acts.HandleGroupChanged(ctx.Request.Context(), req); err != nil {
+		ctx.AbortWithStatusJSON(gh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupGroupRoutes(acts server.GroupActions) {
+	handlers := &groupHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /hooks/group-changed Groups GroupChangedHook
+	//
+	// Notify about changed members or member roles of group to recompute permissions of members (admin only).
+	// Permissions are recomputed for all namespaces and projects group was added to.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/GroupChangedHookRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: group permissions recomputed
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/hooks/group-changed", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.groupChangedHookHandler)
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type invitationHandlers struct {
+	tv   *TranslateValidate
+	acts server.InvitationActions
+}
+
+func (ih *invitationHandlers) getNamespaceInvitationsHandler(ctx *gin.Context) {
+	ret, err := ih.acts.GetNamespaceInvitations(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ih.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"invitations": ret})
+}
+
+func (ih *invitationHandlers) deleteNamespaceInvitationHandler(ctx *gin.Context) {
+	var req model.DeleteInvitationRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ih.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ih.acts.DeleteNamespaceInvitation(ctx.Request.Context(), ctx.Param("id"), req.UserName); err != nil {
+		ctx.AbortWithStatusJSON(ih.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ih *invitationHandlers) userCreatedHookHandler(ctx *gin.Context) {
+	var req model.UserCreatedHookRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ih.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ih.acts.HandleUserCreated(ctx.Request.Context(), req); err != nil {
+		ctx.AbortWithStatusJSON(ih.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupInvitationRoutes(acts server.InvitationActions) {
+	handlers := &invitationHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation GET /namespaces/{id}/invitations Invitations GetNamespaceInvitations
+	//
+	// Get pending invitations to namespace for not registered users (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	// responses:
+	//   '200':
+	//     description: namespace invitations
+	//     schema:
+	//       type: object
+	//       properties:
+	//         invitations:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/Invitation'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/namespaces/:id/invitations", handlers.getNamespaceInvitationsHandler)
+
+	// swagger:operation DELETE /namespaces/{id}/invitations Invitations DeleteNamespaceInvitation
+	//
+	// Revoke pending invitation to namespace (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/DeleteInvitationRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: invitation revoked
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/namespaces/:id/invitations", handlers.deleteNamespaceInvitationHandler)
+
+	// swagger:operation POST /hooks/user-created Invitations UserCreatedHook
+	//
+	// Notify about registered user to convert invitations to permissions (admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/UserCreatedHookRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: invitations accepted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/hooks/user-created", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.userCreatedHookHandler)
+}
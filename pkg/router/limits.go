@@ -0,0 +1,89 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type limitsHandlers struct {
+	tv   *TranslateValidate
+	acts server.LimitsActions
+}
+
+func (lh *limitsHandlers) getUserLimitsHandler(ctx *gin.Context) {
+	ret, err := lh.acts.GetUserLimits(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(lh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (lh *limitsHandlers) setUserLimitsHandler(ctx *gin.Context) {
+	var req model.SetUserLimitsRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(lh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := lh.acts.SetUserLimits(ctx.Request.Context(), req); err != nil {
+		ctx.AbortWithStatusJSON(lh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupLimitsRoutes(acts server.LimitsActions) {
+	handlers := &limitsHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation GET /limits Limits GetUserLimits
+	//
+	// Get limits of resources in all user namespaces and current consumption.
+	// Zero limit means no limit.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	// responses:
+	//   '200':
+	//     description: user limits
+	//     schema:
+	//       $ref: '#/definitions/UserLimitsResponse'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/limits", handlers.getUserLimitsHandler)
+
+	// swagger:operation PUT /admin/limits Limits SetUserLimits
+	//
+	// Set limits of resources in all user namespaces (admin only).
+	// Limits not set in request are reset to service defaults.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/SetUserLimitsRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: limits set
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/admin/limits", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.setUserLimitsHandler)
+}
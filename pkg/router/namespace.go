@@ -82,8 +82,15 @@ func (nh *namespaceHandlers) renameNamespaceHandler(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
-func (nh *namespaceHandlers) deleteNamespaceHandler(ctx *gin.Context) {
-	if err := nh.acts.DeleteNamespace(ctx.Request.Context(), ctx.Param("id")); err != nil {
+func (nh *namespaceHandlers) updateNamespaceMetaHandler(ctx *gin.Context) {
+	var req model.NamespaceUpdateMetaRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := nh.acts.UpdateNamespaceMeta(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
 		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
 		return
 	}
@@ -91,8 +98,15 @@ func (nh *namespaceHandlers) deleteNamespaceHandler(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
-func (nh *namespaceHandlers) deleteAllUserNamespacesHandler(ctx *gin.Context) {
-	if err := nh.acts.DeleteAllUserNamespaces(ctx.Request.Context()); err != nil {
+func (nh *namespaceHandlers) transferNamespaceHandler(ctx *gin.Context) {
+	var req model.NamespaceTransferRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := nh.acts.TransferNamespace(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
 		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
 		return
 	}
@@ -100,6 +114,51 @@ func (nh *namespaceHandlers) deleteAllUserNamespacesHandler(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+func (nh *namespaceHandlers) cloneNamespaceHandler(ctx *gin.Context) {
+	var req model.NamespaceCloneRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := nh.acts.CloneNamespace(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func (nh *namespaceHandlers) restoreNamespaceHandler(ctx *gin.Context) {
+	if err := nh.acts.RestoreNamespace(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (nh *namespaceHandlers) deleteNamespaceHandler(ctx *gin.Context) {
+	ret, err := nh.acts.DeleteNamespace(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (nh *namespaceHandlers) deleteAllUserNamespacesHandler(ctx *gin.Context) {
+	ret, err := nh.acts.DeleteAllUserNamespaces(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
 func (nh *namespaceHandlers) getNamespaceHandler(ctx *gin.Context) {
 	ret, err := nh.acts.GetNamespace(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
@@ -111,7 +170,7 @@ func (nh *namespaceHandlers) getNamespaceHandler(ctx *gin.Context) {
 }
 
 func (nh *namespaceHandlers) getUserNamespacesHandler(ctx *gin.Context) {
-	ret, err := nh.acts.GetUserNamespaces(ctx.Request.Context(), getFilters(ctx.Request.URL.Query())...)
+	ret, err := nh.acts.GetUserNamespaces(ctx.Request.Context(), ctx.Query("organization"), ctx.Query("label_selector"), getFilters(ctx.Request.URL.Query())...)
 	if err != nil {
 		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
 		return
@@ -128,7 +187,7 @@ func (nh *namespaceHandlers) getAllNamespacesHandler(ctx *gin.Context) {
 		gonic.Gonic(errors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
 		return
 	}
-	ret, err := nh.acts.GetAllNamespaces(ctx.Request.Context(), page, perPage, getFilters(ctx.Request.URL.Query())...)
+	ret, err := nh.acts.GetAllNamespaces(ctx.Request.Context(), page, perPage, ctx.Query("organization"), ctx.Query("label_selector"), getFilters(ctx.Request.URL.Query())...)
 	if err != nil {
 		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
 		return
@@ -160,7 +219,7 @@ func (nh *namespaceHandlers) addGroupToNamespaceHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := nh.acts.AddGroupNamespace(ctx.Request.Context(), ctx.Param("id"), req.GroupID); err != nil {
+	if err := nh.acts.AddGroupNamespace(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
 		ctx.AbortWithStatusJSON(nh.tv.HandleError(err))
 		return
 	}
@@ -243,6 +302,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/NamespaceAdminCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '201':
 	//     description: namespace created
@@ -264,6 +324,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/NamespaceCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '201':
 	//     description: namespace created
@@ -282,6 +343,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/NamespacesList'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: namespace imported
@@ -306,6 +368,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    schema:
 	//      $ref: '#/definitions/NamespaceAdminResizeRequest'
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '200':
 	//     description: namespace resized
@@ -328,6 +391,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    schema:
 	//      $ref: '#/definitions/NamespaceRenameRequest'
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '200':
 	//     description: namespace renamed
@@ -335,6 +399,77 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//     $ref: '#/responses/error'
 	r.engine.PUT("/namespaces/:id/rename", handlers.renameNamespaceHandler)
 
+	// swagger:operation PUT /namespaces/{id}/meta Namespaces UpdateNamespaceMeta
+	//
+	// Update namespace labels and description.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/NamespaceUpdateMetaRequest'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace meta updated
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/namespaces/:id/meta", handlers.updateNamespaceMetaHandler)
+
+	// swagger:operation POST /namespaces/{id}/transfer Namespaces TransferNamespace
+	//
+	// Transfer namespace and its volumes to another owner. Previous owner keeps non-owner access.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/NamespaceTransferRequest'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace transferred
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/transfer", handlers.transferNamespaceHandler)
+
+	// swagger:operation POST /namespaces/{id}/clone Namespaces CloneNamespace
+	//
+	// Create namespace with tariff and accesses of existing one (requires access.manage action).
+	// Namespace without tariff (created by admin) can be cloned only by admin, its quota is copied.
+	// User cloning namespace becomes owner of new namespace, group grants are copied too.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/NamespaceCloneRequest'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: namespace cloned
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/clone", handlers.cloneNamespaceHandler)
+
 	// swagger:operation PUT /namespaces/{id} Namespaces ResizeNamespace
 	//
 	// Resize namespace.
@@ -350,6 +485,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    schema:
 	//      $ref: '#/definitions/NamespaceResizeRequest'
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '200':
 	//     description: namespace resized
@@ -360,6 +496,8 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	// swagger:operation DELETE /namespaces/{id} Namespaces DeleteNamespace
 	//
 	// Delete namespace.
+	// Namespace data in other services is deleted by cleanup steps after response,
+	// their status can be requested by returned operation ID.
 	//
 	// ---
 	// parameters:
@@ -367,25 +505,52 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//  - $ref: '#/parameters/UserRoleHeader'
 	//  - $ref: '#/parameters/SubstitutedUserID'
 	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '200':
 	//     description: namespace deleted
+	//     schema:
+	//       $ref: '#/definitions/Operation'
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.DELETE("/namespaces/:id", handlers.deleteNamespaceHandler)
 
+	// swagger:operation POST /namespaces/{id}/restore Namespaces RestoreNamespace
+	//
+	// Restore deleted namespace with its permissions (owner or admin only).
+	// Namespace can be restored only within retention period after delete.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace restored
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/restore", handlers.restoreNamespaceHandler)
+
 	// swagger:operation DELETE /namespaces Namespaces DeleteAllUserNamespaces
 	//
 	// Delete all user namespaces.
+	// Namespaces data in other services is deleted by cleanup steps after response,
+	// their status can be requested by returned operation ID.
 	//
 	// ---
 	// parameters:
 	//  - $ref: '#/parameters/UserIDHeader'
 	//  - $ref: '#/parameters/UserRoleHeader'
 	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '200':
 	//     description: namespaces deleted
+	//     schema:
+	//       $ref: '#/definitions/Operation'
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.DELETE("/namespaces", handlers.deleteAllUserNamespacesHandler)
@@ -404,7 +569,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//   '200':
 	//     description: namespace response
 	//     schema:
-	//       $ref: '#/definitions/Namespace'
+	//       $ref: '#/definitions/NamespaceResponse'
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.GET("/namespaces/:id", handlers.getNamespaceHandler)
@@ -419,6 +584,8 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//  - $ref: '#/parameters/UserRoleHeader'
 	//  - $ref: '#/parameters/SubstitutedUserID'
 	//  - $ref: '#/parameters/Filters'
+	//  - $ref: '#/parameters/LabelSelector'
+	//  - $ref: '#/parameters/OrganizationFilter'
 	// responses:
 	//   '200':
 	//     description: namespaces response
@@ -428,7 +595,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//         namespaces:
 	//           type: array
 	//           items:
-	//             $ref: '#/definitions/Namespace'
+	//             $ref: '#/definitions/NamespaceResponse'
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.GET("/namespaces", handlers.getUserNamespacesHandler)
@@ -443,6 +610,8 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//  - $ref: '#/parameters/UserRoleHeader'
 	//  - $ref: '#/parameters/SubstitutedUserID'
 	//  - $ref: '#/parameters/Filters'
+	//  - $ref: '#/parameters/LabelSelector'
+	//  - $ref: '#/parameters/OrganizationFilter'
 	//  - $ref: '#/parameters/PageNum'
 	//  - $ref: '#/parameters/PerPageLimit'
 	// responses:
@@ -454,7 +623,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//         namespaces:
 	//           type: array
 	//           items:
-	//             $ref: '#/definitions/Namespace'
+	//             $ref: '#/definitions/NamespaceResponse'
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.GET("/admin/namespaces", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.getAllNamespacesHandler)
@@ -463,6 +632,9 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//
 	// Add group to namespace (admin only).
 	//
+	// Group members get access of their group role limited by access given to group.
+	// Membership changes of group apply to namespace, direct user permissions have precedence over group ones.
+	//
 	// ---
 	// parameters:
 	//  - $ref: '#/parameters/UserIDHeader'
@@ -474,6 +646,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/ProjectAddGroupRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: group added to namespace
@@ -484,6 +657,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	// swagger:operation PUT /namespaces/{id}/groups/{group} Namespaces SetGroupMemberNamespaceAccess
 	//
 	// Change access of group member to namespace.
+	// Access is set as direct user permission and overrides access given by group.
 	//
 	// ---
 	// parameters:
@@ -497,6 +671,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/SetGroupMemberAccessRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: access set
@@ -539,6 +714,7 @@ func (r *Router) SetupNamespaceRoutes(acts server.NamespaceActions) {
 	//  - $ref: '#/parameters/SubstitutedUserID'
 	//  - $ref: '#/parameters/ProjectID'
 	//  - $ref: '#/parameters/GroupID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: group deleted
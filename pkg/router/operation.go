@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/gin-gonic/gin"
+)
+
+type operationHandlers struct {
+	tv   *TranslateValidate
+	acts server.OperationActions
+}
+
+func (oh *operationHandlers) getOperationHandler(ctx *gin.Context) {
+	ret, err := oh.acts.GetOperation(ctx.Request.Context(), ctx.Param("operation"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (r *Router) SetupOperationRoutes(acts server.OperationActions) {
+	handlers := &operationHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation GET /operations/{operation} Operations GetOperation
+	//
+	// Get operation with status of its steps (started operation user, owner of cleaned up namespaces or admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OperationID'
+	// responses:
+	//   '200':
+	//     description: operation
+	//     schema:
+	//       $ref: '#/definitions/Operation'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/operations/:operation", handlers.getOperationHandler)
+}
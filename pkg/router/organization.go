@@ -0,0 +1,424 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type organizationHandlers struct {
+	tv   *TranslateValidate
+	acts server.OrganizationActions
+}
+
+func (oh *organizationHandlers) createOrganizationHandler(ctx *gin.Context) {
+	var req model.OrganizationCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := oh.acts.CreateOrganization(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (oh *organizationHandlers) getUserOrganizationsHandler(ctx *gin.Context) {
+	ret, err := oh.acts.GetUserOrganizations(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"organizations": ret})
+}
+
+func (oh *organizationHandlers) getOrganizationHandler(ctx *gin.Context) {
+	ret, err := oh.acts.GetOrganization(ctx.Request.Context(), ctx.Param("org"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (oh *organizationHandlers) deleteOrganizationHandler(ctx *gin.Context) {
+	if err := oh.acts.DeleteOrganization(ctx.Request.Context(), ctx.Param("org")); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) setOrganizationMemberHandler(ctx *gin.Context) {
+	var req model.OrganizationSetMemberRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := oh.acts.SetOrganizationMember(ctx.Request.Context(), ctx.Param("org"), req); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) deleteOrganizationMemberHandler(ctx *gin.Context) {
+	var req model.OrganizationDeleteMemberRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := oh.acts.DeleteOrganizationMember(ctx.Request.Context(), ctx.Param("org"), req.UserName); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) getOrganizationNamespacesHandler(ctx *gin.Context) {
+	ret, err := oh.acts.GetOrganizationNamespaces(ctx.Request.Context(), ctx.Param("org"), ctx.Query("label_selector"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"namespaces": ret})
+}
+
+func (oh *organizationHandlers) addNamespaceToOrganizationHandler(ctx *gin.Context) {
+	if err := oh.acts.AddNamespaceToOrganization(ctx.Request.Context(), ctx.Param("org"), ctx.Param("id")); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) deleteNamespaceFromOrganizationHandler(ctx *gin.Context) {
+	if err := oh.acts.DeleteNamespaceFromOrganization(ctx.Request.Context(), ctx.Param("org"), ctx.Param("id")); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) getOrganizationProjectsHandler(ctx *gin.Context) {
+	ret, err := oh.acts.GetOrganizationProjects(ctx.Request.Context(), ctx.Param("org"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"projects": ret})
+}
+
+func (oh *organizationHandlers) addProjectToOrganizationHandler(ctx *gin.Context) {
+	if err := oh.acts.AddProjectToOrganization(ctx.Request.Context(), ctx.Param("org"), ctx.Param("project")); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (oh *organizationHandlers) deleteProjectFromOrganizationHandler(ctx *gin.Context) {
+	if err := oh.acts.DeleteProjectFromOrganization(ctx.Request.Context(), ctx.Param("org"), ctx.Param("project")); err != nil {
+		ctx.AbortWithStatusJSON(oh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupOrganizationRoutes(acts server.OrganizationActions) {
+	handlers := &organizationHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /organizations Organizations CreateOrganization
+	//
+	// Create organization. Creator becomes organization admin.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/OrganizationCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: organization created
+	//     schema:
+	//       $ref: '#/definitions/Organization'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/organizations", handlers.createOrganizationHandler)
+
+	// swagger:operation GET /organizations Organizations GetUserOrganizations
+	//
+	// Get organizations where user is a member.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	// responses:
+	//   '200':
+	//     description: organizations
+	//     schema:
+	//       type: object
+	//       properties:
+	//         organizations:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/Organization'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/organizations", handlers.getUserOrganizationsHandler)
+
+	// swagger:operation GET /organizations/{org} Organizations GetOrganization
+	//
+	// Get organization with members (members only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	// responses:
+	//   '200':
+	//     description: organization
+	//     schema:
+	//       $ref: '#/definitions/Organization'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/organizations/:org", handlers.getOrganizationHandler)
+
+	// swagger:operation DELETE /organizations/{org} Organizations DeleteOrganization
+	//
+	// Delete organization (organization owner only).
+	// Namespaces and projects of organization stay with their owners.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: organization deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/organizations/:org", handlers.deleteOrganizationHandler)
+
+	// swagger:operation PUT /organizations/{org}/members Organizations SetOrganizationMember
+	//
+	// Add member to organization or change member role (organization admins only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/OrganizationSetMemberRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: member set
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/organizations/:org/members", handlers.setOrganizationMemberHandler)
+
+	// swagger:operation DELETE /organizations/{org}/members Organizations DeleteOrganizationMember
+	//
+	// Delete member from organization (organization admins only).
+	// Accesses of member to organization namespaces and projects are revoked.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/OrganizationDeleteMemberRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: member deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/organizations/:org/members", handlers.deleteOrganizationMemberHandler)
+
+	// swagger:operation GET /organizations/{org}/namespaces Organizations GetOrganizationNamespaces
+	//
+	// Get all namespaces of organization (organization admins only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/LabelSelector'
+	// responses:
+	//   '200':
+	//     description: namespaces response
+	//     schema:
+	//       type: object
+	//       properties:
+	//         namespaces:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/NamespaceResponse'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/organizations/:org/namespaces", handlers.getOrganizationNamespacesHandler)
+
+	// swagger:operation PUT /organizations/{org}/namespaces/{id} Organizations AddNamespaceToOrganization
+	//
+	// Move namespace to organization (namespace owner only, owner must be organization member).
+	// All users having access to namespace must be organization members.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace added to organization
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/organizations/:org/namespaces/:id", handlers.addNamespaceToOrganizationHandler)
+
+	// swagger:operation DELETE /organizations/{org}/namespaces/{id} Organizations DeleteNamespaceFromOrganization
+	//
+	// Remove namespace from organization (namespace owner or organization admins only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace removed from organization
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/organizations/:org/namespaces/:id", handlers.deleteNamespaceFromOrganizationHandler)
+
+	// swagger:operation GET /organizations/{org}/projects Organizations GetOrganizationProjects
+	//
+	// Get all projects of organization (organization admins only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	// responses:
+	//   '200':
+	//     description: projects response
+	//     schema:
+	//       type: object
+	//       properties:
+	//         projects:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/Project'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/organizations/:org/projects", handlers.getOrganizationProjectsHandler)
+
+	// swagger:operation PUT /organizations/{org}/projects/{project} Organizations AddProjectToOrganization
+	//
+	// Move project to organization (project owner only, owner must be organization member).
+	// All users having access to project must be organization members.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: project added to organization
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/organizations/:org/projects/:project", handlers.addProjectToOrganizationHandler)
+
+	// swagger:operation DELETE /organizations/{org}/projects/{project} Organizations DeleteProjectFromOrganization
+	//
+	// Remove project from organization (project owner or organization admins only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/OrganizationID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: project removed from organization
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/organizations/:org/projects/:project", handlers.deleteProjectFromOrganizationHandler)
+}
@@ -40,7 +40,7 @@ func (ph *projectHandlers) addGroupToProjectHandler(ctx *gin.Context) {
 		return
 	}
 
-	if err := ph.acts.AddGroup(ctx.Request.Context(), ctx.Param("project"), req.GroupID); err != nil {
+	if err := ph.acts.AddGroup(ctx.Request.Context(), ctx.Param("project"), req); err != nil {
 		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
 		return
 	}
@@ -99,6 +99,112 @@ func (ph *projectHandlers) addMemberToProjectHandler(ctx *gin.Context) {
 	ctx.Status(http.StatusAccepted)
 }
 
+func (ph *projectHandlers) deleteMemberFromProjectHandler(ctx *gin.Context) {
+	var req model.DeleteMemberFromProjectRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ph.acts.DeleteMemberFromProject(ctx.Request.Context(), ctx.Param("project"), req.UserName); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func (ph *projectHandlers) getProjectHandler(ctx *gin.Context) {
+	ret, err := ph.acts.GetProject(ctx.Request.Context(), ctx.Param("project"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (ph *projectHandlers) getUserProjectsHandler(ctx *gin.Context) {
+	ret, err := ph.acts.GetUserProjects(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"projects": ret})
+}
+
+func (ph *projectHandlers) renameProjectHandler(ctx *gin.Context) {
+	var req model.ProjectRenameRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ph.acts.RenameProject(ctx.Request.Context(), ctx.Param("project"), req.Label); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ph *projectHandlers) updateProjectMetaHandler(ctx *gin.Context) {
+	var req model.ProjectUpdateMetaRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ph.acts.UpdateProjectMeta(ctx.Request.Context(), ctx.Param("project"), req); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ph *projectHandlers) deleteProjectHandler(ctx *gin.Context) {
+	if err := ph.acts.DeleteProject(ctx.Request.Context(), ctx.Param("project")); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ph *projectHandlers) addNamespaceToProjectHandler(ctx *gin.Context) {
+	var req model.ProjectAddNamespaceRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := ph.acts.AddNamespaceToProject(ctx.Request.Context(), ctx.Param("project"), req.Namespace); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (ph *projectHandlers) deleteNamespaceFromProjectHandler(ctx *gin.Context) {
+	if err := ph.acts.DeleteNamespaceFromProject(ctx.Request.Context(), ctx.Param("project"), ctx.Param("namespace")); err != nil {
+		ctx.AbortWithStatusJSON(ph.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
 func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	handlers := &projectHandlers{tv: r.tv, acts: acts}
 
@@ -116,6 +222,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/ProjectCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '201':
 	//     description: project created
@@ -127,6 +234,9 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//
 	// Add group to project (admin only).
 	//
+	// Group members get access of their group role limited by access given to group.
+	// Membership changes of group apply to project, direct user permissions have precedence over group ones.
+	//
 	// ---
 	// parameters:
 	//  - $ref: '#/parameters/UserIDHeader'
@@ -138,6 +248,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/ProjectAddGroupRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: group added to project
@@ -172,6 +283,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	// swagger:operation PUT /projects/{project}/groups/{group} Projects SetGroupMemberAccess
 	//
 	// Change access of group member to project namespaces.
+	// Access is set as direct user permission and overrides access given by group.
 	//
 	// ---
 	// parameters:
@@ -185,6 +297,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/SetGroupMemberAccessRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: access set
@@ -203,6 +316,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//  - $ref: '#/parameters/SubstitutedUserID'
 	//  - $ref: '#/parameters/ProjectID'
 	//  - $ref: '#/parameters/GroupID'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: group deleted
@@ -212,7 +326,7 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 
 	// swagger:operation POST /projects/{project}/members Projects AddMemberToProject
 	//
-	// Add permissions for user to project namespaces.
+	// Add permissions for user to project. Permissions applied to all current and future project namespaces.
 	//
 	// ---
 	// parameters:
@@ -225,10 +339,182 @@ func (r *Router) SetupProjectRoutes(acts server.ProjectActions) {
 	//    required: true
 	//    schema:
 	//      $ref: '#/definitions/AddMemberToProjectRequest'
+	//  - $ref: '#/parameters/DryRun'
 	// responses:
 	//   '202':
 	//     description: member added
 	//   default:
 	//     $ref: '#/responses/error'
 	r.engine.POST("/project/:project/members", handlers.addMemberToProjectHandler)
+
+	// swagger:operation DELETE /projects/{project}/members Projects DeleteMemberFromProject
+	//
+	// Delete user permissions for project. Direct permissions for project namespaces are kept.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/DeleteMemberFromProjectRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '202':
+	//     description: member deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/project/:project/members", handlers.deleteMemberFromProjectHandler)
+
+	// swagger:operation GET /projects Projects GetUserProjects
+	//
+	// Get projects available for user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	// responses:
+	//   '200':
+	//     description: projects response
+	//     schema:
+	//       type: object
+	//       properties:
+	//         projects:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/Project'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/projects", handlers.getUserProjectsHandler)
+
+	// swagger:operation GET /projects/{project} Projects GetProject
+	//
+	// Get project with namespaces.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	// responses:
+	//   '200':
+	//     description: project response
+	//     schema:
+	//       $ref: '#/definitions/Project'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/projects/:project", handlers.getProjectHandler)
+
+	// swagger:operation PUT /projects/{project} Projects RenameProject
+	//
+	// Rename project.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ProjectRenameRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: project renamed
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/projects/:project", handlers.renameProjectHandler)
+
+	// swagger:operation PUT /projects/{project}/meta Projects UpdateProjectMeta
+	//
+	// Update project labels and description.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ProjectUpdateMetaRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: project meta updated
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/projects/:project/meta", handlers.updateProjectMetaHandler)
+
+	// swagger:operation DELETE /projects/{project} Projects DeleteProject
+	//
+	// Delete project. Project namespaces are not deleted but detached from project.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: project deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/projects/:project", handlers.deleteProjectHandler)
+
+	// swagger:operation POST /projects/{project}/namespaces Projects AddNamespaceToProject
+	//
+	// Move namespace to project.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ProjectAddNamespaceRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace added to project
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/projects/:project/namespaces", handlers.addNamespaceToProjectHandler)
+
+	// swagger:operation DELETE /projects/{project}/namespaces/{namespace} Projects DeleteNamespaceFromProject
+	//
+	// Move namespace out of project.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/NamespaceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: namespace deleted from project
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/projects/:project/namespaces/:namespace", handlers.deleteNamespaceFromProjectHandler)
 }
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type roleHandlers struct {
+	tv   *TranslateValidate
+	acts server.RoleActions
+}
+
+func (rh *roleHandlers) getRolesHandler(ctx *gin.Context) {
+	ret, err := rh.acts.GetRoles(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"roles": ret})
+}
+
+func (rh *roleHandlers) createRoleHandler(ctx *gin.Context) {
+	var req model.RoleCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := rh.acts.CreateRole(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (rh *roleHandlers) updateRoleHandler(ctx *gin.Context) {
+	var req model.RoleUpdateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := rh.acts.UpdateRole(ctx.Request.Context(), ctx.Param("role"), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (rh *roleHandlers) deleteRoleHandler(ctx *gin.Context) {
+	if err := rh.acts.DeleteRole(ctx.Request.Context(), ctx.Param("role")); err != nil {
+		ctx.AbortWithStatusJSON(rh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupRoleRoutes(acts server.RoleActions) {
+	handlers := &roleHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation GET /roles Roles GetRoles
+	//
+	// Get built-in and custom roles.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	// responses:
+	//   '200':
+	//     description: roles
+	//     schema:
+	//       type: object
+	//       properties:
+	//         roles:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/Role'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/roles", handlers.getRolesHandler)
+
+	// swagger:operation POST /admin/roles Roles CreateRole
+	//
+	// Create custom role (admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/RoleCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: role created
+	//     schema:
+	//       $ref: '#/definitions/Role'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/admin/roles", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.createRoleHandler)
+
+	// swagger:operation PUT /admin/roles/{role} Roles UpdateRole
+	//
+	// Update custom role (admin only). Access level of users with this role updated too.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/RoleName'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/RoleUpdateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: role updated
+	//     schema:
+	//       $ref: '#/definitions/Role'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/admin/roles/:role", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.updateRoleHandler)
+
+	// swagger:operation DELETE /admin/roles/{role} Roles DeleteRole
+	//
+	// Delete custom role (admin only). Users with this role keep its access level.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/RoleName'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: role deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/admin/roles/:role", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.deleteRoleHandler)
+}
@@ -0,0 +1,423 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type serviceAccountHandlers struct {
+	tv   *TranslateValidate
+	acts server.ServiceAccountActions
+}
+
+func (sh *serviceAccountHandlers) createNamespaceServiceAccountHandler(ctx *gin.Context) {
+	var req model.ServiceAccountCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := sh.acts.CreateNamespaceServiceAccount(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (sh *serviceAccountHandlers) getNamespaceServiceAccountsHandler(ctx *gin.Context) {
+	ret, err := sh.acts.GetNamespaceServiceAccounts(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"service_accounts": ret})
+}
+
+func (sh *serviceAccountHandlers) setNamespaceServiceAccountAccessHandler(ctx *gin.Context) {
+	var req model.ServiceAccountSetAccessRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := sh.acts.SetNamespaceServiceAccountAccess(ctx.Request.Context(), ctx.Param("id"), ctx.Param("account"), req); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (sh *serviceAccountHandlers) rotateNamespaceServiceAccountHandler(ctx *gin.Context) {
+	ret, err := sh.acts.RotateNamespaceServiceAccount(ctx.Request.Context(), ctx.Param("id"), ctx.Param("account"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (sh *serviceAccountHandlers) deleteNamespaceServiceAccountHandler(ctx *gin.Context) {
+	if err := sh.acts.DeleteNamespaceServiceAccount(ctx.Request.Context(), ctx.Param("id"), ctx.Param("account")); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (sh *serviceAccountHandlers) createProjectServiceAccountHandler(ctx *gin.Context) {
+	var req model.ServiceAccountCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := sh.acts.CreateProjectServiceAccount(ctx.Request.Context(), ctx.Param("project"), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (sh *serviceAccountHandlers) getProjectServiceAccountsHandler(ctx *gin.Context) {
+	ret, err := sh.acts.GetProjectServiceAccounts(ctx.Request.Context(), ctx.Param("project"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"service_accounts": ret})
+}
+
+func (sh *serviceAccountHandlers) setProjectServiceAccountAccessHandler(ctx *gin.Context) {
+	var req model.ServiceAccountSetAccessRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := sh.acts.SetProjectServiceAccountAccess(ctx.Request.Context(), ctx.Param("project"), ctx.Param("account"), req); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (sh *serviceAccountHandlers) rotateProjectServiceAccountHandler(ctx *gin.Context) {
+	ret, err := sh.acts.RotateProjectServiceAccount(ctx.Request.Context(), ctx.Param("project"), ctx.Param("account"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (sh *serviceAccountHandlers) deleteProjectServiceAccountHandler(ctx *gin.Context) {
+	if err := sh.acts.DeleteProjectServiceAccount(ctx.Request.Context(), ctx.Param("project"), ctx.Param("account")); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (sh *serviceAccountHandlers) authenticateServiceAccountHandler(ctx *gin.Context) {
+	var req model.ServiceAccountAuthenticateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := sh.acts.AuthenticateServiceAccount(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(sh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (r *Router) SetupServiceAccountRoutes(acts server.ServiceAccountActions) {
+	handlers := &serviceAccountHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /namespaces/{id}/service-accounts ServiceAccounts CreateNamespaceServiceAccount
+	//
+	// Create service account bound to namespace and grant it access (requires access.manage action).
+	// Returned token is shown only once.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ServiceAccountCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: service account created
+	//     schema:
+	//       $ref: '#/definitions/ServiceAccountCredentials'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/service-accounts", handlers.createNamespaceServiceAccountHandler)
+
+	// swagger:operation GET /namespaces/{id}/service-accounts ServiceAccounts GetNamespaceServiceAccounts
+	//
+	// Get service accounts bound to namespace (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	// responses:
+	//   '200':
+	//     description: service accounts
+	//     schema:
+	//       type: object
+	//       properties:
+	//         service_accounts:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/ServiceAccountWithAccess'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/namespaces/:id/service-accounts", handlers.getNamespaceServiceAccountsHandler)
+
+	// swagger:operation PUT /namespaces/{id}/service-accounts/{account} ServiceAccounts SetNamespaceServiceAccountAccess
+	//
+	// Change access of service account to namespace (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ServiceAccountSetAccessRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: access set
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/namespaces/:id/service-accounts/:account", handlers.setNamespaceServiceAccountAccessHandler)
+
+	// swagger:operation POST /namespaces/{id}/service-accounts/{account}/rotate ServiceAccounts RotateNamespaceServiceAccount
+	//
+	// Issue new token for service account, previous token stops working (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: token rotated
+	//     schema:
+	//       $ref: '#/definitions/ServiceAccountCredentials'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/service-accounts/:account/rotate", handlers.rotateNamespaceServiceAccountHandler)
+
+	// swagger:operation DELETE /namespaces/{id}/service-accounts/{account} ServiceAccounts DeleteNamespaceServiceAccount
+	//
+	// Delete service account and revoke its accesses (requires access.manage action).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: service account deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/namespaces/:id/service-accounts/:account", handlers.deleteNamespaceServiceAccountHandler)
+
+	// swagger:operation POST /projects/{project}/service-accounts ServiceAccounts CreateProjectServiceAccount
+	//
+	// Create service account bound to project and grant it access (owner or admin only).
+	// Returned token is shown only once.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ServiceAccountCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: service account created
+	//     schema:
+	//       $ref: '#/definitions/ServiceAccountCredentials'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/projects/:project/service-accounts", handlers.createProjectServiceAccountHandler)
+
+	// swagger:operation GET /projects/{project}/service-accounts ServiceAccounts GetProjectServiceAccounts
+	//
+	// Get service accounts bound to project (owner or admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	// responses:
+	//   '200':
+	//     description: service accounts
+	//     schema:
+	//       type: object
+	//       properties:
+	//         service_accounts:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/ServiceAccountWithAccess'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/projects/:project/service-accounts", handlers.getProjectServiceAccountsHandler)
+
+	// swagger:operation PUT /projects/{project}/service-accounts/{account} ServiceAccounts SetProjectServiceAccountAccess
+	//
+	// Change access of service account to project (owner or admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ServiceAccountSetAccessRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: access set
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/projects/:project/service-accounts/:account", handlers.setProjectServiceAccountAccessHandler)
+
+	// swagger:operation POST /projects/{project}/service-accounts/{account}/rotate ServiceAccounts RotateProjectServiceAccount
+	//
+	// Issue new token for service account, previous token stops working (owner or admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: token rotated
+	//     schema:
+	//       $ref: '#/definitions/ServiceAccountCredentials'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/projects/:project/service-accounts/:account/rotate", handlers.rotateProjectServiceAccountHandler)
+
+	// swagger:operation DELETE /projects/{project}/service-accounts/{account} ServiceAccounts DeleteProjectServiceAccount
+	//
+	// Delete service account and revoke its accesses (owner or admin only).
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ProjectID'
+	//  - $ref: '#/parameters/ServiceAccountName'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: service account deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/projects/:project/service-accounts/:account", handlers.deleteProjectServiceAccountHandler)
+
+	// swagger:operation POST /hooks/service-account-token ServiceAccounts AuthenticateServiceAccount
+	//
+	// Get service account by its token (admin only). Used by auth service to issue credentials for service account.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/ServiceAccountAuthenticateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: service account
+	//     schema:
+	//       $ref: '#/definitions/ServiceAccountWithAccess'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/hooks/service-account-token", httputil.RequireAdminRole(errors.ErrAdminRequired), handlers.authenticateServiceAccountHandler)
+}
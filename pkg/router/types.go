@@ -96,6 +96,7 @@ func NewRouter(engine gin.IRouter, status *model.ServiceStatus, tv *TranslateVal
 		httputil.UserRoleXHeader: "eq=admin|eq=user",
 	}))
 	ret.engine.Use(httputil.SubstituteUserMiddleware(tv.Validate, tv.UniversalTranslator, errors.ErrRequestValidationFailed))
+	ret.engine.Use(dryRunMiddleware)
 
 	return ret
 }
@@ -0,0 +1,226 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type volumeHandlers struct {
+	tv   *TranslateValidate
+	acts server.VolumeActions
+}
+
+func (vh *volumeHandlers) createVolumeHandler(ctx *gin.Context) {
+	var req model.VolumeCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := vh.acts.CreateVolume(ctx.Request.Context(), ctx.Param("id"), req); err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func (vh *volumeHandlers) getVolumeHandler(ctx *gin.Context) {
+	ret, err := vh.acts.GetVolume(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+	httputil.MaskForNonAdmin(ctx, &ret)
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (vh *volumeHandlers) getUserVolumesHandler(ctx *gin.Context) {
+	ret, err := vh.acts.GetUserVolumes(ctx.Request.Context(), getFilters(ctx.Request.URL.Query())...)
+	if err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"volumes": ret})
+}
+
+func (vh *volumeHandlers) getNamespaceVolumesHandler(ctx *gin.Context) {
+	ret, err := vh.acts.GetNamespaceVolumes(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+	for i := range ret {
+		httputil.MaskForNonAdmin(ctx, &ret[i])
+	}
+	ctx.JSON(http.StatusOK, gin.H{"volumes": ret})
+}
+
+func (vh *volumeHandlers) renameVolumeHandler(ctx *gin.Context) {
+	var req model.VolumeRenameRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	if err := vh.acts.RenameVolume(ctx.Request.Context(), ctx.Param("id"), req.Label); err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (vh *volumeHandlers) deleteVolumeHandler(ctx *gin.Context) {
+	if err := vh.acts.DeleteVolume(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.AbortWithStatusJSON(vh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (r *Router) SetupVolumeRoutes(acts server.VolumeActions) {
+	handlers := &volumeHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /namespaces/{id}/volumes Volumes CreateVolume
+	//
+	// Create volume in namespace using billing.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/VolumeCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: volume created
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/namespaces/:id/volumes", handlers.createVolumeHandler)
+
+	// swagger:operation GET /namespaces/{id}/volumes Volumes GetNamespaceVolumes
+	//
+	// Get namespace volumes available for user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	// responses:
+	//   '200':
+	//     description: volumes response
+	//     schema:
+	//       type: object
+	//       properties:
+	//         volumes:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/VolumeWithPermissions'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/namespaces/:id/volumes", handlers.getNamespaceVolumesHandler)
+
+	// swagger:operation GET /volumes/{id} Volumes GetVolume
+	//
+	// Get volume.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	// responses:
+	//   '200':
+	//     description: volume response
+	//     schema:
+	//       $ref: '#/definitions/VolumeWithPermissions'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/volumes/:id", handlers.getVolumeHandler)
+
+	// swagger:operation GET /volumes Volumes GetUserVolumes
+	//
+	// Get user volumes.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/Filters'
+	// responses:
+	//   '200':
+	//     description: volumes response
+	//     schema:
+	//       type: object
+	//       properties:
+	//         volumes:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/VolumeWithPermissions'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/volumes", handlers.getUserVolumesHandler)
+
+	// swagger:operation PUT /volumes/{id}/rename Volumes RenameVolume
+	//
+	// Rename volume.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/VolumeRenameRequest'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: volume renamed
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/volumes/:id/rename", handlers.renameVolumeHandler)
+
+	// swagger:operation DELETE /volumes/{id} Volumes DeleteVolume
+	//
+	// Delete volume.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/ResourceID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: volume deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/volumes/:id", handlers.deleteVolumeHandler)
+}
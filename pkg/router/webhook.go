@@ -0,0 +1,268 @@
+package router
+
+import (
+	"net/http"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"git.containerum.net/ch/permissions/pkg/server"
+	"github.com/containerum/cherry/adaptors/gonic"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type webhookHandlers struct {
+	tv   *TranslateValidate
+	acts server.WebhookActions
+}
+
+func (wh *webhookHandlers) createWebhookSubscriptionHandler(ctx *gin.Context) {
+	var req model.WebhookSubscriptionCreateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := wh.acts.CreateWebhookSubscription(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ret)
+}
+
+func (wh *webhookHandlers) getWebhookSubscriptionsHandler(ctx *gin.Context) {
+	ret, err := wh.acts.GetWebhookSubscriptions(ctx.Request.Context())
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"webhooks": ret})
+}
+
+func (wh *webhookHandlers) getWebhookSubscriptionHandler(ctx *gin.Context) {
+	ret, err := wh.acts.GetWebhookSubscription(ctx.Request.Context(), ctx.Param("webhook"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (wh *webhookHandlers) updateWebhookSubscriptionHandler(ctx *gin.Context) {
+	var req model.WebhookSubscriptionUpdateRequest
+
+	if err := ctx.ShouldBindWith(&req, binding.JSON); err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.BadRequest(ctx, err))
+		return
+	}
+
+	ret, err := wh.acts.UpdateWebhookSubscription(ctx.Request.Context(), ctx.Param("webhook"), req)
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (wh *webhookHandlers) deleteWebhookSubscriptionHandler(ctx *gin.Context) {
+	if err := wh.acts.DeleteWebhookSubscription(ctx.Request.Context(), ctx.Param("webhook")); err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func (wh *webhookHandlers) getWebhookDeliveriesHandler(ctx *gin.Context) {
+	page, perPage, err := getPaginationParams(ctx.Request.URL.Query())
+	if err != nil {
+		gonic.Gonic(errors.ErrRequestValidationFailed().AddDetailsErr(err), ctx)
+		return
+	}
+
+	ret, err := wh.acts.GetWebhookDeliveries(ctx.Request.Context(), ctx.Param("webhook"), page, perPage)
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deliveries": ret})
+}
+
+func (wh *webhookHandlers) pingWebhookSubscriptionHandler(ctx *gin.Context) {
+	ret, err := wh.acts.PingWebhookSubscription(ctx.Request.Context(), ctx.Param("webhook"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(wh.tv.HandleError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, ret)
+}
+
+func (r *Router) SetupWebhookRoutes(acts server.WebhookActions) {
+	handlers := &webhookHandlers{tv: r.tv, acts: acts}
+
+	// swagger:operation POST /webhooks Webhooks CreateWebhookSubscription
+	//
+	// Subscribe URL to events of namespace or of all user namespaces (requires namespace ownership).
+	// Deliveries are signed with HMAC-SHA256 of body in X-Webhook-Signature header. Secret is returned only in this response.
+	// URL must be http(s) with public host, loopback, private, link-local and cluster local addresses are rejected.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/WebhookSubscriptionCreateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '201':
+	//     description: webhook subscription created
+	//     schema:
+	//       $ref: '#/definitions/WebhookSubscription'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/webhooks", handlers.createWebhookSubscriptionHandler)
+
+	// swagger:operation GET /webhooks Webhooks GetWebhookSubscriptions
+	//
+	// Get webhook subscriptions of user.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	// responses:
+	//   '200':
+	//     description: webhook subscriptions
+	//     schema:
+	//       type: object
+	//       properties:
+	//         webhooks:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/WebhookSubscription'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/webhooks", handlers.getWebhookSubscriptionsHandler)
+
+	// swagger:operation GET /webhooks/{webhook} Webhooks GetWebhookSubscription
+	//
+	// Get webhook subscription.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/WebhookID'
+	// responses:
+	//   '200':
+	//     description: webhook subscription
+	//     schema:
+	//       $ref: '#/definitions/WebhookSubscription'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/webhooks/:webhook", handlers.getWebhookSubscriptionHandler)
+
+	// swagger:operation PUT /webhooks/{webhook} Webhooks UpdateWebhookSubscription
+	//
+	// Update webhook subscription URL, secret or event types filter.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/WebhookID'
+	//  - name: body
+	//    in: body
+	//    required: true
+	//    schema:
+	//      $ref: '#/definitions/WebhookSubscriptionUpdateRequest'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: webhook subscription updated
+	//     schema:
+	//       $ref: '#/definitions/WebhookSubscription'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.PUT("/webhooks/:webhook", handlers.updateWebhookSubscriptionHandler)
+
+	// swagger:operation DELETE /webhooks/{webhook} Webhooks DeleteWebhookSubscription
+	//
+	// Delete webhook subscription with its delivery log.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/WebhookID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: webhook subscription deleted
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.DELETE("/webhooks/:webhook", handlers.deleteWebhookSubscriptionHandler)
+
+	// swagger:operation GET /webhooks/{webhook}/deliveries Webhooks GetWebhookDeliveries
+	//
+	// Get delivery log of webhook subscription.
+	// Deliveries are sorted from newest to oldest.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/WebhookID'
+	//  - $ref: '#/parameters/PageNum'
+	//  - $ref: '#/parameters/PerPageLimit'
+	// responses:
+	//   '200':
+	//     description: webhook deliveries
+	//     schema:
+	//       type: object
+	//       properties:
+	//         deliveries:
+	//           type: array
+	//           items:
+	//             $ref: '#/definitions/WebhookDelivery'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.GET("/webhooks/:webhook/deliveries", handlers.getWebhookDeliveriesHandler)
+
+	// swagger:operation POST /webhooks/{webhook}/ping Webhooks PingWebhookSubscription
+	//
+	// Send test ping event to webhook. Delivery is made once and recorded to delivery log.
+	//
+	// ---
+	// parameters:
+	//  - $ref: '#/parameters/UserIDHeader'
+	//  - $ref: '#/parameters/UserRoleHeader'
+	//  - $ref: '#/parameters/SubstitutedUserID'
+	//  - $ref: '#/parameters/WebhookID'
+	//  - $ref: '#/parameters/DryRun'
+	// responses:
+	//   '200':
+	//     description: ping delivery result
+	//     schema:
+	//       $ref: '#/definitions/WebhookDelivery'
+	//   default:
+	//     $ref: '#/responses/error'
+	r.engine.POST("/webhooks/:webhook/ping", handlers.pingWebhookSubscriptionHandler)
+}
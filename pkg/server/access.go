@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"git.containerum.net/ch/auth/proto"
 	"git.containerum.net/ch/permissions/pkg/clients"
@@ -17,8 +18,13 @@ type AccessActions interface {
 	GetUserAccesses(ctx context.Context) (*authProto.ResourcesAccess, error)
 	SetUserAccesses(ctx context.Context, accessLevel kubeClientModel.AccessLevel) error
 	GetNamespaceAccess(ctx context.Context, id string) (kubeClientModel.Namespace, error)
-	SetNamespaceAccess(ctx context.Context, id, targetUser string, accessLevel kubeClientModel.AccessLevel) error
+	SetNamespaceAccess(ctx context.Context, id string, req model.SetUserAccessRequest) error
 	DeleteNamespaceAccess(ctx context.Context, id string, targetUser string) error
+	BatchNamespaceAccesses(ctx context.Context, req model.BatchAccessRequest) (kubeClientModel.ImportResponse, error)
+	ExplainNamespaceAccess(ctx context.Context, id, targetUser string) (model.AccessExplanation, error)
+	GetVolumeAccess(ctx context.Context, id string) (model.VolumeWithPermissions, error)
+	SetVolumeAccess(ctx context.Context, id, targetUser string, accessLevel kubeClientModel.AccessLevel, expiresAt *time.Time) error
+	DeleteVolumeAccess(ctx context.Context, id string, targetUser string) error
 }
 
 func extractAccessesFromDB(ctx context.Context, db database.DB, userID string) (*authProto.ResourcesAccess, error) {
@@ -52,9 +58,21 @@ func (s *Server) GetUserAccesses(ctx context.Context) (*authProto.ResourcesAcces
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithField("user_id", userID).Info("get user resource accesses")
 
+	// user may be registered after invitation but user-created hook was not received
+	if err := s.acceptUserInvitations(ctx, userID); err != nil {
+		s.log.WithError(err).Warn("accept invitations failed")
+	}
+
 	return extractAccessesFromDB(ctx, s.db, userID)
 }
 
+func checkExpiration(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.ErrRequestValidationFailed().AddDetails("expiration time must be in future")
+	}
+	return nil
+}
+
 func updateUserAccesses(ctx context.Context, auth clients.AuthClient, db database.DB, userID string) error {
 	accesses, err := extractAccessesFromDB(ctx, db, userID)
 	if err != nil {
@@ -68,11 +86,20 @@ func (s *Server) SetUserAccesses(ctx context.Context, access kubeClientModel.Acc
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithField("user_id", userID).Infof("Set user accesses to %s", access)
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		if err := tx.SetUserAccesses(ctx, userID, access); err != nil {
 			return err
 		}
 
+		if err := auditChange(ctx, tx, model.AuditRecord{
+			Action:       "SetUserAccesses",
+			ResourceType: model.AuditResourceUser,
+			ResourceID:   userID,
+			After:        accessState(access),
+		}); err != nil {
+			return err
+		}
+
 		if err := updateUserAccesses(ctx, s.clients.Auth, tx, userID); err != nil {
 			return err
 		}
@@ -83,43 +110,129 @@ func (s *Server) SetUserAccesses(ctx context.Context, access kubeClientModel.Acc
 	return err
 }
 
-func (s *Server) SetNamespaceAccess(ctx context.Context, id, targetUser string, accessLevel kubeClientModel.AccessLevel) error {
+// grantNamespaceAccess grants access to namespace for user. Permission checks must be done by caller.
+func grantNamespaceAccess(ctx context.Context, tx database.DB, ns model.Namespace, access database.AccessListElement) error {
+	if access.ToUserID == ns.OwnerUserID {
+		return errors.ErrSetOwnerAccess()
+	}
+
+	if chkErr := organizationMemberCheck(ctx, tx, ns.OrganizationID, access.ToUserID); chkErr != nil {
+		return chkErr
+	}
+
+	return tx.SetNamespaceAccesses(ctx, ns, []database.AccessListElement{access})
+}
+
+// setNamespaceAccess grants access to namespace for user and updates user accesses. Permission checks must be done by caller.
+func setNamespaceAccess(ctx context.Context, auth clients.AuthClient, tx database.DB, ns model.Namespace, access database.AccessListElement) error {
+	if setErr := grantNamespaceAccess(ctx, tx, ns, access); setErr != nil {
+		return setErr
+	}
+
+	return updateUserAccesses(ctx, auth, tx, access.ToUserID)
+}
+
+// inviteToNamespace creates invitation for not registered user.
+func inviteToNamespace(ctx context.Context, tx database.DB, ns model.Namespace, email string, accessLevel kubeClientModel.AccessLevel, role *string, expiresAt *time.Time) error {
+	if ns.OrganizationID != nil {
+		return errors.ErrNotOrganizationMember().AddDetailF("not registered user can not be invited to organization namespace")
+	}
+
+	return tx.CreateInvitation(ctx, &model.Invitation{
+		ResourceType: model.ResourceNamespace,
+		ResourceID:   ns.ID,
+		Email:        email,
+		AccessLevel:  accessLevel,
+		Role:         role,
+		ExpiresAt:    expiresAt,
+		CreatedBy:    httputil.MustGetUserID(ctx),
+	})
+}
+
+// resolveRole returns access level and custom role name which should be written to permission.
+// Access level from request used if role not set, built-in roles are stored as plain access levels.
+func resolveRole(ctx context.Context, db database.DB, accessLevel kubeClientModel.AccessLevel, roleName *string) (kubeClientModel.AccessLevel, *string, error) {
+	if roleName == nil {
+		return accessLevel, nil, nil
+	}
+
+	if builtIn, ok := model.BuiltInRole(*roleName); ok {
+		return builtIn.AccessLevel, nil, nil
+	}
+
+	role, err := db.RoleByName(ctx, *roleName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return role.AccessLevel, &role.Name, nil
+}
+
+func (s *Server) SetNamespaceAccess(ctx context.Context, id string, req model.SetUserAccessRequest) error {
 	ownerID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
 		"owner_id":     ownerID,
-		"target_user":  targetUser,
+		"target_user":  req.Username,
 		"id":           id,
-		"access_level": accessLevel,
+		"access_level": req.Access,
+		"role":         req.Role,
+		"expires_at":   req.ExpiresAt,
 	}).Debugf("set namespace access")
 
-	err := s.db.Transactional(func(tx database.DB) error {
-		targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, targetUser)
-		if err != nil {
-			return err
-		}
+	if chkErr := checkExpiration(req.ExpiresAt); chkErr != nil {
+		return chkErr
+	}
 
-		ns, getErr := s.db.NamespaceByName(ctx, ownerID, id, IsAdminRole(ctx))
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, ownerID, id, IsAdminRole(ctx))
 		if getErr != nil {
 			return getErr
 		}
 
-		if targetUserInfo.ID == ns.OwnerUserID {
-			return errors.ErrSetOwnerAccess()
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+			return chkErr
 		}
 
-		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
-			return chkErr
+		accessLevel, role, getErr := resolveRole(ctx, tx, req.Access, req.Role)
+		if getErr != nil {
+			return getErr
 		}
 
-		if setErr := tx.SetNamespaceAccess(ctx, ns.Namespace, accessLevel, targetUserInfo.ID); setErr != nil {
-			return setErr
+		targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, req.Username)
+		if err != nil {
+			if !isNotFound(err) {
+				return err
+			}
+
+			s.log.WithField("target_user", req.Username).Infof("user not registered, creating invitation")
+			if inviteErr := inviteToNamespace(ctx, tx, ns.Namespace, req.Username, accessLevel, role, req.ExpiresAt); inviteErr != nil {
+				return inviteErr
+			}
+
+			return auditChange(ctx, tx, model.AuditRecord{
+				Action:       "SetNamespaceAccess",
+				ResourceType: model.ResourceNamespace,
+				ResourceID:   ns.ID,
+				Target:       req.Username,
+				After:        invitationState(accessLevel, role, req.ExpiresAt),
+			})
 		}
 
-		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, targetUserInfo.ID); updErr != nil {
-			return updErr
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, targetUserInfo.ID)
+		if getErr != nil {
+			return getErr
 		}
 
-		return nil
+		if setErr := setNamespaceAccess(ctx, s.clients.Auth, tx, ns.Namespace, database.AccessListElement{
+			ToUserID:    targetUserInfo.ID,
+			AccessLevel: accessLevel,
+			Role:        role,
+			ExpiresAt:   req.ExpiresAt,
+		}); setErr != nil {
+			return setErr
+		}
+
+		return auditAccessChange(ctx, tx, "SetNamespaceAccess", model.ResourceNamespace, ns.ID, targetUserInfo.ID, before)
 	})
 
 	return err
@@ -155,7 +268,7 @@ func (s *Server) DeleteNamespaceAccess(ctx context.Context, id string, targetUse
 		"target_user": targetUser,
 	}).Debugf("delete namespace access")
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, targetUser)
 		if err != nil {
 			return err
@@ -166,19 +279,342 @@ func (s *Server) DeleteNamespaceAccess(ctx context.Context, id string, targetUse
 			return getErr
 		}
 
-		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
 			return chkErr
 		}
 
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, targetUserInfo.ID)
+		if getErr != nil {
+			return getErr
+		}
+
 		if delErr := tx.DeleteNamespaceAccess(ctx, ns.Namespace, targetUserInfo.ID); delErr != nil {
 			return delErr
 		}
 
+		// user may still have access through groups
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceNamespace, ns.ID); applyErr != nil {
+			return applyErr
+		}
+
 		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, targetUserInfo.ID); updErr != nil {
 			return updErr
 		}
 
-		return nil
+		return auditAccessChange(ctx, tx, "DeleteNamespaceAccess", model.ResourceNamespace, ns.ID, targetUserInfo.ID, before)
+	})
+
+	return err
+}
+
+// permissionSources returns sources of access given by permission. Limit source added if access was limited.
+func permissionSources(perm model.Permission, effective *model.EffectivePermission) []model.AccessSource {
+	src := model.AccessSource{
+		Access:        perm.CurrentAccessLevel,
+		GrantedAccess: perm.InitialAccessLevel,
+		GroupID:       perm.GroupID,
+		Role:          perm.Role,
+		ExpiresAt:     perm.ExpiresAt,
+		Effective:     effective != nil && effective.ID == perm.ID,
+	}
+	switch {
+	case perm.ResourceType == model.ResourceProject:
+		src.Type = model.AccessSourceProject
+		src.ProjectID = &perm.ResourceID
+	case perm.InitialAccessLevel == kubeClientModel.Owner:
+		src.Type = model.AccessSourceOwner
+	case perm.GroupID != nil:
+		src.Type = model.AccessSourceGroup
+	default:
+		src.Type = model.AccessSourceDirect
+	}
+
+	ret := []model.AccessSource{src}
+	// current access never exceeds initial one
+	if perm.CurrentAccessLevel != perm.InitialAccessLevel {
+		ret = append(ret, model.AccessSource{
+			Type:          model.AccessSourceLimit,
+			Access:        perm.CurrentAccessLevel,
+			GrantedAccess: perm.InitialAccessLevel,
+			GroupID:       src.GroupID,
+			ProjectID:     src.ProjectID,
+			Effective:     src.Effective,
+		})
+	}
+
+	return ret
+}
+
+// groupGrantSources returns sources for group grants not applied to user permission,
+// because direct permission has precedence or other group gives greater access.
+func groupGrantSources(grants, perms []model.Permission) []model.AccessSource {
+	ret := make([]model.AccessSource, 0)
+	for _, grant := range grants {
+		applied := false
+		for _, perm := range perms {
+			if perm.ResourceType == grant.ResourceType && perm.GroupID != nil && *perm.GroupID == *grant.GroupID {
+				applied = true
+			}
+		}
+		if applied {
+			continue
+		}
+
+		src := model.AccessSource{
+			Type:    model.AccessSourceGroup,
+			Access:  grant.CurrentAccessLevel,
+			GroupID: grant.GroupID,
+		}
+		if grant.ResourceType == model.ResourceProject {
+			src.Type = model.AccessSourceProject
+			src.ProjectID = &grant.ResourceID
+		}
+		ret = append(ret, src)
+	}
+	return ret
+}
+
+func (s *Server) ExplainNamespaceAccess(ctx context.Context, id, targetUser string) (model.AccessExplanation, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"id":          id,
+		"target_user": targetUser,
+	}).Infof("explain namespace access")
+
+	targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, targetUser)
+	if err != nil {
+		return model.AccessExplanation{}, err
+	}
+
+	ns, err := s.db.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+	if err != nil {
+		return model.AccessExplanation{}, err
+	}
+
+	// user can explain own access, accesses of other users are visible only to ones managing them
+	if targetUserInfo.ID != userID {
+		if chkErr := NamespaceActionCheck(ctx, s.db, ns, model.ActionAccessManage); chkErr != nil {
+			return model.AccessExplanation{}, chkErr
+		}
+	}
+
+	ret := model.AccessExplanation{
+		ResourceID: ns.ID,
+		UserID:     targetUserInfo.ID,
+		UserLogin:  targetUserInfo.Login,
+		Access:     kubeClientModel.None,
+		Sources:    make([]model.AccessSource, 0),
+	}
+
+	effective, err := s.db.EffectivePermission(ctx, model.ResourceNamespace, ns.ID, targetUserInfo.ID)
+	if err != nil {
+		return model.AccessExplanation{}, err
+	}
+	if effective != nil {
+		ret.Access = effective.CurrentAccessLevel
+	}
+
+	perms, err := s.db.UserNamespacePermissions(ctx, ns.Namespace, targetUserInfo.ID)
+	if err != nil {
+		return model.AccessExplanation{}, err
+	}
+	for _, perm := range perms {
+		ret.Sources = append(ret.Sources, permissionSources(perm, effective)...)
+	}
+
+	grants, err := s.db.UserGroupGrants(ctx, model.ResourceNamespace, ns.ID, targetUserInfo.ID)
+	if err != nil {
+		return model.AccessExplanation{}, err
+	}
+	if ns.ProjectID != nil {
+		projectGrants, getErr := s.db.UserGroupGrants(ctx, model.ResourceProject, *ns.ProjectID, targetUserInfo.ID)
+		if getErr != nil {
+			return model.AccessExplanation{}, getErr
+		}
+		grants = append(grants, projectGrants...)
+	}
+	ret.Sources = append(ret.Sources, groupGrantSources(grants, perms)...)
+
+	return ret, nil
+}
+
+func (s *Server) SetVolumeAccess(ctx context.Context, id, targetUser string, accessLevel kubeClientModel.AccessLevel, expiresAt *time.Time) error {
+	ownerID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"owner_id":     ownerID,
+		"target_user":  targetUser,
+		"id":           id,
+		"access_level": accessLevel,
+		"expires_at":   expiresAt,
+	}).Debugf("set volume access")
+
+	if chkErr := checkExpiration(expiresAt); chkErr != nil {
+		return chkErr
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, targetUser)
+		if err != nil {
+			return err
+		}
+
+		vol, getErr := tx.VolumeByID(ctx, ownerID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if targetUserInfo.ID == vol.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		if chkErr := VolumeActionCheck(ctx, tx, vol, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceVolume, vol.ID, targetUserInfo.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if setErr := tx.SetVolumeAccess(ctx, vol.Volume, accessLevel, targetUserInfo.ID, expiresAt); setErr != nil {
+			return setErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, targetUserInfo.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "SetVolumeAccess", model.ResourceVolume, vol.ID, targetUserInfo.ID, before)
+	})
+
+	return err
+}
+
+func (s *Server) GetVolumeAccess(ctx context.Context, id string) (model.VolumeWithPermissions, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get volume access")
+
+	vol, err := s.db.VolumeByID(ctx, userID, id, IsAdminRole(ctx))
+	if err != nil {
+		return model.VolumeWithPermissions{}, err
+	}
+	err = s.db.VolumePermissions(ctx, &vol)
+	if err != nil {
+		return vol, err
+	}
+
+	AddOwnerLogin(ctx, &vol.Resource, s.clients.User)
+	AddUserLogins(ctx, vol.Permissions, s.clients.User)
+
+	return vol, nil
+}
+
+func (s *Server) DeleteVolumeAccess(ctx context.Context, id string, targetUser string) error {
+	ownerID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"owner_id":    ownerID,
+		"id":          id,
+		"target_user": targetUser,
+	}).Debugf("delete volume access")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		targetUserInfo, err := s.clients.User.UserInfoByLogin(ctx, targetUser)
+		if err != nil {
+			return err
+		}
+
+		vol, getErr := tx.VolumeByID(ctx, ownerID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := VolumeActionCheck(ctx, tx, vol, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceVolume, vol.ID, targetUserInfo.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if delErr := tx.DeleteVolumeAccess(ctx, vol.Volume, targetUserInfo.ID); delErr != nil {
+			return delErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, targetUserInfo.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "DeleteVolumeAccess", model.ResourceVolume, vol.ID, targetUserInfo.ID, before)
+	})
+
+	return err
+}
+
+// RevokeExpiredAccesses deletes expired permissions and updates accesses of affected users.
+// Permissions of each resource are revoked in separate transaction, so failure on one resource does not block others.
+func (s *Server) RevokeExpiredAccesses(ctx context.Context) error {
+	now := time.Now()
+	s.log.WithField("expired_at", now).Debugf("revoke expired accesses")
+
+	expiredPerms, err := s.db.ExpiredPermissions(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for i, perm := range expiredPerms {
+		if i > 0 && expiredPerms[i-1].ResourceType == perm.ResourceType && expiredPerms[i-1].ResourceID == perm.ResourceID {
+			continue
+		}
+
+		if revokeErr := s.revokeExpiredResourceAccesses(ctx, perm.ResourceType, perm.ResourceID, now); revokeErr != nil {
+			s.log.WithError(revokeErr).WithFields(logrus.Fields{
+				"resource_id": perm.ResourceID,
+				"kind":        perm.ResourceType,
+			}).Errorf("revoke expired accesses failed")
+		}
+	}
+
+	return nil
+}
+
+// revokeExpiredResourceAccesses deletes expired permissions to resource and updates accesses of affected users.
+func (s *Server) revokeExpiredResourceAccesses(ctx context.Context, kind model.ResourceType, resourceID string, now time.Time) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		deletedPerms, delErr := tx.DeleteExpiredPermissions(ctx, kind, resourceID, now)
+		if delErr != nil {
+			return delErr
+		}
+
+		for _, perm := range deletedPerms {
+			s.log.WithFields(logrus.Fields{
+				"user_id":     perm.UserID,
+				"resource_id": perm.ResourceID,
+				"kind":        perm.ResourceType,
+				"expires_at":  perm.ExpiresAt,
+			}).Infof("access expired")
+
+			if auditErr := auditChange(ctx, tx, model.AuditRecord{
+				Action:       "RevokeExpiredAccesses",
+				ResourceType: perm.ResourceType,
+				ResourceID:   perm.ResourceID,
+				Target:       perm.UserID,
+				Before:       permissionState(&perm),
+			}); auditErr != nil {
+				return auditErr
+			}
+
+			if pubErr := publishAccessChanged(ctx, tx, perm.ResourceType, perm.ResourceID, "user_id", perm.UserID, permissionState(&perm), nil); pubErr != nil {
+				return pubErr
+			}
+		}
+
+		// members of groups granted to resource get group access back instead of expired direct one
+		return applyGroupPermissions(ctx, s.clients.Auth, tx, kind, resourceID, deletedPerms...)
 	})
 
 	return err
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type AccessRequestActions interface {
+	CreateNamespaceAccessRequest(ctx context.Context, id string, req model.CreateAccessRequestRequest) (model.AccessRequest, error)
+	GetNamespaceAccessRequests(ctx context.Context, id string, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	GetAccessRequestsInbox(ctx context.Context, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	GetUserAccessRequests(ctx context.Context, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error)
+	ApproveAccessRequest(ctx context.Context, id string, req model.ResolveAccessRequestRequest) error
+	DenyAccessRequest(ctx context.Context, id string, req model.ResolveAccessRequestRequest) error
+	CancelAccessRequest(ctx context.Context, id string) error
+}
+
+func checkAccessRequestStatus(status model.AccessRequestStatus) error {
+	switch status {
+	case "", model.AccessRequestPending, model.AccessRequestApproved, model.AccessRequestDenied, model.AccessRequestCancelled:
+		return nil
+	default:
+		return errors.ErrRequestValidationFailed().AddDetailF("invalid access request status %s", status)
+	}
+}
+
+func addAccessRequestsLogins(ctx context.Context, requests []model.AccessRequestWithResource, client clients.UserManagerClient) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	userIDs := make([]string, len(requests))
+	for i := range requests {
+		userIDs[i] = requests[i].UserID
+	}
+	userLogins, err := client.UserLoginIDList(ctx, userIDs...)
+	if err != nil {
+		return err
+	}
+
+	for i := range requests {
+		requests[i].UserLogin = userLogins[requests[i].UserID]
+	}
+	return nil
+}
+
+// accessRequestState returns audit state for access request.
+func accessRequestState(req model.AccessRequest) model.AuditState {
+	return model.AuditState{
+		"access":         req.AccessLevel,
+		"request_status": req.Status,
+	}
+}
+
+func (s *Server) CreateNamespaceAccessRequest(ctx context.Context, id string, req model.CreateAccessRequestRequest) (model.AccessRequest, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+		"access":  req.AccessLevel,
+	}).Infof("create namespace access request")
+
+	switch req.AccessLevel {
+	case kubeClientModel.Write, kubeClientModel.ReadDelete, kubeClientModel.Read:
+	default:
+		return model.AccessRequest{}, errors.ErrRequestValidationFailed().AddDetailF("access level %s can not be requested", req.AccessLevel)
+	}
+
+	var ret model.AccessRequest
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		// user has no access to namespace yet, so search it like admin
+		ns, getErr := tx.NamespaceByName(ctx, userID, id, true)
+		if getErr != nil {
+			return getErr
+		}
+
+		if ns.OwnerUserID == userID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		ret = model.AccessRequest{
+			ResourceType:  model.ResourceNamespace,
+			ResourceID:    ns.ID,
+			UserID:        userID,
+			AccessLevel:   req.AccessLevel,
+			Justification: req.Justification,
+			Status:        model.AccessRequestPending,
+		}
+
+		if createErr := tx.CreateAccessRequest(ctx, &ret); createErr != nil {
+			return createErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "CreateNamespaceAccessRequest",
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			Target:       userID,
+			After:        accessRequestState(ret),
+		})
+	})
+
+	return ret, err
+}
+
+func (s *Server) GetNamespaceAccessRequests(ctx context.Context, id string, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+		"status":  status,
+	}).Infof("get namespace access requests")
+
+	if chkErr := checkAccessRequestStatus(status); chkErr != nil {
+		return nil, chkErr
+	}
+
+	ns, err := s.db.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if chkErr := NamespaceActionCheck(ctx, s.db, ns, model.ActionAccessManage); chkErr != nil {
+		return nil, chkErr
+	}
+
+	ret, err := s.db.NamespaceAccessRequests(ctx, ns.Namespace, status)
+	if err != nil {
+		return nil, err
+	}
+
+	addAccessRequestsLogins(ctx, ret, s.clients.User)
+
+	return ret, nil
+}
+
+func (s *Server) GetAccessRequestsInbox(ctx context.Context, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"status":  status,
+	}).Infof("get access requests inbox")
+
+	if chkErr := checkAccessRequestStatus(status); chkErr != nil {
+		return nil, chkErr
+	}
+
+	ownerID := userID
+	if IsAdminRole(ctx) {
+		ownerID = "" // admin resolves requests to all resources
+	}
+
+	ret, err := s.db.OwnerAccessRequests(ctx, ownerID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	addAccessRequestsLogins(ctx, ret, s.clients.User)
+
+	return ret, nil
+}
+
+func (s *Server) GetUserAccessRequests(ctx context.Context, status model.AccessRequestStatus) ([]model.AccessRequestWithResource, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"status":  status,
+	}).Infof("get user access requests")
+
+	if chkErr := checkAccessRequestStatus(status); chkErr != nil {
+		return nil, chkErr
+	}
+
+	return s.db.UserAccessRequests(ctx, userID, status)
+}
+
+func (s *Server) ApproveAccessRequest(ctx context.Context, id string, req model.ResolveAccessRequestRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"request_id": id,
+	}).Infof("approve access request")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		accessReq, getErr := tx.AccessRequestByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		ns, getErr := tx.NamespaceByName(ctx, userID, accessReq.ResourceName, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, accessReq.UserID)
+		if getErr != nil {
+			return getErr
+		}
+
+		accessReq.Status = model.AccessRequestApproved
+		accessReq.ResolvedBy = &userID
+		accessReq.Comment = req.Comment
+		if updErr := tx.ResolveAccessRequest(ctx, &accessReq.AccessRequest); updErr != nil {
+			return updErr
+		}
+
+		if setErr := setNamespaceAccess(ctx, s.clients.Auth, tx, ns.Namespace, database.AccessListElement{
+			ToUserID:    accessReq.UserID,
+			AccessLevel: accessReq.AccessLevel,
+		}); setErr != nil {
+			return setErr
+		}
+
+		return auditAccessChange(ctx, tx, "ApproveAccessRequest", model.ResourceNamespace, ns.ID, accessReq.UserID, before)
+	})
+
+	return err
+}
+
+func (s *Server) DenyAccessRequest(ctx context.Context, id string, req model.ResolveAccessRequestRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"request_id": id,
+	}).Infof("deny access request")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		accessReq, getErr := tx.AccessRequestByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		ns, getErr := tx.NamespaceByName(ctx, userID, accessReq.ResourceName, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		before := accessRequestState(accessReq.AccessRequest)
+		accessReq.Status = model.AccessRequestDenied
+		accessReq.ResolvedBy = &userID
+		accessReq.Comment = req.Comment
+		if updErr := tx.ResolveAccessRequest(ctx, &accessReq.AccessRequest); updErr != nil {
+			return updErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "DenyAccessRequest",
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			Target:       accessReq.UserID,
+			Before:       before,
+			After:        accessRequestState(accessReq.AccessRequest),
+		})
+	})
+
+	return err
+}
+
+func (s *Server) CancelAccessRequest(ctx context.Context, id string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"request_id": id,
+	}).Infof("cancel access request")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		accessReq, getErr := tx.AccessRequestByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if accessReq.UserID != userID && !IsAdminRole(ctx) {
+			return errors.ErrResourceNotExists().AddDetailF("access request %s not exists", id)
+		}
+
+		before := accessRequestState(accessReq.AccessRequest)
+		accessReq.Status = model.AccessRequestCancelled
+		accessReq.ResolvedBy = &userID
+		if updErr := tx.ResolveAccessRequest(ctx, &accessReq.AccessRequest); updErr != nil {
+			return updErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "CancelAccessRequest",
+			ResourceType: accessReq.ResourceType,
+			ResourceID:   accessReq.ResourceID,
+			Target:       accessReq.UserID,
+			Before:       before,
+			After:        accessRequestState(accessReq.AccessRequest),
+		})
+	})
+
+	return err
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+)
+
+func TestPermissionSources(t *testing.T) {
+	groupID := "group"
+	projectID := "project"
+
+	for _, tc := range []struct {
+		name      string
+		perm      model.Permission
+		effective *model.EffectivePermission
+		expected  []model.AccessSource
+	}{
+		{
+			name:      "owner",
+			perm:      model.Permission{ID: "perm", ResourceType: model.ResourceNamespace, InitialAccessLevel: kubeClientModel.Owner, CurrentAccessLevel: kubeClientModel.Owner},
+			effective: &model.EffectivePermission{Permission: model.Permission{ID: "perm"}},
+			expected:  []model.AccessSource{{Type: model.AccessSourceOwner, Access: kubeClientModel.Owner, GrantedAccess: kubeClientModel.Owner, Effective: true}},
+		},
+		{
+			name:     "direct without effective permission",
+			perm:     model.Permission{ID: "perm", ResourceType: model.ResourceNamespace, InitialAccessLevel: kubeClientModel.Write, CurrentAccessLevel: kubeClientModel.Write},
+			expected: []model.AccessSource{{Type: model.AccessSourceDirect, Access: kubeClientModel.Write, GrantedAccess: kubeClientModel.Write}},
+		},
+		{
+			name:      "group not effective",
+			perm:      model.Permission{ID: "perm", ResourceType: model.ResourceNamespace, GroupID: &groupID, InitialAccessLevel: kubeClientModel.Read, CurrentAccessLevel: kubeClientModel.Read},
+			effective: &model.EffectivePermission{Permission: model.Permission{ID: "other"}},
+			expected:  []model.AccessSource{{Type: model.AccessSourceGroup, Access: kubeClientModel.Read, GrantedAccess: kubeClientModel.Read, GroupID: &groupID}},
+		},
+		{
+			name:      "project limited",
+			perm:      model.Permission{ID: "perm", ResourceType: model.ResourceProject, ResourceID: projectID, InitialAccessLevel: kubeClientModel.Write, CurrentAccessLevel: kubeClientModel.Read},
+			effective: &model.EffectivePermission{Permission: model.Permission{ID: "perm"}},
+			expected: []model.AccessSource{
+				{Type: model.AccessSourceProject, Access: kubeClientModel.Read, GrantedAccess: kubeClientModel.Write, ProjectID: &projectID, Effective: true},
+				{Type: model.AccessSourceLimit, Access: kubeClientModel.Read, GrantedAccess: kubeClientModel.Write, ProjectID: &projectID, Effective: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := permissionSources(tc.perm, tc.effective)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGroupGrantSources(t *testing.T) {
+	groupID, otherGroupID := "group", "other-group"
+	projectID := "project"
+
+	nsGrant := model.Permission{ResourceType: model.ResourceNamespace, ResourceID: "ns", GroupID: &groupID, CurrentAccessLevel: kubeClientModel.Write}
+	projectGrant := model.Permission{ResourceType: model.ResourceProject, ResourceID: projectID, GroupID: &groupID, CurrentAccessLevel: kubeClientModel.Read}
+
+	for _, tc := range []struct {
+		name     string
+		grants   []model.Permission
+		perms    []model.Permission
+		expected []model.AccessSource
+	}{
+		{name: "no grants", expected: []model.AccessSource{}},
+		{
+			name:     "applied grant skipped",
+			grants:   []model.Permission{nsGrant},
+			perms:    []model.Permission{{ResourceType: model.ResourceNamespace, GroupID: &groupID}},
+			expected: []model.AccessSource{},
+		},
+		{
+			name:     "direct permission has precedence",
+			grants:   []model.Permission{nsGrant},
+			perms:    []model.Permission{{ResourceType: model.ResourceNamespace}},
+			expected: []model.AccessSource{{Type: model.AccessSourceGroup, Access: kubeClientModel.Write, GroupID: &groupID}},
+		},
+		{
+			name:     "other group gives greater access",
+			grants:   []model.Permission{nsGrant},
+			perms:    []model.Permission{{ResourceType: model.ResourceNamespace, GroupID: &otherGroupID}},
+			expected: []model.AccessSource{{Type: model.AccessSourceGroup, Access: kubeClientModel.Write, GroupID: &groupID}},
+		},
+		{
+			name:     "same group applied to namespace only",
+			grants:   []model.Permission{nsGrant, projectGrant},
+			perms:    []model.Permission{{ResourceType: model.ResourceNamespace, GroupID: &groupID}},
+			expected: []model.AccessSource{{Type: model.AccessSourceProject, Access: kubeClientModel.Read, GroupID: &groupID, ProjectID: &projectID}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := groupGrantSources(tc.grants, tc.perms)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"net/textproto"
+	"reflect"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditActions interface {
+	GetAuditRecords(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error)
+	GetOwnerAuditRecords(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error)
+}
+
+// auditChange writes record about change to audit log.
+// Must be called with transaction making the change so record is committed or rolled back together with it.
+func auditChange(ctx context.Context, tx database.DB, record model.AuditRecord) error {
+	// changes made by service jobs have no user in context
+	if userID, ok := ctx.Value(httputil.UserIDContextKey).(string); ok {
+		record.ActorUserID = userID
+
+		// SubstituteUserMiddleware replaces user in context with user from query, header keeps real one
+		headerUserID := httputil.RequestXHeadersMap(ctx)[textproto.CanonicalMIMEHeaderKey(httputil.UserIDXHeader)]
+		if headerUserID != "" && headerUserID != userID {
+			record.ActorUserID = headerUserID
+			record.EffectiveUserID = userID
+		}
+	}
+	record.RequestID, _ = ctx.Value(httputil.RequestIDContextKey).(string)
+
+	return tx.AddAuditRecord(ctx, &record)
+}
+
+// auditResourceChange writes record about change of resource itself.
+func auditResourceChange(ctx context.Context, tx database.DB, action string, kind model.ResourceType, resourceID string, before, after model.AuditState) error {
+	return auditChange(ctx, tx, model.AuditRecord{
+		Action:       action,
+		ResourceType: kind,
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// accessState returns audit state for access level, nil for no access.
+func accessState(level kubeClientModel.AccessLevel) model.AuditState {
+	if level == "" || level == kubeClientModel.None {
+		return nil
+	}
+	return model.AuditState{"access": level}
+}
+
+// permissionState returns audit state for permission of user, nil for missing permission.
+func permissionState(perm *model.Permission) model.AuditState {
+	if perm == nil {
+		return nil
+	}
+	state := accessState(perm.CurrentAccessLevel)
+	if state == nil {
+		return nil
+	}
+	if perm.Role != nil {
+		state["role"] = *perm.Role
+	}
+	if perm.ExpiresAt != nil {
+		state["expires_at"] = *perm.ExpiresAt
+	}
+	return state
+}
+
+// effectiveAccessState returns audit state for effective access of user to resource.
+func effectiveAccessState(ctx context.Context, tx database.DB, kind model.ResourceType, resourceID, userID string) (model.AuditState, error) {
+	perm, err := tx.EffectivePermission(ctx, kind, resourceID, userID)
+	if err != nil || perm == nil {
+		return nil, err
+	}
+	return permissionState(&perm.Permission), nil
+}
+
+// auditAccessChange writes record about change of user access to resource and publishes access change event.
+// State before change must be got by effectiveAccessState, state after change is read from transaction.
+func auditAccessChange(ctx context.Context, tx database.DB, action string, kind model.ResourceType, resourceID, userID string, before model.AuditState) error {
+	after, err := effectiveAccessState(ctx, tx, kind, resourceID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		if pubErr := publishAccessChanged(ctx, tx, kind, resourceID, "user_id", userID, before, after); pubErr != nil {
+			return pubErr
+		}
+	}
+
+	return auditChange(ctx, tx, model.AuditRecord{
+		Action:       action,
+		ResourceType: kind,
+		ResourceID:   resourceID,
+		Target:       userID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// groupGrantState returns audit state for access granted to group, nil if group has no access to resource.
+func groupGrantState(ctx context.Context, tx database.DB, kind model.ResourceType, resourceID, groupID string) (model.AuditState, error) {
+	grants, err := tx.ResourceGroupPermissions(ctx, kind, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range grants {
+		if v.GroupID == groupID {
+			state := model.AuditState{"access": v.AccessLevel}
+			if len(v.RoleMapping) > 0 {
+				state["role_mapping"] = v.RoleMapping
+			}
+			return state, nil
+		}
+	}
+	return nil, nil
+}
+
+// auditGroupChange writes record about change of group access to resource and publishes access change event.
+// State before change must be got by groupGrantState, state after change is read from transaction.
+func auditGroupChange(ctx context.Context, tx database.DB, action string, kind model.ResourceType, resourceID, groupID string, before model.AuditState) error {
+	after, err := groupGrantState(ctx, tx, kind, resourceID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		if pubErr := publishAccessChanged(ctx, tx, kind, resourceID, "group_id", groupID, before, after); pubErr != nil {
+			return pubErr
+		}
+	}
+
+	return auditChange(ctx, tx, model.AuditRecord{
+		Action:       action,
+		ResourceType: kind,
+		ResourceID:   resourceID,
+		Target:       groupID,
+		Before:       before,
+		After:        after,
+	})
+}
+
+// invitationState returns audit state for invitation of not registered user.
+func invitationState(accessLevel kubeClientModel.AccessLevel, role *string, expiresAt *time.Time) model.AuditState {
+	state := model.AuditState{"access": accessLevel, "invitation": true}
+	if role != nil {
+		state["role"] = *role
+	}
+	if expiresAt != nil {
+		state["expires_at"] = *expiresAt
+	}
+	return state
+}
+
+// quotaState returns audit state for quota of namespace.
+func quotaState(ns model.Namespace) model.AuditState {
+	state := model.AuditState{
+		"cpu":              ns.CPU,
+		"ram":              ns.RAM,
+		"max_ext_services": ns.MaxExtServices,
+		"max_int_services": ns.MaxIntServices,
+		"max_traffic":      ns.MaxTraffic,
+	}
+	if ns.TariffID != nil {
+		state["tariff_id"] = *ns.TariffID
+	}
+	return state
+}
+
+// namespaceState returns audit state for created or deleted namespace.
+func namespaceState(ns model.Namespace) model.AuditState {
+	state := quotaState(ns)
+	state["label"] = ns.Label
+	state["owner_user_id"] = ns.OwnerUserID
+	return state
+}
+
+// volumeState returns audit state for created or deleted volume.
+func volumeState(vol model.Volume) model.AuditState {
+	state := model.AuditState{
+		"label":         vol.Label,
+		"owner_user_id": vol.OwnerUserID,
+		"capacity":      vol.Capacity,
+		"namespace_id":  vol.NamespaceID,
+	}
+	if vol.TariffID != nil {
+		state["tariff_id"] = *vol.TariffID
+	}
+	return state
+}
+
+// organizationState returns audit state for created or deleted organization.
+func organizationState(org model.Organization) model.AuditState {
+	return model.AuditState{"label": org.Label, "owner_user_id": org.OwnerUserID}
+}
+
+// roleState returns audit state for role.
+func roleState(role model.Role) model.AuditState {
+	return model.AuditState{"access": role.AccessLevel, "actions": role.Actions, "description": role.Description}
+}
+
+// auditFilter converts query to database filter resolving actor login.
+func (s *Server) auditFilter(ctx context.Context, query model.AuditQuery) (database.AuditFilter, error) {
+	filter := database.AuditFilter{
+		ResourceType: query.ResourceType,
+		ResourceID:   query.ResourceID,
+		From:         query.From,
+		To:           query.To,
+	}
+	filter.Limit = query.PerPage
+	filter.SetPage(query.Page)
+
+	if query.Actor != "" {
+		actor, err := s.clients.User.UserInfoByLogin(ctx, query.Actor)
+		if err != nil {
+			return filter, err
+		}
+		filter.ActorUserID = actor.ID
+	}
+
+	return filter, nil
+}
+
+func (s *Server) GetAuditRecords(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+	}).Infof("get audit records %+v", query)
+
+	filter, err := s.auditFilter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.AuditRecords(ctx, filter)
+}
+
+func (s *Server) GetOwnerAuditRecords(ctx context.Context, query model.AuditQuery) ([]model.AuditRecord, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+	}).Infof("get owner audit records %+v", query)
+
+	filter, err := s.auditFilter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	filter.OwnerUserID = userID
+
+	return s.db.AuditRecords(ctx, filter)
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	umtypes "git.containerum.net/ch/user-manager/pkg/models"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+const batchRolledBackMessage = "not applied: batch rolled back"
+
+// accessBatch caches user manager responses so each user and group is fetched once per batch.
+type accessBatch struct {
+	client clients.UserManagerClient
+
+	users    map[string]*umtypes.User
+	userErrs map[string]error
+	groups   map[string]*kubeClientModel.UserGroup
+}
+
+func newAccessBatch(client clients.UserManagerClient) *accessBatch {
+	return &accessBatch{
+		client:   client,
+		users:    make(map[string]*umtypes.User),
+		userErrs: make(map[string]error),
+		groups:   make(map[string]*kubeClientModel.UserGroup),
+	}
+}
+
+func (b *accessBatch) userByLogin(ctx context.Context, login string) (*umtypes.User, error) {
+	if user, ok := b.users[login]; ok {
+		return user, nil
+	}
+	if err, ok := b.userErrs[login]; ok {
+		return nil, err
+	}
+
+	user, err := b.client.UserInfoByLogin(ctx, login)
+	if err != nil {
+		b.userErrs[login] = err
+		return nil, err
+	}
+	b.users[login] = user
+	return user, nil
+}
+
+func (b *accessBatch) group(ctx context.Context, groupID string) (*kubeClientModel.UserGroup, error) {
+	if group, ok := b.groups[groupID]; ok {
+		return group, nil
+	}
+
+	group, err := b.client.Group(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	b.groups[groupID] = group
+	return group, nil
+}
+
+func batchChangeName(change model.BatchAccessChange) string {
+	if change.GroupID != "" {
+		return change.GroupID
+	}
+	return change.Username
+}
+
+func validateBatchChange(change model.BatchAccessChange) error {
+	if (change.Username == "") == (change.GroupID == "") {
+		return errors.ErrRequestValidationFailed().AddDetailF("exactly one of username and group_id must be set")
+	}
+
+	if change.Revoke {
+		return nil
+	}
+
+	if change.GroupID != "" && (change.Role != nil || change.ExpiresAt != nil) {
+		return errors.ErrRequestValidationFailed().AddDetailF("role and expiration can be set only for user")
+	}
+
+	return checkExpiration(change.ExpiresAt)
+}
+
+// applyAccessChange applies single change of batch and returns users whose accesses must be updated.
+func (s *Server) applyAccessChange(ctx context.Context, tx database.DB, b *accessBatch, change model.BatchAccessChange) ([]model.Permission, error) {
+	if err := validateBatchChange(change); err != nil {
+		return nil, err
+	}
+
+	ns, err := tx.NamespaceByName(ctx, httputil.MustGetUserID(ctx), change.Namespace, IsAdminRole(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if change.GroupID != "" {
+		return s.applyGroupAccessChange(ctx, tx, b, ns, change)
+	}
+
+	if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+		return nil, chkErr
+	}
+
+	user, err := b.userByLogin(ctx, change.Username)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	notRegistered := err != nil
+
+	if change.Revoke {
+		if notRegistered {
+			if delErr := tx.DeleteNamespaceInvitation(ctx, ns.Namespace, change.Username); delErr != nil {
+				return nil, delErr
+			}
+
+			return nil, auditChange(ctx, tx, model.AuditRecord{
+				Action:       "BatchNamespaceAccesses",
+				ResourceType: model.ResourceNamespace,
+				ResourceID:   ns.ID,
+				Target:       change.Username,
+				Before:       model.AuditState{"invitation": true},
+			})
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, user.ID)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if delErr := tx.DeleteNamespaceAccess(ctx, ns.Namespace, user.ID); delErr != nil {
+			return nil, delErr
+		}
+
+		// user may still have access through groups
+		materializedPerms, materializeErr := tx.MaterializeGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
+		if materializeErr != nil {
+			return nil, materializeErr
+		}
+
+		if auditErr := auditAccessChange(ctx, tx, "BatchNamespaceAccesses", model.ResourceNamespace, ns.ID, user.ID, before); auditErr != nil {
+			return nil, auditErr
+		}
+
+		return append(materializedPerms, model.Permission{UserID: user.ID}), nil
+	}
+
+	accessLevel, role, err := resolveRole(ctx, tx, change.Access, change.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if notRegistered {
+		if inviteErr := inviteToNamespace(ctx, tx, ns.Namespace, change.Username, accessLevel, role, change.ExpiresAt); inviteErr != nil {
+			return nil, inviteErr
+		}
+
+		return nil, auditChange(ctx, tx, model.AuditRecord{
+			Action:       "BatchNamespaceAccesses",
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			Target:       change.Username,
+			After:        invitationState(accessLevel, role, change.ExpiresAt),
+		})
+	}
+
+	before, err := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if setErr := grantNamespaceAccess(ctx, tx, ns.Namespace, database.AccessListElement{
+		ToUserID:    user.ID,
+		AccessLevel: accessLevel,
+		Role:        role,
+		ExpiresAt:   change.ExpiresAt,
+	}); setErr != nil {
+		return nil, setErr
+	}
+
+	if auditErr := auditAccessChange(ctx, tx, "BatchNamespaceAccesses", model.ResourceNamespace, ns.ID, user.ID, before); auditErr != nil {
+		return nil, auditErr
+	}
+
+	return []model.Permission{{UserID: user.ID}}, nil
+}
+
+func (s *Server) applyGroupAccessChange(ctx context.Context, tx database.DB, b *accessBatch, ns model.NamespaceWithPermissions, change model.BatchAccessChange) ([]model.Permission, error) {
+	if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionGroupManage); chkErr != nil {
+		return nil, chkErr
+	}
+
+	before, err := groupGrantState(ctx, tx, model.ResourceNamespace, ns.ID, change.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changedPerms []model.Permission
+	if change.Revoke {
+		delPerms, delErr := tx.DeleteGroupFromNamespace(ctx, ns.Namespace, change.GroupID)
+		if delErr != nil {
+			return nil, delErr
+		}
+		changedPerms = delPerms
+	} else {
+		req := model.ProjectAddGroupRequest{GroupID: change.GroupID}
+		if change.Access != "" {
+			req.AccessLevel = &change.Access
+		}
+		accessLevel, err := groupAccessLevel(req)
+		if err != nil {
+			return nil, err
+		}
+
+		group, err := b.group(ctx, change.GroupID)
+		if err != nil {
+			return nil, err
+		}
+
+		if syncErr := setGroupMembers(ctx, tx, change.GroupID, group, s.cfg.GroupRoleMapping); syncErr != nil {
+			return nil, syncErr
+		}
+
+		grants, err := tx.ResourceGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// role mapping of existing grant is kept
+		grant := model.GroupPermission{
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			GroupID:      change.GroupID,
+		}
+		for _, v := range grants {
+			if v.GroupID == change.GroupID {
+				grant.RoleMapping = v.RoleMapping
+			}
+		}
+		grant.AccessLevel = accessLevel
+
+		if setErr := tx.SetGroupPermission(ctx, &grant); setErr != nil {
+			return nil, setErr
+		}
+	}
+
+	materializedPerms, err := tx.MaterializeGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if auditErr := auditGroupChange(ctx, tx, "BatchNamespaceAccesses", model.ResourceNamespace, ns.ID, change.GroupID, before); auditErr != nil {
+		return nil, auditErr
+	}
+
+	return append(changedPerms, materializedPerms...), nil
+}
+
+func (s *Server) BatchNamespaceAccesses(ctx context.Context, req model.BatchAccessRequest) (kubeClientModel.ImportResponse, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"changes":     len(req.Changes),
+		"best_effort": req.BestEffort,
+	}).Infof("batch namespace accesses")
+
+	resp := kubeClientModel.ImportResponse{
+		Imported: []kubeClientModel.ImportResult{},
+		Failed:   []kubeClientModel.ImportResult{},
+	}
+
+	if len(req.Changes) == 0 {
+		return resp, errors.ErrRequestValidationFailed().AddDetailF("no changes in batch")
+	}
+
+	b := newAccessBatch(s.clients.User)
+
+	if req.BestEffort {
+		var changedPerms []model.Permission
+		for _, change := range req.Changes {
+			var perms []model.Permission
+			err := s.db.Transactional(ctx, func(tx database.DB) (applyErr error) {
+				perms, applyErr = s.applyAccessChange(ctx, tx, b, change)
+				return
+			})
+			if err != nil {
+				s.log.WithError(err).Debugf("batch change of %s to %s failed", batchChangeName(change), change.Namespace)
+				resp.ImportFailed(batchChangeName(change), change.Namespace, err.Error())
+				continue
+			}
+			changedPerms = append(changedPerms, perms...)
+			resp.ImportSuccessful(batchChangeName(change), change.Namespace)
+		}
+
+		// changes are already committed, failed update will be repaired by next change of user accesses
+		if updErr := updatePermissionsUsers(ctx, s.clients.Auth, s.db, changedPerms); updErr != nil {
+			s.log.WithError(updErr).Warn("update user accesses after batch failed")
+		}
+
+		return resp, nil
+	}
+
+	failed := -1
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		var changedPerms []model.Permission
+		for i, change := range req.Changes {
+			perms, applyErr := s.applyAccessChange(ctx, tx, b, change)
+			if applyErr != nil {
+				failed = i
+				return applyErr
+			}
+			changedPerms = append(changedPerms, perms...)
+		}
+
+		return updatePermissionsUsers(ctx, s.clients.Auth, tx, changedPerms)
+	})
+
+	for i, change := range req.Changes {
+		switch {
+		case err == nil:
+			resp.ImportSuccessful(batchChangeName(change), change.Namespace)
+		case i == failed || failed < 0:
+			resp.ImportFailed(batchChangeName(change), change.Namespace, err.Error())
+		default:
+			resp.ImportFailed(batchChangeName(change), change.Namespace, batchRolledBackMessage)
+		}
+	}
+
+	return resp, nil
+}
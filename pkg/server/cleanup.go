@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of built-in cleanup hooks
+const (
+	cleanupHookSolutions = "solutions"
+	cleanupHookResources = "resources"
+	cleanupHookVolumes   = "volumes"
+	cleanupHookBilling   = "billing"
+	cleanupHookKube      = "kube"
+)
+
+// CleanupHook is a step of deleted namespaces cleanup deleting their data in other service.
+// Hook must be idempotent because it is retried until done.
+type CleanupHook struct {
+	Name string
+
+	// Names of hooks which must be done before this one
+	DependsOn []string
+
+	Run func(ctx context.Context, target model.CleanupTarget) error
+}
+
+// HTTPCleanupHookConfig describes cleanup hook posting cleanup target in JSON to URL
+type HTTPCleanupHookConfig struct {
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// defaultCleanupHooks returns hooks deleting namespaces data in services used by permissions.
+func (s *Server) defaultCleanupHooks() []CleanupHook {
+	return []CleanupHook{
+		{
+			Name: cleanupHookSolutions,
+			Run: func(ctx context.Context, target model.CleanupTarget) error {
+				if target.NamespaceID == "" {
+					return s.clients.Solutions.DeleteUserSolutions(ctx)
+				}
+				return s.clients.Solutions.DeleteNamespaceSolutions(ctx, target.KubeName)
+			},
+		},
+		{
+			Name:      cleanupHookResources,
+			DependsOn: []string{cleanupHookSolutions},
+			Run: func(ctx context.Context, target model.CleanupTarget) error {
+				if target.NamespaceID == "" {
+					return s.clients.Resource.DeleteAllUserNamespaces(ctx)
+				}
+				return s.clients.Resource.DeleteNamespaceResources(ctx, target.KubeName)
+			},
+		},
+		{
+			Name:      cleanupHookVolumes,
+			DependsOn: []string{cleanupHookResources},
+			Run: func(ctx context.Context, target model.CleanupTarget) error {
+				if target.NamespaceID == "" {
+					return s.clients.Volume.DeleteAllUserVolumes(ctx)
+				}
+				return s.clients.Volume.DeleteNamespaceVolumes(ctx, target.KubeName)
+			},
+		},
+		{
+			Name: cleanupHookBilling,
+			Run: func(ctx context.Context, target model.CleanupTarget) error {
+				if len(target.ResourceIDs) == 0 {
+					return nil
+				}
+				return s.clients.Billing.MassiveUnsubscribe(ctx, target.ResourceIDs)
+			},
+		},
+		{
+			Name:      cleanupHookKube,
+			DependsOn: []string{cleanupHookResources, cleanupHookVolumes},
+			Run: func(ctx context.Context, target model.CleanupTarget) error {
+				if target.NamespaceID == "" {
+					return s.clients.Kube.DeleteUserNamespaces(ctx, target.UserID)
+				}
+				return s.clients.Kube.DeleteNamespace(ctx, kubeClientModel.Namespace{ID: target.KubeName})
+			},
+		},
+	}
+}
+
+func (s *Server) cleanupHook(name string) *CleanupHook {
+	for i := range s.cleanupHooks {
+		if s.cleanupHooks[i].Name == name {
+			return &s.cleanupHooks[i]
+		}
+	}
+	return nil
+}
+
+// RegisterCleanupHook adds step to cleanup of deleted namespaces.
+// Hooks which new hook depends on must be registered before, so dependencies never form a cycle.
+// Must be called before server starts handling requests.
+func (s *Server) RegisterCleanupHook(hook CleanupHook) error {
+	if hook.Name == "" || hook.Run == nil {
+		return fmt.Errorf("cleanup hook must have name and action")
+	}
+	if s.cleanupHook(hook.Name) != nil {
+		return fmt.Errorf("cleanup hook %s already registered", hook.Name)
+	}
+	for _, dep := range hook.DependsOn {
+		if s.cleanupHook(dep) == nil {
+			return fmt.Errorf("cleanup hook %s depends on not registered hook %s", hook.Name, dep)
+		}
+	}
+
+	s.cleanupHooks = append(s.cleanupHooks, hook)
+	return nil
+}
+
+// RegisterHTTPCleanupHook adds cleanup step posting cleanup target to URL.
+func (s *Server) RegisterHTTPCleanupHook(cfg HTTPCleanupHookConfig) error {
+	if cfg.URL == "" {
+		return fmt.Errorf("cleanup hook %s has no url", cfg.Name)
+	}
+
+	return s.RegisterCleanupHook(CleanupHook{
+		Name:      cfg.Name,
+		DependsOn: cfg.DependsOn,
+		Run: func(ctx context.Context, target model.CleanupTarget) error {
+			return s.clients.CleanupHook.Call(ctx, cfg.URL, target)
+		},
+	})
+}
+
+// newCleanupOperation returns cleanup operation with steps of all registered hooks.
+// Operation must be created in transaction deleting namespaces, so cleanup is resumed by recovery job after crash.
+func (s *Server) newCleanupOperation(ctx context.Context, opType model.OperationType, kind model.ResourceType, resourceID string, target model.CleanupTarget) *model.Operation {
+	steps := make([]string, len(s.cleanupHooks))
+	for i, hook := range s.cleanupHooks {
+		steps[i] = hook.Name
+	}
+	return newOperation(ctx, opType, kind, resourceID, model.OperationData{Cleanup: &target}, steps...)
+}
+
+// cleanupContext returns context of cleanup acting on behalf of deleted namespaces owner.
+func cleanupContext(ctx context.Context, op *model.Operation) context.Context {
+	return context.WithValue(serviceContext(ctx, op.Data.Cleanup.UserID), httputil.RequestIDContextKey, op.RequestID)
+}
+
+// startCleanup runs cleanup created by request in background and returns its state at start.
+// Cleanup is not bound to request, so it is not interrupted if client disconnects.
+// Cleanup of dry run request runs in request to record its side effects.
+func (s *Server) startCleanup(ctx context.Context, op *model.Operation) model.Operation {
+	if dryrun.FromContext(ctx) != nil {
+		if err := s.runCleanup(ctx, op); err != nil {
+			s.log.WithError(err).Warnf("dry run cleanup not finished")
+		}
+		return *op
+	}
+
+	ret := *op
+	ret.Steps = append([]model.OperationStep(nil), op.Steps...)
+
+	go func() {
+		// unfinished cleanup is resumed by recovery job
+		if err := s.runCleanup(cleanupContext(context.Background(), op), op); err != nil {
+			s.log.WithError(err).Warnf("cleanup not finished")
+		}
+	}()
+
+	return ret
+}
+
+// runCleanupHook calls hook until it succeeds or attempts are exhausted.
+// Not found error means that data is already deleted.
+func (s *Server) runCleanupHook(ctx context.Context, hook *CleanupHook, target model.CleanupTarget, beforeAttempt func()) error {
+	backoff := s.cfg.CleanupRetryBackoff
+	for attempt := 1; ; attempt++ {
+		beforeAttempt()
+
+		err := hook.Run(ctx, target)
+		if err == nil || isNotFound(err) {
+			return nil
+		}
+
+		s.log.WithError(err).WithFields(logrus.Fields{
+			"hook":    hook.Name,
+			"attempt": attempt,
+		}).Warnf("cleanup hook failed")
+
+		if attempt >= s.cfg.CleanupStepAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// runCleanup runs steps of cleanup operation which are not done yet.
+// Steps run in parallel, each one starts when steps it depends on are done. Steps depending on failed one stay pending.
+// Operation is completed when all steps are done, otherwise it is resumed by recovery job
+// until runs limit is reached and operation is failed.
+func (s *Server) runCleanup(ctx context.Context, op *model.Operation) error {
+	s.log.WithFields(logrus.Fields{
+		"operation_id": op.ID,
+		"type":         op.Type,
+		"resource_id":  op.ResourceID,
+	}).Infof("run cleanup")
+
+	// steps and operation state are saved by one step at a time
+	var mu sync.Mutex
+	save := func() {
+		if err := saveOperation(ctx, s.db, op, model.OperationRunning); err != nil {
+			s.log.WithError(err).WithField("operation_id", op.ID).Warnf("cleanup state not saved")
+		}
+	}
+
+	finished := make(map[string]chan struct{}, len(op.Steps))
+	for _, step := range op.Steps {
+		finished[step.Name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i := range op.Steps {
+		wg.Add(1)
+		go func(step *model.OperationStep) {
+			defer wg.Done()
+			defer close(finished[step.Name])
+
+			mu.Lock()
+			status := step.Status
+			mu.Unlock()
+			if status == model.OperationStepDone {
+				return
+			}
+
+			hook := s.cleanupHook(step.Name)
+			if hook == nil {
+				mu.Lock()
+				step.Status = model.OperationStepFailed
+				step.Error = fmt.Sprintf("cleanup hook %s not registered", step.Name)
+				save()
+				mu.Unlock()
+				return
+			}
+
+			for _, dep := range hook.DependsOn {
+				if ch, ok := finished[dep]; ok {
+					<-ch
+				}
+			}
+
+			mu.Lock()
+			for _, dep := range hook.DependsOn {
+				if depStep := op.Step(dep); depStep != nil && depStep.Status != model.OperationStepDone {
+					mu.Unlock()
+					return
+				}
+			}
+			mu.Unlock()
+
+			err := s.runCleanupHook(ctx, hook, *op.Data.Cleanup, func() {
+				mu.Lock()
+				step.Status = model.OperationStepStarted
+				step.Attempts++
+				save()
+				mu.Unlock()
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				step.Status = model.OperationStepFailed
+				step.Error = err.Error()
+			} else {
+				step.Status = model.OperationStepDone
+				step.Error = ""
+			}
+			save()
+		}(&op.Steps[i])
+	}
+	wg.Wait()
+
+	var notDone []string
+	for _, step := range op.Steps {
+		if step.Status != model.OperationStepDone {
+			notDone = append(notDone, step.Name)
+		}
+	}
+
+	if len(notDone) > 0 {
+		op.Attempts++
+		op.LastError = fmt.Sprintf("steps not done: %s", strings.Join(notDone, ", "))
+		if op.Attempts >= s.cfg.CleanupMaxRuns {
+			s.log.WithField("operation_id", op.ID).Errorf("cleanup failed, runs limit reached")
+			op.Status = model.OperationFailed
+		}
+		save()
+		return fmt.Errorf("cleanup %s not finished, %s", op.ID, op.LastError)
+	}
+
+	op.Status = model.OperationCompleted
+	return saveOperation(ctx, s.db, op, model.OperationRunning)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+)
+
+func TestRunCleanup(t *testing.T) {
+	// "b" depends on "a", "c" depends on "b", "d" is independent
+	dependencies := map[string][]string{"b": {"a"}, "c": {"b"}}
+
+	for _, tc := range []struct {
+		name     string
+		steps    map[string]model.OperationStepStatus
+		attempts int
+		errors   map[string]error
+		calls    []string
+		expected map[string]model.OperationStepStatus
+		status   model.OperationStatus
+		err      bool
+	}{
+		{
+			name:     "all done",
+			calls:    []string{"a", "b", "c", "d"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepDone, "b": model.OperationStepDone, "c": model.OperationStepDone, "d": model.OperationStepDone},
+			status:   model.OperationCompleted,
+		},
+		{
+			name:     "dependents of failed step stay pending",
+			errors:   map[string]error{"a": fmt.Errorf("service unavailable")},
+			calls:    []string{"a", "d"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepFailed, "b": model.OperationStepPending, "c": model.OperationStepPending, "d": model.OperationStepDone},
+			status:   model.OperationRunning,
+			err:      true,
+		},
+		{
+			name:     "failed step in the middle",
+			errors:   map[string]error{"b": fmt.Errorf("service unavailable")},
+			calls:    []string{"a", "b", "d"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepDone, "b": model.OperationStepFailed, "c": model.OperationStepPending, "d": model.OperationStepDone},
+			status:   model.OperationRunning,
+			err:      true,
+		},
+		{
+			name:     "done steps not repeated",
+			steps:    map[string]model.OperationStepStatus{"a": model.OperationStepDone, "d": model.OperationStepDone},
+			calls:    []string{"b", "c"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepDone, "b": model.OperationStepDone, "c": model.OperationStepDone, "d": model.OperationStepDone},
+			status:   model.OperationCompleted,
+		},
+		{
+			name:     "not found is done",
+			errors:   map[string]error{"a": errors.ErrResourceNotExists()},
+			calls:    []string{"a", "b", "c", "d"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepDone, "b": model.OperationStepDone, "c": model.OperationStepDone, "d": model.OperationStepDone},
+			status:   model.OperationCompleted,
+		},
+		{
+			name:     "runs limit reached",
+			attempts: 2,
+			errors:   map[string]error{"d": fmt.Errorf("service unavailable")},
+			calls:    []string{"a", "b", "c", "d"},
+			expected: map[string]model.OperationStepStatus{"a": model.OperationStepDone, "b": model.OperationStepDone, "c": model.OperationStepDone, "d": model.OperationStepFailed},
+			status:   model.OperationFailed,
+			err:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := &operationTestCalls{errors: tc.errors}
+			s := newOperationTestServer(operationTestDB{}, calls)
+			s.cfg.CleanupStepAttempts = 1
+			s.cfg.CleanupMaxRuns = 3
+
+			op := &model.Operation{
+				ID:       "op",
+				Status:   model.OperationRunning,
+				Attempts: tc.attempts,
+				Data:     model.OperationData{Cleanup: &model.CleanupTarget{UserID: "user"}},
+			}
+			for _, name := range []string{"a", "b", "c", "d"} {
+				name := name
+				s.cleanupHooks = append(s.cleanupHooks, CleanupHook{
+					Name:      name,
+					DependsOn: dependencies[name],
+					Run: func(ctx context.Context, target model.CleanupTarget) error {
+						return calls.call(name)
+					},
+				})
+
+				status := tc.steps[name]
+				if status == "" {
+					status = model.OperationStepPending
+				}
+				op.Steps = append(op.Steps, model.OperationStep{Name: name, Status: status})
+			}
+
+			err := s.runCleanup(context.Background(), op)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+
+			order := make(map[string]int, len(calls.calls))
+			for i, name := range calls.calls {
+				order[name] = i
+			}
+			for name, deps := range dependencies {
+				for _, dep := range deps {
+					if i, called := order[name]; called && order[dep] > i {
+						t.Errorf("step %s called before its dependency %s", name, dep)
+					}
+				}
+			}
+
+			sort.Strings(calls.calls)
+			if !reflect.DeepEqual(calls.calls, tc.calls) {
+				t.Errorf("expected calls %v, got %v", tc.calls, calls.calls)
+			}
+			for _, step := range op.Steps {
+				if step.Status != tc.expected[step.Name] {
+					t.Errorf("expected step %s status %s, got %s", step.Name, tc.expected[step.Name], step.Status)
+				}
+			}
+			if op.Status != tc.status {
+				t.Errorf("expected status %s, got %s", tc.status, op.Status)
+			}
+		})
+	}
+}
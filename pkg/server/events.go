@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+// publishEvent writes event to outbox and enqueues its deliveries to webhook subscriptions.
+// Must be called with transaction making the change so event is published only if change is committed.
+func publishEvent(ctx context.Context, tx database.DB, eventType model.EventType, kind model.ResourceType, resourceID string, payload model.EventPayload) error {
+	event := model.Event{
+		Type:         eventType,
+		ResourceType: kind,
+		ResourceID:   resourceID,
+		Payload:      payload,
+	}
+	event.RequestID, _ = ctx.Value(httputil.RequestIDContextKey).(string)
+
+	if err := tx.AddEvent(ctx, &event); err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(ctx, tx, event)
+}
+
+// namespaceEventPayload returns payload of namespace created or deleted event.
+func namespaceEventPayload(ns model.Namespace) model.EventPayload {
+	payload := model.EventPayload(namespaceState(ns))
+	payload["kube_name"] = ns.KubeName
+	return payload
+}
+
+// publishAccessChanged publishes event about change of user or group access to resource.
+func publishAccessChanged(ctx context.Context, tx database.DB, kind model.ResourceType, resourceID, targetField, target string, before, after model.AuditState) error {
+	payload := model.EventPayload{targetField: target}
+	if before != nil {
+		payload["before"] = before
+	}
+	if after != nil {
+		payload["after"] = after
+	}
+	return publishEvent(ctx, tx, model.EventAccessChanged, kind, resourceID, payload)
+}
+
+// eventsClaimTimeout is a period while claimed events are not taken by other service instances.
+// Events not marked within it may be sent again.
+const eventsClaimTimeout = 10 * time.Minute
+
+// DispatchEvents delivers pending events from outbox to all sinks.
+// Event of resource is not sent until all previous events of the same resource are delivered or failed.
+func (s *Server) DispatchEvents(ctx context.Context) error {
+	if s.cfg.EventsRetention > 0 {
+		deleted, err := s.db.DeleteDeliveredEvents(ctx, time.Now().Add(-s.cfg.EventsRetention))
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			s.log.WithField("deleted", deleted).Infof("delivered events deleted")
+		}
+	}
+
+	for {
+		// events are claimed under lock, so other instance does not claim next events of the same resources before commit
+		var events []model.Event
+		err := s.db.Transactional(ctx, func(tx database.DB) error {
+			locked, lockErr := tx.TryLockEventsDispatch(ctx)
+			if lockErr != nil || !locked {
+				return lockErr
+			}
+
+			var claimErr error
+			events, claimErr = tx.ClaimPendingEvents(ctx, eventsClaimTimeout, s.cfg.EventsBatchSize)
+			return claimErr
+		})
+		if err != nil {
+			return err
+		}
+
+		// sinks are called outside of transaction, results are saved by short one
+		s.sendEvents(ctx, events)
+		err = s.db.Transactional(ctx, func(tx database.DB) error {
+			for i := range events {
+				if updErr := tx.UpdateEvent(ctx, &events[i]); updErr != nil {
+					return updErr
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(events) < s.cfg.EventsBatchSize {
+			return nil
+		}
+	}
+}
+
+// sendEvents sends claimed events in order and updates their state.
+// Failed event is postponed with growing delay and failed after attempts limit is reached.
+// Next events of its resource in batch are released to be sent after it.
+func (s *Server) sendEvents(ctx context.Context, events []model.Event) {
+	blocked := make(map[string]struct{})
+	for i := range events {
+		event := &events[i]
+		now := time.Now()
+		if _, isBlocked := blocked[event.ResourceID]; isBlocked {
+			event.NextAttemptTime = now
+			continue
+		}
+
+		event.Attempts++
+		sendErr := s.sendEvent(ctx, *event)
+		if sendErr == nil {
+			event.DeliveredAt = &now
+			event.LastError = ""
+			continue
+		}
+
+		log := s.log.WithFields(logrus.Fields{
+			"id":          event.ID,
+			"type":        event.Type,
+			"resource_id": event.ResourceID,
+			"attempts":    event.Attempts,
+		}).WithError(sendErr)
+
+		event.LastError = sendErr.Error()
+		if event.Attempts >= s.cfg.EventsMaxAttempts {
+			log.Errorf("event delivery failed, attempts limit reached")
+			event.FailedAt = &now
+			continue
+		}
+
+		log.Warnf("event delivery failed")
+		event.NextAttemptTime = now.Add(retryDelay(s.cfg.EventsRetryBackoff, event.Attempts))
+		blocked[event.ResourceID] = struct{}{}
+	}
+}
+
+// sendEvent sends event to all sinks. If any sink failed, event will be sent again to all of them.
+func (s *Server) sendEvent(ctx context.Context, event model.Event) error {
+	for _, sink := range s.clients.EventSinks {
+		if err := sink.Send(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
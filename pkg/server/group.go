@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+type GroupActions interface {
+	HandleGroupChanged(ctx context.Context, req model.GroupChangedHookRequest) error
+}
+
+// groupAccessLevel returns maximal access of group members from request.
+func groupAccessLevel(req model.ProjectAddGroupRequest) (kubeClientModel.AccessLevel, error) {
+	if !req.RoleMapping.IsValid() {
+		return "", errors.ErrRequestValidationFailed().AddDetailF("invalid group role mapping")
+	}
+
+	if req.AccessLevel == nil {
+		return kubeClientModel.Write, nil
+	}
+
+	switch *req.AccessLevel {
+	case kubeClientModel.Write, kubeClientModel.ReadDelete, kubeClientModel.Read:
+		return *req.AccessLevel, nil
+	default:
+		return "", errors.ErrRequestValidationFailed().AddDetailF("access level %s can not be granted to group", *req.AccessLevel)
+	}
+}
+
+// syncGroupMembers saves current group membership from user manager.
+// Access levels of members are set by service level mapping of their roles.
+func syncGroupMembers(ctx context.Context, client clients.UserManagerClient, tx database.DB, groupID string, mapping model.GroupRoleMapping) error {
+	group, err := client.Group(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	return setGroupMembers(ctx, tx, groupID, group, mapping)
+}
+
+// setGroupMembers saves membership of group received from user manager.
+func setGroupMembers(ctx context.Context, tx database.DB, groupID string, group *kubeClientModel.UserGroup, mapping model.GroupRoleMapping) error {
+	members := make([]model.GroupMember, 0)
+	if group.UserGroupMembers != nil {
+		for _, v := range group.Members {
+			// user manager sends role of member in access field
+			role := kubeClientModel.UserGroupAccess(v.Access)
+			if role == "" {
+				role = kubeClientModel.NoAccess
+			}
+			members = append(members, model.GroupMember{
+				GroupID:     groupID,
+				UserID:      v.ID,
+				Role:        role,
+				AccessLevel: UserGroupAccessToDBAccess(role, mapping),
+			})
+		}
+	}
+
+	return tx.SetGroupMembers(ctx, groupID, members)
+}
+
+// updatePermissionsUsers updates accesses of users owning permissions.
+func updatePermissionsUsers(ctx context.Context, auth clients.AuthClient, tx database.DB, perms []model.Permission) error {
+	updatedUsers := make(map[string]struct{})
+	for _, perm := range perms {
+		if _, updated := updatedUsers[perm.UserID]; updated {
+			continue
+		}
+		updatedUsers[perm.UserID] = struct{}{}
+
+		if updErr := updateUserAccesses(ctx, auth, tx, perm.UserID); updErr != nil {
+			return updErr
+		}
+	}
+
+	return nil
+}
+
+// applyGroupPermissions rebuilds permissions of group members to resource and updates accesses of affected users.
+// Must be called after group grants, group membership or direct permissions of resource were changed.
+// Users of changedPerms will get updated accesses too.
+func applyGroupPermissions(ctx context.Context, auth clients.AuthClient, tx database.DB, kind model.ResourceType, resourceID string, changedPerms ...model.Permission) error {
+	materializedPerms, err := tx.MaterializeGroupPermissions(ctx, kind, resourceID)
+	if err != nil {
+		return err
+	}
+
+	return updatePermissionsUsers(ctx, auth, tx, append(changedPerms, materializedPerms...))
+}
+
+// groupsByPermissions fetches information about groups granted to resources.
+func groupsByPermissions(ctx context.Context, client clients.UserManagerClient, perms []model.GroupPermission) ([]kubeClientModel.UserGroup, error) {
+	groupIDsSet := make(map[string]bool)
+	var groupIDs []string
+	for _, v := range perms {
+		if !groupIDsSet[v.GroupID] {
+			groupIDsSet[v.GroupID] = true
+			groupIDs = append(groupIDs, v.GroupID)
+		}
+	}
+
+	if len(groupIDs) == 0 {
+		return make([]kubeClientModel.UserGroup, 0), nil
+	}
+
+	groups, err := client.GroupFullIDList(ctx, groupIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups.Groups, nil
+}
+
+// syncGroup fetches group membership from user manager and rebuilds permissions of members to all resources granted to group.
+// Action is written to audit log if permissions were changed.
+func (s *Server) syncGroup(ctx context.Context, action, groupID string) (changedPerms []model.Permission, err error) {
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		changedPerms = nil
+
+		if syncErr := syncGroupMembers(ctx, s.clients.User, tx, groupID, s.cfg.GroupRoleMapping); syncErr != nil {
+			return syncErr
+		}
+
+		grants, getErr := tx.GroupPermissions(ctx, groupID)
+		if getErr != nil {
+			return getErr
+		}
+
+		for _, grant := range grants {
+			perms, materializeErr := tx.MaterializeGroupPermissions(ctx, grant.ResourceType, grant.ResourceID)
+			if materializeErr != nil {
+				return materializeErr
+			}
+			changedPerms = append(changedPerms, perms...)
+		}
+
+		if len(changedPerms) > 0 {
+			if auditErr := auditChange(ctx, tx, model.AuditRecord{
+				Action:       action,
+				ResourceType: model.AuditResourceGroup,
+				ResourceID:   groupID,
+				After:        model.AuditState{"changed_permissions": len(changedPerms)},
+			}); auditErr != nil {
+				return auditErr
+			}
+		}
+
+		return updatePermissionsUsers(ctx, s.clients.Auth, tx, changedPerms)
+	})
+
+	return
+}
+
+func (s *Server) HandleGroupChanged(ctx context.Context, req model.GroupChangedHookRequest) error {
+	s.log.WithField("group_id", req.GroupID).Infof("handle group changed")
+
+	changedPerms, err := s.syncGroup(ctx, "HandleGroupChanged", req.GroupID)
+	if err != nil {
+		return err
+	}
+
+	s.log.WithField("group_id", req.GroupID).Debugf("%d permissions changed", len(changedPerms))
+
+	return nil
+}
+
+// ReconcileGroups compares membership of granted groups with user manager and repairs permissions of members.
+func (s *Server) ReconcileGroups(ctx context.Context) error {
+	groupIDs, err := s.db.GrantedGroupIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, groupID := range groupIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		changedPerms, syncErr := s.syncGroup(ctx, "ReconcileGroups", groupID)
+		if syncErr != nil {
+			s.log.WithError(syncErr).WithField("group_id", groupID).Warnf("group reconciliation failed")
+			continue
+		}
+
+		if len(changedPerms) > 0 {
+			s.log.WithFields(logrus.Fields{
+				"group_id": groupID,
+				"changed":  len(changedPerms),
+			}).Infof("repaired group permissions drift")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type InvitationActions interface {
+	GetNamespaceInvitations(ctx context.Context, id string) ([]model.Invitation, error)
+	DeleteNamespaceInvitation(ctx context.Context, id, email string) error
+	HandleUserCreated(ctx context.Context, req model.UserCreatedHookRequest) error
+}
+
+// acceptUserInvitations converts invitations sent to user email to permissions.
+func (s *Server) acceptUserInvitations(ctx context.Context, userID string) error {
+	user, err := s.clients.User.UserInfoByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		accepted, acceptErr := tx.AcceptInvitations(ctx, userID, user.Login)
+		if acceptErr != nil {
+			return acceptErr
+		}
+
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		s.log.WithFields(logrus.Fields{
+			"user_id": userID,
+			"login":   user.Login,
+		}).Infof("accepted %d invitations", len(accepted))
+
+		for _, v := range accepted {
+			if auditErr := auditAccessChange(ctx, tx, "AcceptInvitations", v.ResourceType, v.ResourceID, userID,
+				invitationState(v.AccessLevel, v.Role, v.ExpiresAt)); auditErr != nil {
+				return auditErr
+			}
+		}
+
+		return updateUserAccesses(ctx, s.clients.Auth, tx, userID)
+	})
+
+	return err
+}
+
+func (s *Server) GetNamespaceInvitations(ctx context.Context, id string) ([]model.Invitation, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get namespace invitations")
+
+	ns, err := s.db.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if chkErr := NamespaceActionCheck(ctx, s.db, ns, model.ActionAccessManage); chkErr != nil {
+		return nil, chkErr
+	}
+
+	return s.db.NamespaceInvitations(ctx, ns.Namespace)
+}
+
+func (s *Server) DeleteNamespaceInvitation(ctx context.Context, id, email string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+		"email":   email,
+	}).Infof("delete namespace invitation")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		if delErr := tx.DeleteNamespaceInvitation(ctx, ns.Namespace, email); delErr != nil {
+			return delErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "DeleteNamespaceInvitation",
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			Target:       email,
+			Before:       model.AuditState{"invitation": true},
+		})
+	})
+
+	return err
+}
+
+func (s *Server) HandleUserCreated(ctx context.Context, req model.UserCreatedHookRequest) error {
+	s.log.WithField("user_id", req.UserID).Infof("handle user created")
+
+	return s.acceptUserInvitations(ctx, req.UserID)
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/containerum/utils/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// serviceContext returns context with headers of requests made to other services by jobs.
+// Service clients take request headers from context and fail without them.
+// User ID header is set if job acts on behalf of user.
+func serviceContext(ctx context.Context, userID string) context.Context {
+	header := make(http.Header)
+	header.Set(httputil.UserRoleXHeader, "admin")
+	if userID != "" {
+		header.Set(httputil.UserIDXHeader, userID)
+	}
+
+	gctx := &gin.Context{Request: (&http.Request{Header: header}).WithContext(ctx)}
+	httputil.SaveHeaders(gctx)
+	return gctx.Request.Context()
+}
+
+// runJob calls job every interval until ctx is done. Job errors are logged.
+func (s *Server) runJob(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	log := s.log.WithField("job", name)
+	if interval <= 0 {
+		log.Warnf("job disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	jobCtx := serviceContext(ctx, "")
+	for {
+		if err := job(jobCtx); err != nil {
+			log.WithError(err).Errorf("job failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// StartJobs starts server background jobs. Jobs will be stopped when ctx is done.
+func (s *Server) StartJobs(ctx context.Context) {
+	go s.runJob(ctx, "purge_tombstones", s.cfg.TombstonesPurgeInterval, s.PurgeTombstones)
+	go s.runJob(ctx, "revoke_expired_accesses", s.cfg.ExpiredAccessesSweepInterval, s.RevokeExpiredAccesses)
+	go s.runJob(ctx, "reconcile_groups", s.cfg.GroupsReconcileInterval, s.ReconcileGroups)
+	go s.runJob(ctx, "dispatch_events", s.cfg.EventsDispatchInterval, s.DispatchEvents)
+	go s.runJob(ctx, "deliver_webhooks", s.cfg.WebhooksDeliveryInterval, s.DeliverWebhooks)
+	go s.runJob(ctx, "recover_operations", s.cfg.OperationsRecoveryInterval, s.RecoverOperations)
+}
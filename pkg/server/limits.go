@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type LimitsActions interface {
+	GetUserLimits(ctx context.Context) (model.UserLimitsResponse, error)
+	SetUserLimits(ctx context.Context, req model.SetUserLimitsRequest) error
+}
+
+// userLimits returns effective limits of user and current consumption of resources.
+func userLimits(ctx context.Context, db database.DB, defaults model.ResourcesLimits, userID string) (model.UserLimitsResponse, error) {
+	limits, err := db.UserLimits(ctx, userID)
+	if err != nil {
+		return model.UserLimitsResponse{}, err
+	}
+
+	usage, err := db.UserResourcesUsage(ctx, userID)
+	if err != nil {
+		return model.UserLimitsResponse{}, err
+	}
+
+	return model.UserLimitsResponse{
+		Limits: defaults.Override(limits),
+		Usage:  usage,
+	}, nil
+}
+
+// checkUserLimits checks if user namespaces can be changed by delta without exceeding limits of user.
+// Only increased resources are checked so user exceeding lowered limits still can shrink namespaces.
+// Must be called inside transaction, changes of user namespaces are serialized until transaction end.
+func checkUserLimits(ctx context.Context, tx database.DB, defaults model.ResourcesLimits, userID string, delta model.ResourcesLimits) error {
+	resp, err := userLimits(ctx, tx, defaults, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range []struct {
+		name        string
+		limit, used int
+		requested   int
+	}{
+		{"namespaces", resp.Limits.Namespaces, resp.Usage.Namespaces, delta.Namespaces},
+		{"CPU", resp.Limits.CPU, resp.Usage.CPU, delta.CPU},
+		{"RAM", resp.Limits.RAM, resp.Usage.RAM, delta.RAM},
+		{"external services", resp.Limits.MaxExtServices, resp.Usage.MaxExtServices, delta.MaxExtServices},
+		{"internal services", resp.Limits.MaxIntServices, resp.Usage.MaxIntServices, delta.MaxIntServices},
+	} {
+		if v.limit > 0 && v.requested > 0 && v.used+v.requested > v.limit {
+			return errors.ErrQuotaExceeded().AddDetailF("user limit of %s exceeded: used %d, requested %d, limit %d", v.name, v.used, v.requested, v.limit)
+		}
+	}
+
+	return nil
+}
+
+// limitsState returns audit state for effective limits of user.
+func limitsState(limits model.ResourcesLimits) model.AuditState {
+	return model.AuditState{
+		"namespaces":       limits.Namespaces,
+		"cpu":              limits.CPU,
+		"ram":              limits.RAM,
+		"max_ext_services": limits.MaxExtServices,
+		"max_int_services": limits.MaxIntServices,
+	}
+}
+
+// namespaceResources returns resources of namespace counted in user limits.
+func namespaceResources(ns model.Namespace) model.ResourcesLimits {
+	return model.ResourcesLimits{
+		Namespaces:     1,
+		CPU:            ns.CPU,
+		RAM:            ns.RAM,
+		MaxExtServices: ns.MaxExtServices,
+		MaxIntServices: ns.MaxIntServices,
+	}
+}
+
+// resizeResources returns change of resources counted in user limits after namespace resize.
+func resizeResources(oldNS, newNS model.Namespace) model.ResourcesLimits {
+	return model.ResourcesLimits{
+		CPU:            newNS.CPU - oldNS.CPU,
+		RAM:            newNS.RAM - oldNS.RAM,
+		MaxExtServices: newNS.MaxExtServices - oldNS.MaxExtServices,
+		MaxIntServices: newNS.MaxIntServices - oldNS.MaxIntServices,
+	}
+}
+
+func (s *Server) GetUserLimits(ctx context.Context) (model.UserLimitsResponse, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithField("user_id", userID).Infof("get user limits")
+
+	var ret model.UserLimitsResponse
+	err := s.db.Transactional(ctx, func(tx database.DB) (err error) {
+		ret, err = userLimits(ctx, tx, s.cfg.UserLimits, userID)
+		return
+	})
+
+	return ret, err
+}
+
+func (s *Server) SetUserLimits(ctx context.Context, req model.SetUserLimitsRequest) error {
+	s.log.WithFields(logrus.Fields{
+		"username": req.UserName,
+	}).Infof("set user limits %+v", req)
+
+	for _, v := range []*int{req.Namespaces, req.CPU, req.RAM, req.MaxExtServices, req.MaxIntServices} {
+		if v != nil && *v < 0 {
+			return errors.ErrRequestValidationFailed().AddDetailF("limit can`t be negative")
+		}
+	}
+
+	user, err := s.clients.User.UserInfoByLogin(ctx, req.UserName)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		oldLimits, getErr := tx.UserLimits(ctx, user.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		newLimits := model.UserLimits{
+			UserID:         user.ID,
+			Namespaces:     req.Namespaces,
+			CPU:            req.CPU,
+			RAM:            req.RAM,
+			MaxExtServices: req.MaxExtServices,
+			MaxIntServices: req.MaxIntServices,
+		}
+		if setErr := tx.SetUserLimits(ctx, &newLimits); setErr != nil {
+			return setErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "SetUserLimits",
+			ResourceType: model.AuditResourceUser,
+			ResourceID:   user.ID,
+			Before:       limitsState(s.cfg.UserLimits.Override(oldLimits)),
+			After:        limitsState(s.cfg.UserLimits.Override(newLimits)),
+		})
+	})
+
+	return err
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/cherry"
+)
+
+// limitsTestDB returns fixed limits and usage of user, other methods are not implemented.
+type limitsTestDB struct {
+	database.DB
+
+	limits model.UserLimits
+	usage  model.ResourcesLimits
+}
+
+func (db *limitsTestDB) UserLimits(ctx context.Context, userID string) (model.UserLimits, error) {
+	return db.limits, nil
+}
+
+func (db *limitsTestDB) UserResourcesUsage(ctx context.Context, userID string) (model.ResourcesLimits, error) {
+	return db.usage, nil
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCheckUserLimits(t *testing.T) {
+	defaults := model.ResourcesLimits{Namespaces: 5, CPU: 4000, RAM: 8192}
+
+	for _, tc := range []struct {
+		name     string
+		limits   model.UserLimits
+		usage    model.ResourcesLimits
+		delta    model.ResourcesLimits
+		exceeded bool
+	}{
+		{
+			name:  "within defaults",
+			usage: model.ResourcesLimits{Namespaces: 4, CPU: 3000, RAM: 4096},
+			delta: model.ResourcesLimits{Namespaces: 1, CPU: 1000, RAM: 4096},
+		},
+		{
+			name:     "namespaces exceeded",
+			usage:    model.ResourcesLimits{Namespaces: 5},
+			delta:    model.ResourcesLimits{Namespaces: 1},
+			exceeded: true,
+		},
+		{
+			name:     "CPU exceeded",
+			usage:    model.ResourcesLimits{CPU: 3500},
+			delta:    model.ResourcesLimits{CPU: 501},
+			exceeded: true,
+		},
+		{
+			name:     "user limit overrides default",
+			limits:   model.UserLimits{RAM: intPtr(1024)},
+			usage:    model.ResourcesLimits{RAM: 512},
+			delta:    model.ResourcesLimits{RAM: 1024},
+			exceeded: true,
+		},
+		{
+			name:   "zero limit is unlimited",
+			limits: model.UserLimits{Namespaces: intPtr(0)},
+			usage:  model.ResourcesLimits{Namespaces: 100},
+			delta:  model.ResourcesLimits{Namespaces: 1},
+		},
+		{
+			name:     "services exceeded",
+			limits:   model.UserLimits{MaxExtServices: intPtr(2)},
+			usage:    model.ResourcesLimits{MaxExtServices: 2},
+			delta:    model.ResourcesLimits{MaxExtServices: 1},
+			exceeded: true,
+		},
+		{
+			name:   "shrink over lowered limit",
+			limits: model.UserLimits{CPU: intPtr(1000)},
+			usage:  model.ResourcesLimits{CPU: 3000},
+			delta:  model.ResourcesLimits{CPU: -500},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &limitsTestDB{limits: tc.limits, usage: tc.usage}
+			err := checkUserLimits(context.Background(), db, defaults, "user", tc.delta)
+			switch {
+			case tc.exceeded && !cherry.Equals(err, errors.ErrQuotaExceeded()):
+				t.Errorf("expected quota exceeded error, got %v", err)
+			case !tc.exceeded && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestResizeResources(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		oldNS, newNS model.Namespace
+		resources    model.ResourcesLimits
+	}{
+		{name: "not changed", oldNS: model.Namespace{CPU: 500, RAM: 512}, newNS: model.Namespace{CPU: 500, RAM: 512}},
+		{
+			name:      "grown",
+			oldNS:     model.Namespace{CPU: 500, RAM: 512, MaxExtServices: 1, MaxIntServices: 2},
+			newNS:     model.Namespace{CPU: 1000, RAM: 1024, MaxExtServices: 2, MaxIntServices: 4},
+			resources: model.ResourcesLimits{CPU: 500, RAM: 512, MaxExtServices: 1, MaxIntServices: 2},
+		},
+		{
+			name:      "shrunk",
+			oldNS:     model.Namespace{CPU: 1000, RAM: 1024},
+			newNS:     model.Namespace{CPU: 500, RAM: 2048},
+			resources: model.ResourcesLimits{CPU: -500, RAM: 1024},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := resizeResources(tc.oldNS, tc.newNS); !reflect.DeepEqual(actual, tc.resources) {
+				t.Errorf("expected %+v, got %+v", tc.resources, actual)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package server
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
 	"git.containerum.net/ch/permissions/pkg/model"
 	"github.com/containerum/bill-external/errors"
 	billing "github.com/containerum/bill-external/models"
+	"github.com/containerum/cherry"
 	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/containerum/utils/httputil"
 )
@@ -40,6 +44,101 @@ func OwnerCheck(ctx context.Context, resource model.Resource) error {
 	return nil
 }
 
+// ActionCheck checks that user role allows action on resource. Owner and admin can do anything.
+func ActionCheck(ctx context.Context, db database.DB, resource model.Resource, perm model.EffectivePermission, action model.Action) error {
+	if httputil.MustGetUserID(ctx) == resource.OwnerUserID || IsAdminRole(ctx) {
+		return nil
+	}
+
+	role, builtIn := model.BuiltInRole(string(perm.CurrentAccessLevel))
+	if perm.Role != nil {
+		var err error
+		if role, err = db.RoleByName(ctx, *perm.Role); err != nil {
+			return err
+		}
+	} else if !builtIn {
+		return errors.ErrPermissionDenied().AddDetailF("unknown access level %s", perm.CurrentAccessLevel)
+	}
+
+	if !role.Allows(action) {
+		return errors.ErrPermissionDenied().AddDetailF("role %s does not allow %s", role.Name, action)
+	}
+	return nil
+}
+
+// NamespaceActionCheck checks that user can do action with namespace. Organization admins can do anything with organization namespaces.
+func NamespaceActionCheck(ctx context.Context, db database.DB, ns model.NamespaceWithPermissions, action model.Action) error {
+	if ns.OrganizationID != nil {
+		isAdmin, err := isOrganizationAdmin(ctx, db, ns.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if isAdmin {
+			return nil
+		}
+	}
+
+	return ActionCheck(ctx, db, ns.Resource, ns.Permission, action)
+}
+
+// VolumeActionCheck checks that user can do action with volume. Organization admins can do anything with volumes of organization namespaces.
+func VolumeActionCheck(ctx context.Context, db database.DB, vol model.VolumeWithPermissions, action model.Action) error {
+	if vol.Namespace != nil && vol.Namespace.OrganizationID != nil {
+		isAdmin, err := isOrganizationAdmin(ctx, db, vol.Namespace.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if isAdmin {
+			return nil
+		}
+	}
+
+	return ActionCheck(ctx, db, vol.Resource, model.EffectivePermission{Permission: vol.Permission}, action)
+}
+
+// ProjectOwnerCheck checks that user owns project. Organization admins can manage organization projects.
+func ProjectOwnerCheck(ctx context.Context, db database.DB, project model.Project) error {
+	if project.OrganizationID != nil {
+		isAdmin, err := isOrganizationAdmin(ctx, db, project.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if isAdmin {
+			return nil
+		}
+	}
+
+	return OwnerCheck(ctx, project.Resource)
+}
+
+// organizationAdminCheck checks if current user can manage organization.
+func organizationAdminCheck(ctx context.Context, db database.DB, orgID string) error {
+	isAdmin, err := isOrganizationAdmin(ctx, db, &orgID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin {
+		return errors.ErrPermissionDenied().AddDetailF("only organization admin can do this")
+	}
+	return nil
+}
+
+func organizationOwnerCheck(ctx context.Context, org model.Organization) error {
+	if httputil.MustGetUserID(ctx) != org.OwnerUserID && !IsAdminRole(ctx) {
+		return errors.ErrPermissionDenied().AddDetailF("only organization owner can do this")
+	}
+	return nil
+}
+
+// isNotFound checks if error returned from other service means that requested object not found
+func isNotFound(err error) bool {
+	if cherryErr, ok := err.(*cherry.Err); ok {
+		return cherryErr.StatusHTTP == http.StatusNotFound
+	}
+	return false
+}
+
 func AddOwnerLogin(ctx context.Context, r *model.Resource, client clients.UserManagerClient) error {
 	user, err := client.UserInfoByID(ctx, r.OwnerUserID)
 	if err != nil {
@@ -77,7 +176,13 @@ func NamespaceAddUsage(ctx context.Context, ns *kubeClientModel.Namespace, clien
 	return nil
 }
 
-func UserGroupAccessToDBAccess(access kubeClientModel.UserGroupAccess) kubeClientModel.AccessLevel {
+// UserGroupAccessToDBAccess maps role of group member to access level.
+// Roles present in mapping are mapped by it, others by default rules.
+func UserGroupAccessToDBAccess(access kubeClientModel.UserGroupAccess, mapping model.GroupRoleMapping) kubeClientModel.AccessLevel {
+	if level, mapped := mapping[access]; mapped {
+		return level
+	}
+
 	switch access {
 	case kubeClientModel.OwnerAccess:
 		return kubeClientModel.Owner
@@ -91,3 +196,18 @@ func UserGroupAccessToDBAccess(access kubeClientModel.UserGroupAccess) kubeClien
 		return kubeClientModel.None
 	}
 }
+
+// maxRetryDelay limits exponential growth of delay between delivery attempts
+const maxRetryDelay = 24 * time.Hour
+
+// retryDelay returns delay before next delivery attempt growing exponentially with number of made attempts.
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+)
+
+// rolesTestDB returns custom roles, other methods are not implemented.
+type rolesTestDB struct {
+	database.DB
+	roles map[string]model.Role
+}
+
+func (db rolesTestDB) RoleByName(ctx context.Context, name string) (model.Role, error) {
+	role, ok := db.roles[name]
+	if !ok {
+		return model.Role{}, errors.ErrResourceNotExists().AddDetailF("role %s not exists", name)
+	}
+	return role, nil
+}
+
+func TestActionCheck(t *testing.T) {
+	db := rolesTestDB{roles: map[string]model.Role{
+		"maintainer":     {Name: "maintainer", AccessLevel: kubeClientModel.Read, Actions: []model.Action{model.ActionNamespaceRename, model.ActionNamespaceResize}},
+		"access-manager": {Name: "access-manager", AccessLevel: kubeClientModel.Write, Actions: []model.Action{model.ActionAccessManage}},
+	}}
+	resource := model.Resource{OwnerUserID: "owner"}
+
+	for _, tc := range []struct {
+		name   string
+		userID string
+		admin  bool
+		access kubeClientModel.AccessLevel
+		role   string
+		action model.Action
+		err    bool
+	}{
+		{name: "owner", userID: "owner", action: model.ActionNamespaceDelete},
+		{name: "admin", userID: "user", admin: true, access: kubeClientModel.Read, action: model.ActionNamespaceDelete},
+		{name: "built-in role allows", userID: "user", access: kubeClientModel.Write, action: model.ActionVolumeCreate},
+		{name: "built-in role denies", userID: "user", access: kubeClientModel.Write, action: model.ActionNamespaceResize, err: true},
+		{name: "read denies everything", userID: "user", access: kubeClientModel.Read, action: model.ActionNamespaceRename, err: true},
+		{name: "unknown access level", userID: "user", access: "superuser", action: model.ActionNamespaceResize, err: true},
+		{name: "custom role allows", userID: "user", access: kubeClientModel.Read, role: "maintainer", action: model.ActionNamespaceResize},
+		{name: "custom role denies", userID: "user", access: kubeClientModel.Read, role: "maintainer", action: model.ActionNamespaceDelete, err: true},
+		{name: "custom role allows not built-in action", userID: "user", access: kubeClientModel.Write, role: "access-manager", action: model.ActionAccessManage},
+		{name: "custom role replaces built-in", userID: "user", access: kubeClientModel.Write, role: "access-manager", action: model.ActionVolumeCreate, err: true},
+		{name: "custom role not exists", userID: "user", access: kubeClientModel.Write, role: "deleted", action: model.ActionNamespaceResize, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), httputil.UserIDContextKey, tc.userID)
+			if tc.admin {
+				ctx = context.WithValue(ctx, httputil.UserRoleContextKey, "admin")
+			}
+
+			perm := model.EffectivePermission{Permission: model.Permission{CurrentAccessLevel: tc.access}}
+			if tc.role != "" {
+				perm.Role = &tc.role
+			}
+
+			err := ActionCheck(ctx, db, resource, perm, tc.action)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUserGroupAccessToDBAccess(t *testing.T) {
+	mapping := model.GroupRoleMapping{
+		kubeClientModel.MemberAccess: kubeClientModel.Write,
+		kubeClientModel.GuestAccess:  kubeClientModel.None,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		access   kubeClientModel.UserGroupAccess
+		mapping  model.GroupRoleMapping
+		expected kubeClientModel.AccessLevel
+	}{
+		{name: "owner", access: kubeClientModel.OwnerAccess, expected: kubeClientModel.Owner},
+		{name: "admin", access: kubeClientModel.AdminAccess, expected: kubeClientModel.Write},
+		{name: "master", access: kubeClientModel.MasterAccess, expected: kubeClientModel.Write},
+		{name: "member", access: kubeClientModel.MemberAccess, expected: kubeClientModel.ReadDelete},
+		{name: "guest", access: kubeClientModel.GuestAccess, expected: kubeClientModel.Read},
+		{name: "no access", access: kubeClientModel.NoAccess, expected: kubeClientModel.None},
+		{name: "unknown", access: "superuser", expected: kubeClientModel.None},
+		{name: "mapped member", access: kubeClientModel.MemberAccess, mapping: mapping, expected: kubeClientModel.Write},
+		{name: "mapped guest", access: kubeClientModel.GuestAccess, mapping: mapping, expected: kubeClientModel.None},
+		{name: "not mapped owner", access: kubeClientModel.OwnerAccess, mapping: mapping, expected: kubeClientModel.Owner},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := UserGroupAccessToDBAccess(tc.access, tc.mapping); actual != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		base     time.Duration
+		attempts int
+		expected time.Duration
+	}{
+		{name: "no attempts", base: time.Second, attempts: 0, expected: time.Second},
+		{name: "first attempt", base: time.Second, attempts: 1, expected: time.Second},
+		{name: "doubled", base: time.Second, attempts: 2, expected: 2 * time.Second},
+		{name: "exponential", base: 5 * time.Second, attempts: 5, expected: 80 * time.Second},
+		{name: "capped", base: time.Minute, attempts: 20, expected: maxRetryDelay},
+		{name: "capped base", base: 48 * time.Hour, attempts: 1, expected: maxRetryDelay},
+		{name: "many attempts", base: time.Second, attempts: 1000, expected: maxRetryDelay},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := retryDelay(tc.base, tc.attempts); actual != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/database"
 	"git.containerum.net/ch/permissions/pkg/errors"
@@ -15,21 +16,25 @@ import (
 
 type NamespaceActions interface {
 	CreateNamespace(ctx context.Context, req model.NamespaceCreateRequest) error
-	GetNamespace(ctx context.Context, id string) (kubeClientModel.Namespace, error)
-	GetUserNamespaces(ctx context.Context, filters ...string) ([]kubeClientModel.Namespace, error)
-	GetAllNamespaces(ctx context.Context, page, perPage int, filters ...string) ([]kubeClientModel.Namespace, error)
+	GetNamespace(ctx context.Context, id string) (model.NamespaceResponse, error)
+	GetUserNamespaces(ctx context.Context, organizationID, labelSelector string, filters ...string) ([]model.NamespaceResponse, error)
+	GetAllNamespaces(ctx context.Context, page, perPage int, organizationID, labelSelector string, filters ...string) ([]model.NamespaceResponse, error)
 	AdminCreateNamespace(ctx context.Context, req model.NamespaceAdminCreateRequest) error
 	AdminResizeNamespace(ctx context.Context, id string, req model.NamespaceAdminResizeRequest) error
 	RenameNamespace(ctx context.Context, id, newLabel string) error
+	UpdateNamespaceMeta(ctx context.Context, id string, req model.NamespaceUpdateMetaRequest) error
 	ResizeNamespace(ctx context.Context, id, newTariffID string) error
-	DeleteNamespace(ctx context.Context, id string) error
-	DeleteAllUserNamespaces(ctx context.Context) error
-	AddGroupNamespace(ctx context.Context, namespace, groupID string) error
+	DeleteNamespace(ctx context.Context, id string) (model.Operation, error)
+	DeleteAllUserNamespaces(ctx context.Context) (model.Operation, error)
+	AddGroupNamespace(ctx context.Context, namespace string, req model.ProjectAddGroupRequest) error
 	SetGroupMemberNamespaceAccess(ctx context.Context, namespace, groupID string, req model.SetGroupMemberAccessRequest) error
 	GetNamespaceGroups(ctx context.Context, projectID string) ([]kubeClientModel.UserGroup, error)
 	DeleteGroupFromNamespace(ctx context.Context, namespace, groupID string) error
 	GetGroupsNamespaces(ctx context.Context, groupID string) ([]kubeClientModel.Namespace, error)
 	ImportNamespaces(ctx context.Context, req kubeClientModel.NamespacesList) kubeClientModel.ImportResponse
+	TransferNamespace(ctx context.Context, id string, req model.NamespaceTransferRequest) error
+	CloneNamespace(ctx context.Context, id string, req model.NamespaceCloneRequest) error
+	RestoreNamespace(ctx context.Context, id string) error
 }
 
 var StandardNamespaceFilter = database.NamespaceFilter{
@@ -66,7 +71,8 @@ func (s *Server) CreateNamespace(ctx context.Context, req model.NamespaceCreateR
 
 	nsuuid := uuid.NewV4().String()
 
-	err = s.db.Transactional(func(tx database.DB) error {
+	var op *model.Operation
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
 		ns := model.NamespaceWithPermissions{
 			Namespace: model.Namespace{
 				Resource: model.Resource{
@@ -84,19 +90,35 @@ func (s *Server) CreateNamespace(ctx context.Context, req model.NamespaceCreateR
 			},
 		}
 
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, userID, namespaceResources(ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
 		if createErr := tx.CreateNamespace(ctx, &ns.Namespace); createErr != nil {
 			return createErr
 		}
 
-		if createErr := s.clients.Kube.CreateNamespace(ctx, ns.ToKube()); createErr != nil {
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceCreate, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns.Namespace},
+			stepKubeCreateNamespace, stepBillingSubscribe)
+		if opErr != nil {
+			return opErr
+		}
+
+		if createErr := s.runOperationStep(ctx, op, stepKubeCreateNamespace, func() error {
+			return s.clients.Kube.CreateNamespace(ctx, ns.ToKube())
+		}); createErr != nil {
 			return createErr
 		}
 
-		if subErr := s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
-			TariffID:      tariff.ID,
-			ResourceType:  billing.Namespace,
-			ResourceLabel: ns.Label,
-			ResourceID:    ns.KubeName,
+		if subErr := s.runOperationStep(ctx, op, stepBillingSubscribe, func() error {
+			return s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
+				TariffID:      tariff.ID,
+				ResourceType:  billing.Namespace,
+				ResourceLabel: ns.Label,
+				ResourceID:    ns.KubeName,
+			})
 		}); subErr != nil {
 			return subErr
 		}
@@ -105,13 +127,21 @@ func (s *Server) CreateNamespace(ctx context.Context, req model.NamespaceCreateR
 			return updErr
 		}
 
-		return nil
+		if auditErr := auditResourceChange(ctx, tx, "CreateNamespace", model.ResourceNamespace, ns.ID, nil, namespaceState(ns.Namespace)); auditErr != nil {
+			return auditErr
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns.Namespace)); pubErr != nil {
+			return pubErr
+		}
+
+		return completeOperation(ctx, tx, op)
 	})
 
-	return err
+	return s.finishOperation(ctx, op, err)
 }
 
-func (s *Server) GetNamespace(ctx context.Context, name string) (kubeClientModel.Namespace, error) {
+func (s *Server) GetNamespace(ctx context.Context, name string) (model.NamespaceResponse, error) {
 	userID := httputil.MustGetUserID(ctx)
 
 	s.log.WithFields(logrus.Fields{
@@ -121,28 +151,30 @@ func (s *Server) GetNamespace(ctx context.Context, name string) (kubeClientModel
 
 	ns, err := s.db.NamespaceByName(ctx, userID, name, IsAdminRole(ctx))
 	if err != nil {
-		return kubeClientModel.Namespace{}, err
+		return model.NamespaceResponse{}, err
 	}
 
-	kubeNS := ns.ToKube()
-	if kubeErr := NamespaceAddUsage(ctx, &kubeNS, s.clients.Kube); kubeErr != nil {
+	resp := ns.ToResponse()
+	if kubeErr := NamespaceAddUsage(ctx, &resp.Namespace, s.clients.Kube); kubeErr != nil {
 		s.log.WithError(kubeErr).Warn("NamespaceAddUsage failed")
-		return kubeClientModel.Namespace{},
+		return model.NamespaceResponse{},
 			errors.ErrResourceNotExists().AddDetailF("namespace %s not exists", name)
 	}
 
 	AddOwnerLogin(ctx, &ns.Resource, s.clients.User)
 	AddUserLogins(ctx, ns.Permissions, s.clients.User)
 
-	return kubeNS, nil
+	return resp, nil
 }
 
-func (s *Server) GetUserNamespaces(ctx context.Context, filters ...string) ([]kubeClientModel.Namespace, error) {
+func (s *Server) GetUserNamespaces(ctx context.Context, organizationID, labelSelector string, filters ...string) ([]model.NamespaceResponse, error) {
 	userID := httputil.MustGetUserID(ctx)
 
 	s.log.WithFields(logrus.Fields{
-		"user_id": userID,
-		"filters": filters,
+		"user_id":         userID,
+		"organization_id": organizationID,
+		"filters":         filters,
+		"label_selector":  labelSelector,
 	}).Infof("get user namespaces")
 
 	var filter database.NamespaceFilter
@@ -151,31 +183,40 @@ func (s *Server) GetUserNamespaces(ctx context.Context, filters ...string) ([]ku
 	} else {
 		filter = database.ParseNamespaceFilter(filters...)
 	}
+	filter.OrganizationID = organizationID
+
+	var err error
+	filter.LabelSelector, err = database.ParseLabelSelector(labelSelector)
+	if err != nil {
+		return nil, errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
 
 	namespaces, err := s.db.UserNamespaces(ctx, userID, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	ret := make([]kubeClientModel.Namespace, 0)
+	ret := make([]model.NamespaceResponse, 0)
 	for _, namespace := range namespaces {
 		AddOwnerLogin(ctx, &namespace.Resource, s.clients.User)
-		kubeNS := namespace.ToKube()
-		kubeErr := NamespaceAddUsage(ctx, &kubeNS, s.clients.Kube)
+		resp := namespace.ToResponse()
+		kubeErr := NamespaceAddUsage(ctx, &resp.Namespace, s.clients.Kube)
 		if kubeErr != nil {
 			s.log.WithError(kubeErr).Warn("NamespaceAddUsage failed")
 		}
-		ret = append(ret, kubeNS)
+		ret = append(ret, resp)
 	}
 
 	return ret, nil
 }
 
-func (s *Server) GetAllNamespaces(ctx context.Context, page, perPage int, filters ...string) ([]kubeClientModel.Namespace, error) {
+func (s *Server) GetAllNamespaces(ctx context.Context, page, perPage int, organizationID, labelSelector string, filters ...string) ([]model.NamespaceResponse, error) {
 	s.log.WithFields(logrus.Fields{
-		"page":     page,
-		"per_page": perPage,
-		"filters":  filters,
+		"page":            page,
+		"per_page":        perPage,
+		"organization_id": organizationID,
+		"filters":         filters,
+		"label_selector":  labelSelector,
 	}).Infof("get all namespaces")
 
 	var filter database.NamespaceFilter
@@ -184,23 +225,30 @@ func (s *Server) GetAllNamespaces(ctx context.Context, page, perPage int, filter
 	} else {
 		filter = StandardNamespaceFilter
 	}
+	filter.OrganizationID = organizationID
 	filter.Limit = perPage
 	filter.SetPage(page)
 
+	var err error
+	filter.LabelSelector, err = database.ParseLabelSelector(labelSelector)
+	if err != nil {
+		return nil, errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+
 	namespaces, err := s.db.AllNamespaces(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	ret := make([]kubeClientModel.Namespace, 0)
+	ret := make([]model.NamespaceResponse, 0)
 	for _, namespace := range namespaces {
 		AddOwnerLogin(ctx, &namespace.Resource, s.clients.User)
-		kubeNS := (&model.NamespaceWithPermissions{Namespace: namespace}).ToKube()
-		kubeErr := NamespaceAddUsage(ctx, &kubeNS, s.clients.Kube)
+		resp := (&model.NamespaceWithPermissions{Namespace: namespace}).ToResponse()
+		kubeErr := NamespaceAddUsage(ctx, &resp.Namespace, s.clients.Kube)
 		if kubeErr != nil {
 			s.log.WithError(kubeErr).Warn("NamespaceAddUsage failed")
 		}
-		ret = append(ret, kubeNS)
+		ret = append(ret, resp)
 	}
 
 	return ret, nil
@@ -215,7 +263,8 @@ func (s *Server) AdminCreateNamespace(ctx context.Context, req model.NamespaceAd
 
 	nsuuid := uuid.NewV4().String()
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	var op *model.Operation
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		ns := model.NamespaceWithPermissions{
 			Namespace: model.Namespace{
 				Resource: model.Resource{
@@ -232,11 +281,25 @@ func (s *Server) AdminCreateNamespace(ctx context.Context, req model.NamespaceAd
 			},
 		}
 
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, userID, namespaceResources(ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
 		if createErr := tx.CreateNamespace(ctx, &ns.Namespace); createErr != nil {
 			return createErr
 		}
 
-		if createErr := s.clients.Kube.CreateNamespace(ctx, ns.ToKube()); createErr != nil {
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceCreate, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns.Namespace},
+			stepKubeCreateNamespace)
+		if opErr != nil {
+			return opErr
+		}
+
+		if createErr := s.runOperationStep(ctx, op, stepKubeCreateNamespace, func() error {
+			return s.clients.Kube.CreateNamespace(ctx, ns.ToKube())
+		}); createErr != nil {
 			return createErr
 		}
 
@@ -244,10 +307,18 @@ func (s *Server) AdminCreateNamespace(ctx context.Context, req model.NamespaceAd
 			return updErr
 		}
 
-		return nil
+		if auditErr := auditResourceChange(ctx, tx, "AdminCreateNamespace", model.ResourceNamespace, ns.ID, nil, namespaceState(ns.Namespace)); auditErr != nil {
+			return auditErr
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns.Namespace)); pubErr != nil {
+			return pubErr
+		}
+
+		return completeOperation(ctx, tx, op)
 	})
 
-	return err
+	return s.finishOperation(ctx, op, err)
 }
 
 func (s *Server) ImportNamespaces(ctx context.Context, req kubeClientModel.NamespacesList) kubeClientModel.ImportResponse {
@@ -259,7 +330,7 @@ func (s *Server) ImportNamespaces(ctx context.Context, req kubeClientModel.Names
 	}
 
 	for _, reqns := range req.Namespaces {
-		err := s.db.Transactional(func(tx database.DB) error {
+		err := s.db.Transactional(ctx, func(tx database.DB) error {
 			ns := model.NamespaceWithPermissions{
 				Namespace: model.Namespace{
 					Resource: model.Resource{
@@ -275,10 +346,19 @@ func (s *Server) ImportNamespaces(ctx context.Context, req kubeClientModel.Names
 				},
 			}
 
+			if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, reqns.Owner, namespaceResources(ns.Namespace)); chkErr != nil {
+				return chkErr
+			}
+
 			if createErr := tx.CreateNamespace(ctx, &ns.Namespace); createErr != nil {
 				return createErr
 			}
-			return nil
+
+			if auditErr := auditResourceChange(ctx, tx, "ImportNamespaces", model.ResourceNamespace, ns.ID, nil, namespaceState(ns.Namespace)); auditErr != nil {
+				return auditErr
+			}
+
+			return publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns.Namespace))
 		})
 		if err != nil {
 			s.log.Debugln("Unable to add namespace:", err)
@@ -299,12 +379,13 @@ func (s *Server) AdminResizeNamespace(ctx context.Context, name string, req mode
 		WithField("name", name).
 		Infof("admin resize namespace %+v", req)
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		ns, getErr := tx.NamespaceByName(ctx, userID, name, IsAdminRole(ctx))
 		if getErr != nil {
 			return getErr
 		}
 
+		oldNS := ns.Namespace
 		if req.CPU != nil {
 			ns.CPU = *req.CPU
 		}
@@ -321,6 +402,10 @@ func (s *Server) AdminResizeNamespace(ctx context.Context, name string, req mode
 			ns.MaxTraffic = *req.MaxTraffic
 		}
 
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, ns.OwnerUserID, resizeResources(oldNS, ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
 		nsWithUsage, getErr := s.clients.Kube.GetNamespace(ctx, ns.KubeName)
 		if getErr != nil {
 			return getErr
@@ -340,6 +425,14 @@ func (s *Server) AdminResizeNamespace(ctx context.Context, name string, req mode
 			return setErr
 		}
 
+		if auditErr := auditResourceChange(ctx, tx, "AdminResizeNamespace", model.ResourceNamespace, ns.ID, quotaState(oldNS), quotaState(ns.Namespace)); auditErr != nil {
+			return auditErr
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceResized, model.ResourceNamespace, ns.ID, model.EventPayload{"before": quotaState(oldNS), "after": quotaState(ns.Namespace)}); pubErr != nil {
+			return pubErr
+		}
+
 		return nil
 	})
 
@@ -354,16 +447,17 @@ func (s *Server) RenameNamespace(ctx context.Context, id, newLabel string) error
 		"new_id":  newLabel,
 	}).Infof("rename namespace")
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		ns, getErr := tx.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
 		if getErr != nil {
 			return getErr
 		}
 
-		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionNamespaceRename); chkErr != nil {
 			return chkErr
 		}
 
+		oldLabel := ns.Label
 		if renameErr := tx.RenameNamespace(ctx, &ns.Namespace, newLabel); renameErr != nil {
 			return renameErr
 		}
@@ -372,8 +466,19 @@ func (s *Server) RenameNamespace(ctx context.Context, id, newLabel string) error
 			return renameErr
 		}
 
-		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, userID); updErr != nil {
-			return updErr
+		// namespace may be changed by user with role, not only by owner
+		users := []string{userID}
+		if ns.OwnerUserID != userID {
+			users = append(users, ns.OwnerUserID)
+		}
+		for _, user := range users {
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user); updErr != nil {
+				return updErr
+			}
+		}
+
+		if auditErr := auditResourceChange(ctx, tx, "RenameNamespace", model.ResourceNamespace, ns.ID, model.AuditState{"label": oldLabel}, model.AuditState{"label": newLabel}); auditErr != nil {
+			return auditErr
 		}
 
 		return nil
@@ -382,6 +487,46 @@ func (s *Server) RenameNamespace(ctx context.Context, id, newLabel string) error
 	return err
 }
 
+func (s *Server) UpdateNamespaceMeta(ctx context.Context, id string, req model.NamespaceUpdateMetaRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("update namespace meta")
+
+	if err := database.ValidateLabels(req.Labels); err != nil {
+		return errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionNamespaceRename); chkErr != nil {
+			return chkErr
+		}
+
+		before := model.AuditState{"labels": ns.Labels, "description": ns.Description}
+		if req.Labels != nil {
+			ns.Labels = req.Labels
+		}
+		if req.Description != nil {
+			ns.Description = *req.Description
+		}
+
+		if updErr := tx.UpdateNamespaceMeta(ctx, &ns.Namespace); updErr != nil {
+			return updErr
+		}
+
+		return auditResourceChange(ctx, tx, "UpdateNamespaceMeta", model.ResourceNamespace, ns.ID,
+			before, model.AuditState{"labels": ns.Labels, "description": ns.Description})
+	})
+
+	return err
+}
+
 func (s *Server) ResizeNamespace(ctx context.Context, id, newTariffID string) error {
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
@@ -399,23 +544,29 @@ func (s *Server) ResizeNamespace(ctx context.Context, id, newTariffID string) er
 		return chkErr
 	}
 
-	err = s.db.Transactional(func(tx database.DB) error {
-		ns, getErr := s.db.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+	var op *model.Operation
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
 		if getErr != nil {
 			return getErr
 		}
 
-		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionNamespaceResize); chkErr != nil {
 			return chkErr
 		}
 
+		oldNS := ns.Namespace
 		ns.TariffID = &newTariff.ID
-		ns.MaxIntServices = newTariff.ExternalServices
+		ns.MaxExtServices = newTariff.ExternalServices
 		ns.MaxIntServices = newTariff.InternalServices
 		ns.MaxTraffic = newTariff.Traffic
 		ns.CPU = newTariff.CPULimit
 		ns.RAM = newTariff.MemoryLimit
 
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, ns.OwnerUserID, resizeResources(oldNS, ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
 		nsWithUsage, getErr := s.clients.Kube.GetNamespace(ctx, ns.KubeName)
 		if getErr != nil {
 			return getErr
@@ -431,48 +582,76 @@ func (s *Server) ResizeNamespace(ctx context.Context, id, newTariffID string) er
 			return resizeErr
 		}
 
-		if resizeErr := s.clients.Kube.SetNamespaceQuota(ctx, ns.ToKube()); resizeErr != nil {
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceResize, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns.Namespace, OldNamespace: &oldNS},
+			stepKubeSetQuota, stepBillingUpdateSubscription, stepDeleteEphemeralVolumes)
+		if opErr != nil {
+			return opErr
+		}
+
+		if resizeErr := s.runOperationStep(ctx, op, stepKubeSetQuota, func() error {
+			return s.clients.Kube.SetNamespaceQuota(ctx, kubeNS)
+		}); resizeErr != nil {
+			return resizeErr
+		}
+
+		if resizeErr := s.runOperationStep(ctx, op, stepBillingUpdateSubscription, func() error {
+			return s.clients.Billing.UpdateSubscription(ctx, ns.KubeName, newTariff.ID)
+		}); resizeErr != nil {
 			return resizeErr
 		}
 
-		if newTariff.VolumeSize == 0 {
+		// deleted volumes can not be restored so they are deleted after all revertible steps
+		if delErr := s.runOperationStep(ctx, op, stepDeleteEphemeralVolumes, func() error {
+			if newTariff.VolumeSize != 0 {
+				return nil
+			}
 			volumes, err := s.clients.Volume.GetNamespaceVolumes(ctx, ns.KubeName)
 			if err != nil {
 				return err
 			}
 			for _, v := range volumes {
 				if v.TariffID == "00000000-0000-0000-0000-000000000000" {
-					if createErr := s.clients.Volume.DeleteNamespaceVolume(ctx, ns.KubeName, v.Name); createErr != nil {
-						return createErr
+					if delErr := s.clients.Volume.DeleteNamespaceVolume(ctx, ns.KubeName, v.Name); delErr != nil {
+						return delErr
 					}
 				}
 			}
+			return nil
+		}); delErr != nil {
+			return delErr
 		}
 
-		if resizeErr := s.clients.Billing.UpdateSubscription(ctx, ns.KubeName, newTariff.ID); resizeErr != nil {
-			return resizeErr
+		if auditErr := auditResourceChange(ctx, tx, "ResizeNamespace", model.ResourceNamespace, ns.ID, quotaState(oldNS), quotaState(ns.Namespace)); auditErr != nil {
+			return auditErr
 		}
 
-		return nil
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceResized, model.ResourceNamespace, ns.ID, model.EventPayload{"before": quotaState(oldNS), "after": quotaState(ns.Namespace)}); pubErr != nil {
+			return pubErr
+		}
+
+		return completeOperation(ctx, tx, op)
 	})
 
-	return err
+	return s.finishOperation(ctx, op, err)
 }
 
-func (s *Server) DeleteNamespace(ctx context.Context, name string) error {
+func (s *Server) DeleteNamespace(ctx context.Context, name string) (model.Operation, error) {
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
 		"user_id": userID,
 		"id":      name,
 	}).Infof("delete namespace")
 
-	err := s.db.Transactional(func(tx database.DB) error {
-		ns, getErr := s.db.NamespaceByName(ctx, userID, name, IsAdminRole(ctx))
+	var op *model.Operation
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, name, IsAdminRole(ctx))
 		if getErr != nil {
 			return getErr
 		}
 
-		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionNamespaceDelete); chkErr != nil {
 			return chkErr
 		}
 
@@ -480,42 +659,60 @@ func (s *Server) DeleteNamespace(ctx context.Context, name string) error {
 			return delErr
 		}
 
-		if delErr := s.clients.Solutions.DeleteNamespaceSolutions(ctx, ns.KubeName); delErr != nil {
+		deletedVolumes, delErr := tx.DeleteNamespaceVolumes(ctx, ns.Namespace)
+		if delErr != nil {
 			return delErr
 		}
 
-		if delErr := s.clients.Resource.DeleteNamespaceResources(ctx, ns.KubeName); delErr != nil {
-			return delErr
+		resourceIDs := []string{ns.KubeName}
+		for _, v := range deletedVolumes {
+			resourceIDs = append(resourceIDs, v.ID)
 		}
 
-		if delErr := s.clients.Volume.DeleteNamespaceVolumes(ctx, ns.KubeName); delErr != nil {
-			return delErr
+		op = s.newCleanupOperation(ctx, model.OperationNamespaceCleanup, model.ResourceNamespace, ns.ID, model.CleanupTarget{
+			UserID:      ns.OwnerUserID,
+			NamespaceID: ns.ID,
+			KubeName:    ns.KubeName,
+			ResourceIDs: resourceIDs,
+		})
+		if createErr := tx.CreateOperation(ctx, op); createErr != nil {
+			return createErr
 		}
 
-		resourceIDs := []string{ns.KubeName}
-		if unsubErr := s.clients.Billing.MassiveUnsubscribe(ctx, resourceIDs); unsubErr != nil {
-			return unsubErr
+		// namespace may be changed by user with role, not only by owner
+		users := []string{userID}
+		if ns.OwnerUserID != userID {
+			users = append(users, ns.OwnerUserID)
+		}
+		for _, user := range users {
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user); updErr != nil {
+				return updErr
+			}
 		}
 
-		if delErr := s.clients.Kube.DeleteNamespace(ctx, ns.ToKube()); delErr != nil {
-			return delErr
+		if auditErr := auditResourceChange(ctx, tx, "DeleteNamespace", model.ResourceNamespace, ns.ID, namespaceState(ns.Namespace), nil); auditErr != nil {
+			return auditErr
 		}
 
-		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, userID); updErr != nil {
-			return updErr
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceDeleted, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns.Namespace)); pubErr != nil {
+			return pubErr
 		}
 
 		return nil
 	})
+	if err != nil {
+		return model.Operation{}, err
+	}
 
-	return err
+	return s.startCleanup(ctx, op), nil
 }
 
-func (s *Server) DeleteAllUserNamespaces(ctx context.Context) error {
+func (s *Server) DeleteAllUserNamespaces(ctx context.Context) (model.Operation, error) {
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithField("user_id", userID).Infof("delete all user namespaces")
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	var op *model.Operation
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		deletedNamespaces, delErr := tx.DeleteAllUserNamespaces(ctx, userID)
 		if delErr != nil {
 			return delErr
@@ -524,26 +721,30 @@ func (s *Server) DeleteAllUserNamespaces(ctx context.Context) error {
 		var resourceIDs []string
 		for _, v := range deletedNamespaces {
 			resourceIDs = append(resourceIDs, v.ID)
-		}
 
-		if unsubErr := s.clients.Billing.MassiveUnsubscribe(ctx, resourceIDs); unsubErr != nil {
-			return unsubErr
-		}
-
-		if delErr := s.clients.Solutions.DeleteUserSolutions(ctx); delErr != nil {
-			return delErr
-		}
+			if auditErr := auditResourceChange(ctx, tx, "DeleteAllUserNamespaces", model.ResourceNamespace, v.ID, namespaceState(v), nil); auditErr != nil {
+				return auditErr
+			}
 
-		if delErr := s.clients.Resource.DeleteAllUserNamespaces(ctx); delErr != nil {
-			return delErr
-		}
+			if pubErr := publishEvent(ctx, tx, model.EventNamespaceDeleted, model.ResourceNamespace, v.ID, namespaceEventPayload(v)); pubErr != nil {
+				return pubErr
+			}
 
-		if delErr := s.clients.Kube.DeleteUserNamespaces(ctx, userID); delErr != nil {
-			return delErr
+			deletedVolumes, delErr := tx.DeleteNamespaceVolumes(ctx, v)
+			if delErr != nil {
+				return delErr
+			}
+			for _, vol := range deletedVolumes {
+				resourceIDs = append(resourceIDs, vol.ID)
+			}
 		}
 
-		if delErr := s.clients.Volume.DeleteAllUserVolumes(ctx); delErr != nil {
-			return delErr
+		op = s.newCleanupOperation(ctx, model.OperationUserNamespacesCleanup, model.AuditResourceUser, userID, model.CleanupTarget{
+			UserID:      userID,
+			ResourceIDs: resourceIDs,
+		})
+		if createErr := tx.CreateOperation(ctx, op); createErr != nil {
+			return createErr
 		}
 
 		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, userID); updErr != nil {
@@ -552,52 +753,538 @@ func (s *Server) DeleteAllUserNamespaces(ctx context.Context) error {
 
 		return nil
 	})
+	if err != nil {
+		return model.Operation{}, err
+	}
 
-	return err
+	return s.startCleanup(ctx, op), nil
+}
+
+// namespaceSubscription returns billing subscription of namespace or nothing if namespace has no tariff.
+func namespaceSubscription(ns model.Namespace) []billing.SubscribeTariffRequest {
+	if ns.TariffID == nil {
+		return nil
+	}
+	return []billing.SubscribeTariffRequest{{
+		TariffID:      *ns.TariffID,
+		ResourceType:  billing.Namespace,
+		ResourceLabel: ns.Label,
+		ResourceID:    ns.KubeName,
+	}}
+}
+
+// volumeSubscriptions returns billing subscriptions of volumes having tariff.
+func volumeSubscriptions(volumes []model.Volume) []billing.SubscribeTariffRequest {
+	var ret []billing.SubscribeTariffRequest
+	for _, v := range volumes {
+		if v.TariffID == nil {
+			continue
+		}
+		ret = append(ret, billing.SubscribeTariffRequest{
+			TariffID:      *v.TariffID,
+			ResourceType:  billing.Volume,
+			ResourceLabel: v.Label,
+			ResourceID:    v.ID,
+		})
+	}
+	return ret
+}
+
+// transferSubscriptions moves billing subscriptions from one user to another.
+// Billing can't change subscription owner, so resource is unsubscribed on behalf of previous owner and subscribed on behalf of new one.
+// Interrupted transfer leaves some resources unsubscribed, so not found error of unsubscribe is ignored to make transfer repeatable.
+func (s *Server) transferSubscriptions(ctx context.Context, subscriptions []billing.SubscribeTariffRequest, fromUserID, toUserID string) error {
+	for _, sub := range subscriptions {
+		if err := s.clients.Billing.Unsubscribe(serviceContext(ctx, fromUserID), sub.ResourceID); err != nil && !isNotFound(err) {
+			return err
+		}
+		if err := s.clients.Billing.Subscribe(serviceContext(ctx, toUserID), sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (s *Server) AddGroupNamespace(ctx context.Context, namespace, groupID string) error {
+func (s *Server) TransferNamespace(ctx context.Context, id string, req model.NamespaceTransferRequest) error {
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
-		"user_id":   userID,
-		"group_id":  groupID,
-		"namespace": namespace,
-	}).Info("add group")
+		"user_id":          userID,
+		"id":               id,
+		"new_owner":        req.Username,
+		"old_owner_access": req.OldOwnerAccess,
+	}).Infof("transfer namespace")
+
+	oldOwnerAccess := s.cfg.TransferOldOwnerAccess
+	if req.OldOwnerAccess != nil {
+		oldOwnerAccess = *req.OldOwnerAccess
+	}
+	if oldOwnerAccess == kubeClientModel.Owner {
+		return errors.ErrRequestValidationFailed().AddDetailF("previous owner can`t keep owner access")
+	}
 
-	group, err := s.clients.User.Group(ctx, groupID)
+	newOwner, err := s.clients.User.UserInfoByLogin(ctx, req.Username)
 	if err != nil {
 		return err
 	}
 
-	ns, err := s.db.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+	var op *model.Operation
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionNamespaceTransfer); chkErr != nil {
+			return chkErr
+		}
+
+		oldOwnerID := ns.OwnerUserID
+		if newOwner.ID == oldOwnerID {
+			return errors.ErrRequestValidationFailed().AddDetailF("user %s already owns namespace", req.Username)
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, ns.OrganizationID, newOwner.ID); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, newOwner.ID, namespaceResources(ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
+		var project *model.Project
+		if ns.ProjectID != nil {
+			p, getErr := tx.ProjectByID(ctx, *ns.ProjectID)
+			if getErr != nil {
+				return getErr
+			}
+			project = &p
+		}
+
+		oldNS := ns.Namespace
+		volumes, transferErr := tx.TransferNamespace(ctx, &ns.Namespace, newOwner.ID, oldOwnerAccess)
+		if transferErr != nil {
+			return transferErr
+		}
+
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceTransfer, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns.Namespace, OldNamespace: &oldNS, Volumes: volumes},
+			stepBillingTransferNamespace, stepBillingTransferVolumes)
+		if opErr != nil {
+			return opErr
+		}
+
+		if transferErr := s.runOperationStep(ctx, op, stepBillingTransferNamespace, func() error {
+			return s.transferSubscriptions(ctx, namespaceSubscription(oldNS), oldOwnerID, newOwner.ID)
+		}); transferErr != nil {
+			return transferErr
+		}
+
+		if transferErr := s.runOperationStep(ctx, op, stepBillingTransferVolumes, func() error {
+			return s.transferSubscriptions(ctx, volumeSubscriptions(volumes), oldOwnerID, newOwner.ID)
+		}); transferErr != nil {
+			return transferErr
+		}
+
+		for _, user := range []string{oldOwnerID, newOwner.ID} {
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user); updErr != nil {
+				return updErr
+			}
+		}
+
+		// namespace was detached from project of previous owner
+		if project != nil {
+			if updErr := updateProjectUsersAccesses(ctx, s.clients.Auth, tx, *project); updErr != nil {
+				return updErr
+			}
+		}
+
+		before := model.AuditState{"owner_user_id": oldOwnerID}
+		after := model.AuditState{"owner_user_id": newOwner.ID, "old_owner_access": oldOwnerAccess}
+		if auditErr := auditResourceChange(ctx, tx, "TransferNamespace", model.ResourceNamespace, ns.ID, before, after); auditErr != nil {
+			return auditErr
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceTransferred, model.ResourceNamespace, ns.ID, model.EventPayload{"before": before, "after": after}); pubErr != nil {
+			return pubErr
+		}
+
+		return completeOperation(ctx, tx, op)
+	})
+
+	return s.finishOperation(ctx, op, err)
+}
+
+func (s *Server) CloneNamespace(ctx context.Context, id string, req model.NamespaceCloneRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+		"label":   req.Label,
+	}).Infof("clone namespace")
+
+	src, err := s.db.NamespaceByName(ctx, userID, id, IsAdminRole(ctx))
 	if err != nil {
 		return err
 	}
 
-	var accessList []database.AccessListElement
-	for _, v := range group.Members {
-		if v.Access != kubeClientModel.Owner {
-			accessList = append(accessList, database.AccessListElement{
-				AccessLevel: v.Access,
-				ToUserID:    v.ID,
-				GroupID:     &groupID,
-			})
-		} else {
-			ownerErr := s.db.Transactional(func(tx database.DB) error {
-				if err = tx.SetNamespaceAccess(ctx, ns.Namespace, v.Access, v.ID); err != nil {
-					s.log.Warningln("Unable to add owner. Trying to add user with 'Write' permissions")
-					err = tx.SetNamespaceAccess(ctx, ns.Namespace, kubeClientModel.Write, v.ID)
+	// namespace created by admin has no tariff, so its quota can be copied only by admin
+	var tariff *billing.NamespaceTariff
+	if src.TariffID != nil {
+		t, getErr := s.clients.Billing.GetNamespaceTariff(ctx, *src.TariffID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := CheckTariff(t.Tariff, IsAdminRole(ctx)); chkErr != nil {
+			return chkErr
+		}
+		tariff = &t
+	} else if !IsAdminRole(ctx) {
+		return errors.ErrAdminRequired().AddDetailF("namespace %s without tariff can be cloned only by admin", src.Label)
+	}
+
+	nsuuid := uuid.NewV4().String()
+
+	var op *model.Operation
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		if chkErr := NamespaceActionCheck(ctx, tx, src, model.ActionAccessManage); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, src.OrganizationID, userID); chkErr != nil {
+			return chkErr
+		}
+
+		ns := model.NamespaceWithPermissions{
+			Namespace: model.Namespace{
+				Resource: model.Resource{
+					OwnerUserID: userID,
+					Label:       req.Label,
+					ID:          nsuuid,
+				},
+				KubeName:       nsuuid,
+				TariffID:       src.TariffID,
+				CPU:            src.CPU,
+				RAM:            src.RAM,
+				MaxExtServices: src.MaxExtServices,
+				MaxIntServices: src.MaxIntServices,
+				MaxTraffic:     src.MaxTraffic,
+				OrganizationID: src.OrganizationID,
+			},
+		}
+		if tariff != nil {
+			ns.CPU = tariff.CPULimit
+			ns.RAM = tariff.MemoryLimit
+			ns.MaxExtServices = tariff.ExternalServices
+			ns.MaxIntServices = tariff.InternalServices
+			ns.MaxTraffic = tariff.Traffic
+		}
+
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, userID, namespaceResources(ns.Namespace)); chkErr != nil {
+			return chkErr
+		}
+
+		if createErr := tx.CreateNamespace(ctx, &ns.Namespace); createErr != nil {
+			return createErr
+		}
+
+		copiedPerms, copyErr := tx.CopyNamespacePermissions(ctx, src.Namespace, ns.Namespace)
+		if copyErr != nil {
+			return copyErr
+		}
+
+		// group members are rebuilt from current membership
+		materializedPerms, materializeErr := tx.MaterializeGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
+		if materializeErr != nil {
+			return materializeErr
+		}
+
+		if permErr := tx.NamespacePermissions(ctx, &ns); permErr != nil {
+			return permErr
+		}
+
+		steps := []string{stepKubeCreateNamespace}
+		if tariff != nil {
+			steps = append(steps, stepBillingSubscribe)
+		}
+
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceClone, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns.Namespace}, steps...)
+		if opErr != nil {
+			return opErr
+		}
+
+		if createErr := s.runOperationStep(ctx, op, stepKubeCreateNamespace, func() error {
+			return s.clients.Kube.CreateNamespace(ctx, ns.ToKube())
+		}); createErr != nil {
+			return createErr
+		}
+
+		if tariff != nil {
+			if subErr := s.runOperationStep(ctx, op, stepBillingSubscribe, func() error {
+				return s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
+					TariffID:      tariff.ID,
+					ResourceType:  billing.Namespace,
+					ResourceLabel: ns.Label,
+					ResourceID:    ns.KubeName,
+				})
+			}); subErr != nil {
+				return subErr
+			}
+		}
+
+		after := namespaceState(ns.Namespace)
+		after["cloned_from"] = src.ID
+		if auditErr := auditResourceChange(ctx, tx, "CloneNamespace", model.ResourceNamespace, ns.ID, nil, after); auditErr != nil {
+			return auditErr
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns.Namespace)); pubErr != nil {
+			return pubErr
+		}
+
+		changedPerms := append(copiedPerms, materializedPerms...)
+		changedPerms = append(changedPerms, model.Permission{UserID: userID})
+		if updErr := updatePermissionsUsers(ctx, s.clients.Auth, tx, changedPerms); updErr != nil {
+			return updErr
+		}
+
+		return completeOperation(ctx, tx, op)
+	})
+
+	return s.finishOperation(ctx, op, err)
+}
+
+// restorePeriodCheck checks that namespace was deleted within retention period.
+func restorePeriodCheck(ns model.Namespace, retention time.Duration) error {
+	if ns.DeleteTime == nil || time.Since(*ns.DeleteTime) > retention {
+		return errors.ErrRestorePeriodExpired().AddDetailF("namespace %s can be restored only within %s after delete", ns.Label, retention)
+	}
+	return nil
+}
+
+func (s *Server) RestoreNamespace(ctx context.Context, id string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("restore namespace")
+
+	var op *model.Operation
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.DeletedNamespaceByName(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		// permission of user was removed with namespace, so role is taken from its tombstone
+		perm, getErr := tx.DeletedNamespacePermission(ctx, ns, userID)
+		if getErr != nil {
+			return getErr
+		}
+
+		deletedNS := model.NamespaceWithPermissions{Namespace: ns, Permission: model.EffectivePermission{Permission: perm}}
+		if chkErr := NamespaceActionCheck(ctx, tx, deletedNS, model.ActionNamespaceRestore); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := restorePeriodCheck(ns, s.cfg.NamespaceRetention); chkErr != nil {
+			return chkErr
+		}
+
+		// unfinished cleanup would delete restored namespace data
+		cleanupRunning, chkErr := tx.NamespaceCleanupRunning(ctx, ns)
+		if chkErr != nil {
+			return chkErr
+		}
+		if cleanupRunning {
+			return errors.ErrCleanupNotFinished().AddDetailF("namespace %s can be restored after its cleanup is finished", ns.Label)
+		}
+
+		if chkErr := checkUserLimits(ctx, tx, s.cfg.UserLimits, ns.OwnerUserID, namespaceResources(ns)); chkErr != nil {
+			return chkErr
+		}
+
+		// volumes deleted with namespace are found by its delete time, so they are restored first
+		volumes, restoreErr := tx.RestoreNamespaceVolumes(ctx, ns)
+		if restoreErr != nil {
+			return restoreErr
+		}
+
+		if restoreErr := tx.RestoreNamespace(ctx, &ns); restoreErr != nil {
+			return restoreErr
+		}
+
+		nsWithPermissions := model.NamespaceWithPermissions{Namespace: ns}
+		if permErr := tx.NamespacePermissions(ctx, &nsWithPermissions); permErr != nil {
+			return permErr
+		}
+
+		steps := []string{stepKubeCreateNamespace}
+		if ns.TariffID != nil {
+			steps = append(steps, stepBillingSubscribe)
+		}
+		if len(volumes) > 0 {
+			steps = append(steps, stepVolumeCreateVolumes, stepBillingSubscribeVolumes)
+		}
+
+		var opErr error
+		op, opErr = s.startOperation(ctx, model.OperationNamespaceRestore, model.ResourceNamespace, ns.ID,
+			model.OperationData{Namespace: &ns, Volumes: volumes}, steps...)
+		if opErr != nil {
+			return opErr
+		}
+
+		if createErr := s.runOperationStep(ctx, op, stepKubeCreateNamespace, func() error {
+			return s.clients.Kube.CreateNamespace(ctx, nsWithPermissions.ToKube())
+		}); createErr != nil {
+			return createErr
+		}
+
+		if ns.TariffID != nil {
+			if subErr := s.runOperationStep(ctx, op, stepBillingSubscribe, func() error {
+				return s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
+					TariffID:      *ns.TariffID,
+					ResourceType:  billing.Namespace,
+					ResourceLabel: ns.Label,
+					ResourceID:    ns.KubeName,
+				})
+			}); subErr != nil {
+				return subErr
+			}
+		}
+
+		if len(volumes) > 0 {
+			if createErr := s.runOperationStep(ctx, op, stepVolumeCreateVolumes, func() error {
+				for _, v := range volumes {
+					if err := s.clients.Volume.CreateVolume(ctx, ns.KubeName, v.Label, v.Capacity); err != nil {
+						return err
+					}
 				}
-				return err
-			})
-			if ownerErr != nil {
-				s.log.Warningln("Unable to add owner:", ownerErr)
+				return nil
+			}); createErr != nil {
+				return createErr
+			}
+
+			if subErr := s.runOperationStep(ctx, op, stepBillingSubscribeVolumes, func() error {
+				for _, v := range volumes {
+					if v.TariffID == nil {
+						continue
+					}
+					if err := s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
+						TariffID:      *v.TariffID,
+						ResourceType:  billing.Volume,
+						ResourceLabel: v.Label,
+						ResourceID:    v.ID,
+					}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); subErr != nil {
+				return subErr
+			}
+		}
+
+		users := map[string]bool{ns.OwnerUserID: true}
+		for _, v := range nsWithPermissions.Permissions {
+			users[v.UserID] = true
+		}
+
+		for user := range users {
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user); updErr != nil {
+				return updErr
 			}
 		}
+
+		if auditErr := auditResourceChange(ctx, tx, "RestoreNamespace", model.ResourceNamespace, ns.ID, nil, namespaceState(ns)); auditErr != nil {
+			return auditErr
+		}
+
+		for _, v := range volumes {
+			if auditErr := auditResourceChange(ctx, tx, "RestoreNamespace", model.ResourceVolume, v.ID, nil, volumeState(v)); auditErr != nil {
+				return auditErr
+			}
+		}
+
+		if pubErr := publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, ns.ID, namespaceEventPayload(ns)); pubErr != nil {
+			return pubErr
+		}
+
+		return completeOperation(ctx, tx, op)
+	})
+
+	return s.finishOperation(ctx, op, err)
+}
+
+// PurgeTombstones permanently removes permissions of resources deleted before retention period.
+func (s *Server) PurgeTombstones(ctx context.Context) error {
+	deletedBefore := time.Now().Add(-s.cfg.NamespaceRetention)
+	s.log.WithField("deleted_before", deletedBefore).Debugf("purge tombstones")
+
+	purged, err := s.db.PurgeTombstones(ctx, deletedBefore)
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		s.log.Infof("purged %d tombstones", purged)
+	}
+
+	return nil
+}
+
+func (s *Server) AddGroupNamespace(ctx context.Context, namespace string, req model.ProjectAddGroupRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"group_id":  req.GroupID,
+		"namespace": namespace,
+		"access":    req.AccessLevel,
+	}).Info("add group")
+
+	accessLevel, err := groupAccessLevel(req)
+	if err != nil {
+		return err
 	}
 
-	err = s.db.Transactional(func(tx database.DB) error {
-		return tx.SetNamespacesAccesses(ctx, []model.Namespace{ns.Namespace}, accessList)
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionGroupManage); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := groupGrantState(ctx, tx, model.ResourceNamespace, ns.ID, req.GroupID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if syncErr := syncGroupMembers(ctx, s.clients.User, tx, req.GroupID, s.cfg.GroupRoleMapping); syncErr != nil {
+			return syncErr
+		}
+
+		if setErr := tx.SetGroupPermission(ctx, &model.GroupPermission{
+			ResourceType: model.ResourceNamespace,
+			ResourceID:   ns.ID,
+			GroupID:      req.GroupID,
+			AccessLevel:  accessLevel,
+			RoleMapping:  req.RoleMapping,
+		}); setErr != nil {
+			return setErr
+		}
+
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceNamespace, ns.ID); applyErr != nil {
+			return applyErr
+		}
+
+		return auditGroupChange(ctx, tx, "AddGroupNamespace", model.ResourceNamespace, ns.ID, req.GroupID, before)
 	})
 
 	return err
@@ -613,25 +1300,51 @@ func (s *Server) SetGroupMemberNamespaceAccess(ctx context.Context, namespace, g
 		"access":    req.AccessLevel,
 	}).Infof("set group member access")
 
-	err := s.db.Transactional(func(tx database.DB) error {
-		ns, err := s.db.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+	if chkErr := checkExpiration(req.ExpiresAt); chkErr != nil {
+		return chkErr
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, err := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
 		if err != nil {
 			return err
 		}
 
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionGroupManage); chkErr != nil {
+			return chkErr
+		}
+
 		user, getErr := s.clients.User.UserInfoByLogin(ctx, req.Username)
 		if getErr != nil {
 			return getErr
 		}
 
+		if user.ID == ns.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, ns.OrganizationID, user.ID); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceNamespace, ns.ID, user.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		// direct permission overrides access given by group
 		accesses := []database.AccessListElement{
-			{ToUserID: user.ID, AccessLevel: req.AccessLevel},
+			{ToUserID: user.ID, AccessLevel: req.AccessLevel, ExpiresAt: req.ExpiresAt},
 		}
 		if setErr := tx.SetNamespacesAccesses(ctx, []model.Namespace{ns.Namespace}, accesses); setErr != nil {
 			return setErr
 		}
 
-		return updateUserAccesses(ctx, s.clients.Auth, s.db, user.ID)
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "SetGroupMemberNamespaceAccess", model.ResourceNamespace, ns.ID, user.ID, before)
 	})
 
 	return err
@@ -642,59 +1355,55 @@ func (s *Server) GetNamespaceGroups(ctx context.Context, namespace string) ([]ku
 	s.log.WithFields(logrus.Fields{
 		"namespace": namespace,
 		"user_id":   userID,
-	}).Infof("get project groups")
+	}).Infof("get namespace groups")
 
 	ns, err := s.db.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.db.NamespacePermissions(ctx, &ns); err != nil {
-		return nil, err
-	}
-
-	var groupIDs []string
-	for _, v := range ns.Permissions {
-		if v.GroupID != nil {
-			groupIDs = append(groupIDs, *v.GroupID)
-		}
-	}
-
-	if len(groupIDs) == 0 {
-		return []kubeClientModel.UserGroup{}, nil
-	}
-
-	groups, err := s.clients.User.GroupFullIDList(ctx, groupIDs...)
+	perms, err := s.db.ResourceGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return groups.Groups, nil
+	return groupsByPermissions(ctx, s.clients.User, perms)
 }
 
 func (s *Server) DeleteGroupFromNamespace(ctx context.Context, namespace, groupID string) error {
+	userID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
 		"namespace": namespace,
 		"group":     groupID,
-	}).Infof("delete group from project")
+		"user_id":   userID,
+	}).Infof("delete group from namespace")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionGroupManage); chkErr != nil {
+			return chkErr
+		}
 
-	err := s.db.Transactional(func(tx database.DB) error {
-		delPerms, delErr := tx.DeleteGroupFromNamespace(ctx, namespace, groupID)
+		before, getErr := groupGrantState(ctx, tx, model.ResourceNamespace, ns.ID, groupID)
+		if getErr != nil {
+			return getErr
+		}
+
+		delPerms, delErr := tx.DeleteGroupFromNamespace(ctx, ns.Namespace, groupID)
 		if delErr != nil {
 			return delErr
 		}
-		users := make(map[string]bool)
-		for _, v := range delPerms {
-			users[v.UserID] = true
-		}
 
-		for user := range users {
-			if updErr := updateUserAccesses(ctx, s.clients.Auth, s.db, user); updErr != nil {
-				s.log.WithError(updErr).Warnf("update access failed for user %s", user)
-			}
+		// members may still have access through other groups
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceNamespace, ns.ID, delPerms...); applyErr != nil {
+			return applyErr
 		}
 
-		return nil
+		return auditGroupChange(ctx, tx, "DeleteGroupFromNamespace", model.ResourceNamespace, ns.ID, groupID, before)
 	})
 
 	return err
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	billing "github.com/containerum/bill-external/models"
+	"github.com/containerum/cherry"
+	"github.com/containerum/utils/httputil"
+)
+
+// transferTestBilling records subscription changes with resource and user made them.
+type transferTestBilling struct {
+	clients.BillingClient
+	*operationTestCalls
+}
+
+func (b transferTestBilling) Unsubscribe(ctx context.Context, resourceID string) error {
+	return b.call(fmt.Sprintf("unsubscribe %s %s", resourceID, httputil.RequestHeaders(ctx).Get(httputil.UserIDXHeader)))
+}
+
+func (b transferTestBilling) Subscribe(ctx context.Context, req billing.SubscribeTariffRequest) error {
+	return b.call(fmt.Sprintf("subscribe %s %s", req.ResourceID, httputil.RequestHeaders(ctx).Get(httputil.UserIDXHeader)))
+}
+
+func TestRestorePeriodCheck(t *testing.T) {
+	retention := 24 * time.Hour
+	now := time.Now()
+	withinRetention := now.Add(-23 * time.Hour)
+	afterRetention := now.Add(-25 * time.Hour)
+
+	for _, tc := range []struct {
+		name      string
+		deletedAt *time.Time
+		err       bool
+	}{
+		{name: "not deleted", err: true},
+		{name: "just deleted", deletedAt: &now},
+		{name: "within retention", deletedAt: &withinRetention},
+		{name: "retention expired", deletedAt: &afterRetention, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ns := model.Namespace{Resource: model.Resource{DeleteTime: tc.deletedAt, Label: "ns"}}
+			err := restorePeriodCheck(ns, retention)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+			if err != nil && !cherry.Equals(err, errors.ErrRestorePeriodExpired()) {
+				t.Errorf("expected restore period expired error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransferSubscriptions(t *testing.T) {
+	tariffID := "tariff"
+	createTime := time.Now()
+	oldNS := model.Namespace{Resource: model.Resource{CreateTime: &createTime, OwnerUserID: "old"}, KubeName: "ns", TariffID: &tariffID}
+	ns := oldNS
+	ns.OwnerUserID = "new"
+	volumes := []model.Volume{
+		{Resource: model.Resource{ID: "vol1"}, TariffID: &tariffID},
+		{Resource: model.Resource{ID: "vol2"}},
+		{Resource: model.Resource{ID: "vol3"}, TariffID: &tariffID},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		steps  []model.OperationStepStatus
+		errors map[string]error
+		calls  []string
+		status model.OperationStatus
+		err    bool
+	}{
+		{
+			name:   "transferred",
+			steps:  []model.OperationStepStatus{model.OperationStepDone, model.OperationStepDone},
+			calls:  []string{"unsubscribe ns old", "subscribe ns new", "unsubscribe vol1 old", "subscribe vol1 new", "unsubscribe vol3 old", "subscribe vol3 new"},
+			status: model.OperationRunning,
+		},
+		{
+			name:   "unsubscribed resource not found",
+			errors: map[string]error{"unsubscribe vol1 old": errors.ErrResourceNotExists()},
+			steps:  []model.OperationStepStatus{model.OperationStepDone, model.OperationStepDone},
+			calls:  []string{"unsubscribe ns old", "subscribe ns new", "unsubscribe vol1 old", "subscribe vol1 new", "unsubscribe vol3 old", "subscribe vol3 new"},
+			status: model.OperationRunning,
+		},
+		{
+			name:   "namespace unsubscribe failed",
+			errors: map[string]error{"unsubscribe ns old": fmt.Errorf("billing unavailable")},
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepPending},
+			calls:  []string{"unsubscribe ns old", "unsubscribe ns new", "subscribe ns old"},
+			status: model.OperationCompensated,
+			err:    true,
+		},
+		{
+			name:   "volume subscribe failed",
+			errors: map[string]error{"subscribe vol3 new": fmt.Errorf("billing unavailable")},
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepCompensated},
+			calls: []string{
+				"unsubscribe ns old", "subscribe ns new", "unsubscribe vol1 old", "subscribe vol1 new", "unsubscribe vol3 old", "subscribe vol3 new",
+				"unsubscribe vol1 new", "subscribe vol1 old", "unsubscribe vol3 new", "subscribe vol3 old",
+				"unsubscribe ns new", "subscribe ns old",
+			},
+			status: model.OperationCompensated,
+			err:    true,
+		},
+		{
+			name: "compensation failed",
+			errors: map[string]error{
+				"subscribe vol1 new": fmt.Errorf("billing unavailable"),
+				"subscribe vol1 old": fmt.Errorf("billing unavailable"),
+			},
+			steps: []model.OperationStepStatus{model.OperationStepDone, model.OperationStepFailed},
+			calls: []string{
+				"unsubscribe ns old", "subscribe ns new", "unsubscribe vol1 old", "subscribe vol1 new",
+				"unsubscribe vol1 new", "subscribe vol1 old",
+			},
+			status: model.OperationCompensating,
+			err:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := &operationTestCalls{errors: tc.errors}
+			s := newOperationTestServer(operationTestDB{}, calls)
+			s.clients.Billing = transferTestBilling{operationTestCalls: calls}
+
+			ctx := context.WithValue(context.Background(), httputil.UserIDContextKey, "old")
+			op := newOperation(ctx, model.OperationNamespaceTransfer, model.ResourceNamespace, ns.ID,
+				model.OperationData{Namespace: &ns, OldNamespace: &oldNS, Volumes: volumes},
+				stepBillingTransferNamespace, stepBillingTransferVolumes)
+
+			err := s.runOperationStep(ctx, op, stepBillingTransferNamespace, func() error {
+				return s.transferSubscriptions(ctx, namespaceSubscription(oldNS), oldNS.OwnerUserID, ns.OwnerUserID)
+			})
+			if err == nil {
+				err = s.runOperationStep(ctx, op, stepBillingTransferVolumes, func() error {
+					return s.transferSubscriptions(ctx, volumeSubscriptions(volumes), oldNS.OwnerUserID, ns.OwnerUserID)
+				})
+			}
+			err = s.finishOperation(ctx, op, err)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(calls.calls, tc.calls) {
+				t.Errorf("expected calls %v, got %v", tc.calls, calls.calls)
+			}
+			if op.Status != tc.status {
+				t.Errorf("expected status %s, got %s", tc.status, op.Status)
+			}
+			for i, step := range op.Steps {
+				if step.Status != tc.steps[i] {
+					t.Errorf("expected step %s status %s, got %s", step.Name, tc.steps[i], step.Status)
+				}
+			}
+		})
+	}
+}
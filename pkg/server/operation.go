@@ -0,0 +1,389 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+// Steps of operations making changes in other services
+const (
+	stepKubeCreateNamespace       = "kube_create_namespace"
+	stepBillingSubscribe          = "billing_subscribe"
+	stepKubeSetQuota              = "kube_set_quota"
+	stepBillingUpdateSubscription = "billing_update_subscription"
+	stepDeleteEphemeralVolumes    = "delete_ephemeral_volumes"
+	stepVolumeCreateVolumes       = "volume_create_volumes"
+	stepBillingSubscribeVolumes   = "billing_subscribe_volumes"
+	stepBillingTransferNamespace  = "billing_transfer_namespace"
+	stepBillingTransferVolumes    = "billing_transfer_volumes"
+)
+
+// operationsRecoveryBatch is a max number of stale operations claimed by recovery job at once
+const operationsRecoveryBatch = 100
+
+type OperationActions interface {
+	GetOperation(ctx context.Context, id string) (model.Operation, error)
+}
+
+// newOperation returns running operation with pending steps.
+func newOperation(ctx context.Context, opType model.OperationType, kind model.ResourceType, resourceID string, data model.OperationData, steps ...string) *model.Operation {
+	op := &model.Operation{
+		Type:         opType,
+		ResourceType: kind,
+		ResourceID:   resourceID,
+		UserID:       httputil.MustGetUserID(ctx),
+		Status:       model.OperationRunning,
+		Steps:        make([]model.OperationStep, len(steps)),
+		Data:         data,
+	}
+	op.RequestID, _ = ctx.Value(httputil.RequestIDContextKey).(string)
+	for i, name := range steps {
+		op.Steps[i] = model.OperationStep{Name: name, Status: model.OperationStepPending}
+	}
+	return op
+}
+
+// startOperation saves new operation with pending steps.
+// Operation must be started before first step and completed in transaction making database changes.
+// Operations of dry run requests are not saved because their steps make no changes.
+func (s *Server) startOperation(ctx context.Context, opType model.OperationType, kind model.ResourceType, resourceID string, data model.OperationData, steps ...string) (*model.Operation, error) {
+	op := newOperation(ctx, opType, kind, resourceID, data, steps...)
+	if dryrun.FromContext(ctx) != nil {
+		return op, nil
+	}
+
+	return op, s.db.CreateOperation(ctx, op)
+}
+
+// saveOperation saves operation state. Error is returned if operation status was changed by other executor.
+func saveOperation(ctx context.Context, db database.DB, op *model.Operation, expectedStatus model.OperationStatus) error {
+	if dryrun.FromContext(ctx) != nil {
+		return nil
+	}
+
+	updated, err := db.UpdateOperation(ctx, op, expectedStatus)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.ErrOperationAborted().AddDetailF("operation %s is not %s", op.ID, expectedStatus)
+	}
+
+	return nil
+}
+
+// runOperationStep calls step action saving step state before and after call.
+// State is saved outside of transaction so it survives rollback and crash of service.
+func (s *Server) runOperationStep(ctx context.Context, op *model.Operation, name string, action func() error) error {
+	step := op.Step(name)
+
+	step.Status = model.OperationStepStarted
+	if err := saveOperation(ctx, s.db, op, model.OperationRunning); err != nil {
+		return err
+	}
+
+	if err := action(); err != nil {
+		step.Status = model.OperationStepFailed
+		step.Error = err.Error()
+		if saveErr := saveOperation(ctx, s.db, op, model.OperationRunning); saveErr != nil {
+			s.log.WithError(saveErr).WithField("operation_id", op.ID).Warnf("failed step state not saved")
+		}
+		return err
+	}
+
+	step.Status = model.OperationStepDone
+	return saveOperation(ctx, s.db, op, model.OperationRunning)
+}
+
+// completeOperation marks operation completed. Must be called last in transaction making database changes,
+// so operation is completed only if changes are committed and compensated otherwise.
+func completeOperation(ctx context.Context, tx database.DB, op *model.Operation) error {
+	op.Status = model.OperationCompleted
+	return saveOperation(ctx, tx, op, model.OperationRunning)
+}
+
+// finishOperation compensates operation if transaction failed and returns transaction error.
+// Compensation failed here is retried by recovery job.
+func (s *Server) finishOperation(ctx context.Context, op *model.Operation, err error) error {
+	if op == nil || err == nil || dryrun.FromContext(ctx) != nil {
+		return err
+	}
+
+	log := s.log.WithField("operation_id", op.ID)
+
+	op.Status = model.OperationCompensating
+	if claimErr := saveOperation(ctx, s.db, op, model.OperationRunning); claimErr != nil {
+		// operation is already taken by recovery job
+		log.WithError(claimErr).Warnf("operation not compensated")
+		return err
+	}
+
+	if compErr := s.compensateOperation(ctx, op); compErr != nil {
+		log.WithError(compErr).Errorf("operation compensation failed")
+	}
+
+	return err
+}
+
+// operationStepCompensation returns action reverting change made by operation step or nil if step can not be reverted.
+func (s *Server) operationStepCompensation(op *model.Operation, name string) func(ctx context.Context) error {
+	ns, oldNS := op.Data.Namespace, op.Data.OldNamespace
+	switch name {
+	case stepKubeCreateNamespace:
+		return func(ctx context.Context) error {
+			return s.clients.Kube.DeleteNamespace(ctx, kubeClientModel.Namespace{ID: ns.KubeName})
+		}
+	case stepBillingSubscribe:
+		return func(ctx context.Context) error {
+			return s.clients.Billing.Unsubscribe(ctx, ns.KubeName)
+		}
+	case stepKubeSetQuota:
+		return func(ctx context.Context) error {
+			kubeNS := (&model.NamespaceWithPermissions{Namespace: *oldNS}).ToKube()
+			return s.clients.Kube.SetNamespaceQuota(ctx, kubeNS)
+		}
+	case stepVolumeCreateVolumes:
+		return func(ctx context.Context) error {
+			return s.clients.Volume.DeleteNamespaceVolumes(ctx, ns.KubeName)
+		}
+	case stepBillingSubscribeVolumes:
+		var ids []string
+		for _, v := range op.Data.Volumes {
+			ids = append(ids, v.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return func(ctx context.Context) error {
+			return s.clients.Billing.MassiveUnsubscribe(ctx, ids)
+		}
+	case stepBillingTransferNamespace:
+		return func(ctx context.Context) error {
+			return s.transferSubscriptions(ctx, namespaceSubscription(*oldNS), ns.OwnerUserID, oldNS.OwnerUserID)
+		}
+	case stepBillingTransferVolumes:
+		return func(ctx context.Context) error {
+			return s.transferSubscriptions(ctx, volumeSubscriptions(op.Data.Volumes), ns.OwnerUserID, oldNS.OwnerUserID)
+		}
+	case stepBillingUpdateSubscription:
+		if oldNS.TariffID == nil {
+			return nil
+		}
+		return func(ctx context.Context) error {
+			return s.clients.Billing.UpdateSubscription(ctx, ns.KubeName, *oldNS.TariffID)
+		}
+	default:
+		return nil
+	}
+}
+
+// compensateOperation reverts changes made by operation steps in reverse order. Operation must be in compensating status.
+// Started or failed step may have made no change, so not found error of its compensation is ignored.
+func (s *Server) compensateOperation(ctx context.Context, op *model.Operation) error {
+	s.log.WithFields(logrus.Fields{
+		"operation_id": op.ID,
+		"type":         op.Type,
+		"resource_id":  op.ResourceID,
+	}).Infof("compensate operation")
+
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		step := &op.Steps[i]
+		switch step.Status {
+		case model.OperationStepStarted, model.OperationStepDone, model.OperationStepFailed:
+		default:
+			continue
+		}
+
+		compensate := s.operationStepCompensation(op, step.Name)
+		if compensate == nil {
+			continue
+		}
+
+		if err := compensate(ctx); err != nil && (step.Status == model.OperationStepDone || !isNotFound(err)) {
+			op.Attempts++
+			op.LastError = fmt.Sprintf("%s: %v", step.Name, err)
+			step.Error = err.Error()
+			if saveErr := saveOperation(ctx, s.db, op, model.OperationCompensating); saveErr != nil {
+				s.log.WithError(saveErr).WithField("operation_id", op.ID).Warnf("compensation error not saved")
+			}
+			return err
+		}
+
+		step.Status = model.OperationStepCompensated
+		if err := saveOperation(ctx, s.db, op, model.OperationCompensating); err != nil {
+			return err
+		}
+	}
+
+	op.Status = model.OperationCompensated
+	return saveOperation(ctx, s.db, op, model.OperationCompensating)
+}
+
+// operationContext returns context of recovered operation acting on behalf of user started it.
+func operationContext(ctx context.Context, op *model.Operation) context.Context {
+	ctx = context.WithValue(serviceContext(ctx, op.UserID), httputil.UserIDContextKey, op.UserID)
+	return context.WithValue(ctx, httputil.RequestIDContextKey, op.RequestID)
+}
+
+// operationResumption returns action applying database changes of abandoned operation with all steps done
+// or nil if operation data is not enough to repeat its changes.
+func (s *Server) operationResumption(op *model.Operation) func(ctx context.Context, tx database.DB) error {
+	ns, oldNS := op.Data.Namespace, op.Data.OldNamespace
+	switch op.Type {
+	case model.OperationNamespaceCreate:
+		return func(ctx context.Context, tx database.DB) error {
+			created := *ns
+			if err := checkUserLimits(ctx, tx, s.cfg.UserLimits, created.OwnerUserID, namespaceResources(created)); err != nil {
+				return err
+			}
+
+			if err := tx.CreateNamespace(ctx, &created); err != nil {
+				return err
+			}
+
+			if err := updateUserAccesses(ctx, s.clients.Auth, tx, created.OwnerUserID); err != nil {
+				return err
+			}
+
+			if err := auditResourceChange(ctx, tx, "CreateNamespace", model.ResourceNamespace, created.ID, nil, namespaceState(created)); err != nil {
+				return err
+			}
+
+			return publishEvent(ctx, tx, model.EventNamespaceCreated, model.ResourceNamespace, created.ID, namespaceEventPayload(created))
+		}
+	case model.OperationNamespaceResize:
+		return func(ctx context.Context, tx database.DB) error {
+			current, err := tx.NamespaceByName(ctx, op.UserID, ns.KubeName, true)
+			if err != nil {
+				return err
+			}
+
+			// quota changed by other request would be overwritten
+			if !reflect.DeepEqual(quotaState(current.Namespace), quotaState(*oldNS)) {
+				return fmt.Errorf("namespace %s changed after operation start", ns.ID)
+			}
+
+			if err := tx.ResizeNamespace(ctx, *ns); err != nil {
+				return err
+			}
+
+			if err := auditResourceChange(ctx, tx, "ResizeNamespace", model.ResourceNamespace, ns.ID, quotaState(*oldNS), quotaState(*ns)); err != nil {
+				return err
+			}
+
+			return publishEvent(ctx, tx, model.EventNamespaceResized, model.ResourceNamespace, ns.ID, model.EventPayload{"before": quotaState(*oldNS), "after": quotaState(*ns)})
+		}
+	default:
+		return nil
+	}
+}
+
+// resumeOperation applies database changes of abandoned operation and completes it.
+func (s *Server) resumeOperation(ctx context.Context, op *model.Operation, resume func(ctx context.Context, tx database.DB) error) error {
+	s.log.WithFields(logrus.Fields{
+		"operation_id": op.ID,
+		"type":         op.Type,
+		"resource_id":  op.ResourceID,
+	}).Infof("resume operation")
+
+	ctx = operationContext(ctx, op)
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		if err := resume(ctx, tx); err != nil {
+			return err
+		}
+		return completeOperation(ctx, tx, op)
+	})
+	if err != nil {
+		// status set by completeOperation is rolled back with transaction
+		op.Status = model.OperationRunning
+	}
+	return err
+}
+
+// recoverOperation resumes abandoned operation with all steps done if possible and compensates it otherwise.
+// Unfinished cleanup is always resumed.
+func (s *Server) recoverOperation(ctx context.Context, op *model.Operation) error {
+	if op.Type.IsCleanup() {
+		return s.runCleanup(cleanupContext(ctx, op), op)
+	}
+
+	if op.Status == model.OperationRunning {
+		if resume := s.operationResumption(op); resume != nil && op.StepsDone() {
+			resumeErr := s.resumeOperation(ctx, op, resume)
+			if resumeErr == nil {
+				return nil
+			}
+			s.log.WithError(resumeErr).WithField("operation_id", op.ID).Warnf("operation not resumed, compensating")
+		}
+
+		op.Status = model.OperationCompensating
+		if err := saveOperation(ctx, s.db, op, model.OperationRunning); err != nil {
+			return err
+		}
+	}
+
+	return s.compensateOperation(ctx, op)
+}
+
+// RecoverOperations resumes or compensates operations abandoned by crashed service instances, retries failed compensations,
+// resumes unfinished cleanups and deletes old finished operations.
+func (s *Server) RecoverOperations(ctx context.Context) error {
+	if s.cfg.OperationsRetention > 0 {
+		deleted, err := s.db.DeleteFinishedOperations(ctx, time.Now().Add(-s.cfg.OperationsRetention))
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			s.log.WithField("deleted", deleted).Infof("finished operations deleted")
+		}
+	}
+
+	for {
+		// claimed operations are not taken again until lease timeout even if recovery fails
+		ops, err := s.db.ClaimStaleOperations(ctx, time.Now().Add(-s.cfg.OperationsLeaseTimeout), operationsRecoveryBatch)
+		if err != nil {
+			return err
+		}
+
+		for i := range ops {
+			if recErr := s.recoverOperation(ctx, &ops[i]); recErr != nil {
+				s.log.WithError(recErr).WithField("operation_id", ops[i].ID).Errorf("operation recovery failed")
+			}
+		}
+
+		if len(ops) < operationsRecoveryBatch {
+			return nil
+		}
+	}
+}
+
+func (s *Server) GetOperation(ctx context.Context, id string) (model.Operation, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get operation")
+
+	op, err := s.db.OperationByID(ctx, id)
+	if err != nil {
+		return model.Operation{}, err
+	}
+
+	// owner of deleted namespaces can see cleanup started by other user
+	isCleanupOwner := op.Data.Cleanup != nil && op.Data.Cleanup.UserID == userID
+	if op.UserID != userID && !isCleanupOwner && !IsAdminRole(ctx) {
+		return model.Operation{}, errors.ErrResourceNotExists().AddDetailF("operation %s not exists", id)
+	}
+
+	return op, nil
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	billing "github.com/containerum/bill-external/models"
+	"github.com/containerum/cherry/adaptors/cherrylog"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/sirupsen/logrus"
+)
+
+// operationTestDB accepts any operation update, other methods are not implemented.
+type operationTestDB struct {
+	database.DB
+}
+
+func (db operationTestDB) UpdateOperation(ctx context.Context, op *model.Operation, expectedStatus model.OperationStatus) (bool, error) {
+	return true, nil
+}
+
+// operationTestCalls records calls of external services and returns configured errors.
+type operationTestCalls struct {
+	mu     sync.Mutex
+	calls  []string
+	errors map[string]error
+}
+
+func (c *operationTestCalls) call(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, name)
+	return c.errors[name]
+}
+
+type operationTestKube struct {
+	clients.KubeAPIClient
+	*operationTestCalls
+}
+
+func (k operationTestKube) DeleteNamespace(ctx context.Context, ns kubeClientModel.Namespace) error {
+	return k.call("kube.DeleteNamespace")
+}
+
+func (k operationTestKube) SetNamespaceQuota(ctx context.Context, ns kubeClientModel.Namespace) error {
+	return k.call("kube.SetNamespaceQuota")
+}
+
+type operationTestBilling struct {
+	clients.BillingClient
+	*operationTestCalls
+}
+
+func (b operationTestBilling) Unsubscribe(ctx context.Context, resourceID string) error {
+	return b.call("billing.Unsubscribe")
+}
+
+func (b operationTestBilling) UpdateSubscription(ctx context.Context, resourceID, newTariffID string) error {
+	return b.call("billing.UpdateSubscription")
+}
+
+func (b operationTestBilling) MassiveUnsubscribe(ctx context.Context, resourceIDs []string) error {
+	return b.call("billing.MassiveUnsubscribe")
+}
+
+func (b operationTestBilling) Subscribe(ctx context.Context, req billing.SubscribeTariffRequest) error {
+	return b.call("billing.Subscribe")
+}
+
+func newOperationTestServer(db database.DB, calls *operationTestCalls) *Server {
+	return &Server{
+		db:  db,
+		log: cherrylog.NewLogrusAdapter(logrus.WithField("component", "test")),
+		clients: &Clients{
+			Kube:    operationTestKube{operationTestCalls: calls},
+			Billing: operationTestBilling{operationTestCalls: calls},
+		},
+	}
+}
+
+func TestCompensateOperation(t *testing.T) {
+	oldTariffID := "old-tariff"
+	createTime := time.Now()
+	ns := &model.Namespace{Resource: model.Resource{CreateTime: &createTime}, KubeName: "ns"}
+	oldNS := &model.Namespace{Resource: model.Resource{CreateTime: &createTime}, KubeName: "ns", TariffID: &oldTariffID}
+
+	steps := func(statuses ...model.OperationStepStatus) []model.OperationStep {
+		names := []string{stepKubeCreateNamespace, stepBillingSubscribe}
+		ret := make([]model.OperationStep, len(statuses))
+		for i, status := range statuses {
+			ret[i] = model.OperationStep{Name: names[i], Status: status}
+		}
+		return ret
+	}
+
+	for _, tc := range []struct {
+		name     string
+		op       model.Operation
+		errors   map[string]error
+		calls    []string
+		status   model.OperationStatus
+		steps    []model.OperationStepStatus
+		attempts int
+		err      bool
+	}{
+		{
+			name:   "reverse order",
+			op:     model.Operation{Steps: steps(model.OperationStepDone, model.OperationStepDone)},
+			calls:  []string{"billing.Unsubscribe", "kube.DeleteNamespace"},
+			status: model.OperationCompensated,
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepCompensated},
+		},
+		{
+			name:   "pending step skipped",
+			op:     model.Operation{Steps: steps(model.OperationStepDone, model.OperationStepPending)},
+			calls:  []string{"kube.DeleteNamespace"},
+			status: model.OperationCompensated,
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepPending},
+		},
+		{
+			name:   "started step not found",
+			op:     model.Operation{Steps: steps(model.OperationStepDone, model.OperationStepStarted)},
+			errors: map[string]error{"billing.Unsubscribe": errors.ErrResourceNotExists()},
+			calls:  []string{"billing.Unsubscribe", "kube.DeleteNamespace"},
+			status: model.OperationCompensated,
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepCompensated},
+		},
+		{
+			name:     "done step not found",
+			op:       model.Operation{Steps: steps(model.OperationStepDone, model.OperationStepDone)},
+			errors:   map[string]error{"billing.Unsubscribe": errors.ErrResourceNotExists()},
+			calls:    []string{"billing.Unsubscribe"},
+			status:   model.OperationCompensating,
+			steps:    []model.OperationStepStatus{model.OperationStepDone, model.OperationStepDone},
+			attempts: 1,
+			err:      true,
+		},
+		{
+			name:     "failed compensation stops",
+			op:       model.Operation{Steps: steps(model.OperationStepDone, model.OperationStepFailed)},
+			errors:   map[string]error{"billing.Unsubscribe": fmt.Errorf("billing unavailable")},
+			calls:    []string{"billing.Unsubscribe"},
+			status:   model.OperationCompensating,
+			steps:    []model.OperationStepStatus{model.OperationStepDone, model.OperationStepFailed},
+			attempts: 1,
+			err:      true,
+		},
+		{
+			name: "compensated step skipped",
+			op: model.Operation{Steps: []model.OperationStep{
+				{Name: stepKubeCreateNamespace, Status: model.OperationStepDone},
+				{Name: stepBillingSubscribe, Status: model.OperationStepCompensated},
+			}},
+			calls:  []string{"kube.DeleteNamespace"},
+			status: model.OperationCompensated,
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepCompensated},
+		},
+		{
+			name: "step without compensation",
+			op: model.Operation{Steps: []model.OperationStep{
+				{Name: stepKubeSetQuota, Status: model.OperationStepDone},
+				{Name: stepDeleteEphemeralVolumes, Status: model.OperationStepDone},
+				{Name: stepBillingUpdateSubscription, Status: model.OperationStepDone},
+			}},
+			calls:  []string{"billing.UpdateSubscription", "kube.SetNamespaceQuota"},
+			status: model.OperationCompensated,
+			steps:  []model.OperationStepStatus{model.OperationStepCompensated, model.OperationStepDone, model.OperationStepCompensated},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := &operationTestCalls{errors: tc.errors}
+			s := newOperationTestServer(operationTestDB{}, calls)
+
+			op := tc.op
+			op.ID = "op"
+			op.Status = model.OperationCompensating
+			op.Data = model.OperationData{Namespace: ns, OldNamespace: oldNS}
+
+			err := s.compensateOperation(context.Background(), &op)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(calls.calls, tc.calls) {
+				t.Errorf("expected calls %v, got %v", tc.calls, calls.calls)
+			}
+			if op.Status != tc.status {
+				t.Errorf("expected status %s, got %s", tc.status, op.Status)
+			}
+			for i, step := range op.Steps {
+				if step.Status != tc.steps[i] {
+					t.Errorf("expected step %s status %s, got %s", step.Name, tc.steps[i], step.Status)
+				}
+			}
+			if op.Attempts != tc.attempts {
+				t.Errorf("expected attempts %d, got %d", tc.attempts, op.Attempts)
+			}
+		})
+	}
+}
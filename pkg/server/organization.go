@@ -0,0 +1,500 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/cherry"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type OrganizationActions interface {
+	CreateOrganization(ctx context.Context, req model.OrganizationCreateRequest) (model.Organization, error)
+	GetUserOrganizations(ctx context.Context) ([]model.Organization, error)
+	GetOrganization(ctx context.Context, id string) (model.Organization, error)
+	DeleteOrganization(ctx context.Context, id string) error
+	SetOrganizationMember(ctx context.Context, id string, req model.OrganizationSetMemberRequest) error
+	DeleteOrganizationMember(ctx context.Context, id, username string) error
+	GetOrganizationNamespaces(ctx context.Context, id, labelSelector string) ([]model.NamespaceResponse, error)
+	AddNamespaceToOrganization(ctx context.Context, id, namespace string) error
+	DeleteNamespaceFromOrganization(ctx context.Context, id, namespace string) error
+	GetOrganizationProjects(ctx context.Context, id string) ([]model.Project, error)
+	AddProjectToOrganization(ctx context.Context, id, projectID string) error
+	DeleteProjectFromOrganization(ctx context.Context, id, projectID string) error
+}
+
+// isOrganizationAdmin checks if current user is admin of organization. Global admins are admins of all organizations.
+func isOrganizationAdmin(ctx context.Context, db database.DB, orgID *string) (bool, error) {
+	if IsAdminRole(ctx) {
+		return true, nil
+	}
+
+	if orgID == nil {
+		return false, nil
+	}
+
+	member, err := db.OrganizationMember(ctx, *orgID, httputil.MustGetUserID(ctx))
+	switch {
+	case err == nil:
+		return member.Role == model.OrganizationRoleAdmin, nil
+	case cherry.Equals(err, errors.ErrNotOrganizationMember()):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// organizationMemberCheck checks if resource of organization can be shared with user.
+func organizationMemberCheck(ctx context.Context, db database.DB, orgID *string, userID string) error {
+	if orgID == nil {
+		return nil
+	}
+
+	_, err := db.OrganizationMember(ctx, *orgID, userID)
+	return err
+}
+
+// resourceNonMembersCheck checks that resource is not shared with users outside of organization.
+func resourceNonMembersCheck(ctx context.Context, db database.DB, orgID string, kind model.ResourceType, resourceID string) error {
+	nonMembers, err := db.ResourceNonMembers(ctx, orgID, kind, resourceID)
+	if err != nil {
+		return err
+	}
+
+	if len(nonMembers) > 0 {
+		return errors.ErrNotOrganizationMember().AddDetailF("resource is shared with users outside of organization: %s", strings.Join(nonMembers, ", "))
+	}
+	return nil
+}
+
+func (s *Server) CreateOrganization(ctx context.Context, req model.OrganizationCreateRequest) (model.Organization, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"label":   req.Label,
+	}).Infof("create organization")
+
+	org := model.Organization{
+		Label:       req.Label,
+		OwnerUserID: userID,
+	}
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		if createErr := tx.CreateOrganization(ctx, &org); createErr != nil {
+			return createErr
+		}
+
+		return auditResourceChange(ctx, tx, "CreateOrganization", model.AuditResourceOrganization, org.ID, nil, organizationState(org))
+	})
+
+	return org, err
+}
+
+func (s *Server) GetUserOrganizations(ctx context.Context) ([]model.Organization, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithField("user_id", userID).Infof("get user organizations")
+
+	return s.db.UserOrganizations(ctx, userID)
+}
+
+func (s *Server) GetOrganization(ctx context.Context, id string) (model.Organization, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get organization")
+
+	org, err := s.db.OrganizationByID(ctx, id)
+	if err != nil {
+		return model.Organization{}, err
+	}
+
+	if !IsAdminRole(ctx) {
+		if chkErr := organizationMemberCheck(ctx, s.db, &org.ID, userID); chkErr != nil {
+			return model.Organization{}, errors.ErrResourceNotExists().AddDetailF("organization %s not exists", id)
+		}
+	}
+
+	for i := range org.Members {
+		user, getErr := s.clients.User.UserInfoByID(ctx, org.Members[i].UserID)
+		if getErr != nil {
+			s.log.WithError(getErr).Warnf("get login of user %s failed", org.Members[i].UserID)
+			continue
+		}
+		org.Members[i].UserLogin = user.Login
+	}
+
+	return org, nil
+}
+
+func (s *Server) DeleteOrganization(ctx context.Context, id string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("delete organization")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		org, getErr := tx.OrganizationByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := organizationOwnerCheck(ctx, org); chkErr != nil {
+			return chkErr
+		}
+
+		// namespaces and projects stay with their owners
+		if delErr := tx.DeleteOrganization(ctx, org); delErr != nil {
+			return delErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteOrganization", model.AuditResourceOrganization, org.ID, organizationState(org), nil)
+	})
+
+	return err
+}
+
+func (s *Server) SetOrganizationMember(ctx context.Context, id string, req model.OrganizationSetMemberRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"id":       id,
+		"username": req.Username,
+		"role":     req.Role,
+	}).Infof("set organization member")
+
+	if !req.Role.IsValid() {
+		return errors.ErrRequestValidationFailed().AddDetailF("invalid organization role %s", req.Role)
+	}
+
+	user, err := s.clients.User.UserInfoByLogin(ctx, req.Username)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		org, getErr := tx.OrganizationByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := organizationAdminCheck(ctx, tx, org.ID); chkErr != nil {
+			return chkErr
+		}
+
+		if user.ID == org.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		var before model.AuditState
+		member, getErr := tx.OrganizationMember(ctx, org.ID, user.ID)
+		switch {
+		case getErr == nil:
+			before = model.AuditState{"role": member.Role}
+		case !cherry.Equals(getErr, errors.ErrNotOrganizationMember()):
+			return getErr
+		}
+
+		if setErr := tx.SetOrganizationMember(ctx, &model.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         user.ID,
+			Role:           req.Role,
+		}); setErr != nil {
+			return setErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "SetOrganizationMember",
+			ResourceType: model.AuditResourceOrganization,
+			ResourceID:   org.ID,
+			Target:       user.ID,
+			Before:       before,
+			After:        model.AuditState{"role": req.Role},
+		})
+	})
+
+	return err
+}
+
+func (s *Server) DeleteOrganizationMember(ctx context.Context, id, username string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"id":       id,
+		"username": username,
+	}).Infof("delete organization member")
+
+	user, err := s.clients.User.UserInfoByLogin(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		org, getErr := tx.OrganizationByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := organizationAdminCheck(ctx, tx, org.ID); chkErr != nil {
+			return chkErr
+		}
+
+		if user.ID == org.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		member, getErr := tx.OrganizationMember(ctx, org.ID, user.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		owned, cntErr := tx.OrganizationOwnedResourcesCount(ctx, org.ID, user.ID)
+		if cntErr != nil {
+			return cntErr
+		}
+		if owned > 0 {
+			return errors.ErrRequestValidationFailed().AddDetailF("user %s owns %d resources of organization", username, owned)
+		}
+
+		if _, delErr := tx.DeleteOrganizationMember(ctx, member); delErr != nil {
+			return delErr
+		}
+
+		if auditErr := auditChange(ctx, tx, model.AuditRecord{
+			Action:       "DeleteOrganizationMember",
+			ResourceType: model.AuditResourceOrganization,
+			ResourceID:   org.ID,
+			Target:       user.ID,
+			Before:       model.AuditState{"role": member.Role},
+		}); auditErr != nil {
+			return auditErr
+		}
+
+		return updateUserAccesses(ctx, s.clients.Auth, tx, user.ID)
+	})
+
+	return err
+}
+
+func (s *Server) GetOrganizationNamespaces(ctx context.Context, id, labelSelector string) ([]model.NamespaceResponse, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":        userID,
+		"id":             id,
+		"label_selector": labelSelector,
+	}).Infof("get organization namespaces")
+
+	if chkErr := organizationAdminCheck(ctx, s.db, id); chkErr != nil {
+		return nil, chkErr
+	}
+
+	filter := StandardNamespaceFilter
+	filter.OrganizationID = id
+
+	var err error
+	filter.LabelSelector, err = database.ParseLabelSelector(labelSelector)
+	if err != nil {
+		return nil, errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+
+	namespaces, err := s.db.AllNamespaces(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]model.NamespaceResponse, 0)
+	for _, namespace := range namespaces {
+		AddOwnerLogin(ctx, &namespace.Resource, s.clients.User)
+		resp := (&model.NamespaceWithPermissions{Namespace: namespace}).ToResponse()
+		kubeErr := NamespaceAddUsage(ctx, &resp.Namespace, s.clients.Kube)
+		if kubeErr != nil {
+			s.log.WithError(kubeErr).Warn("NamespaceAddUsage failed")
+		}
+		ret = append(ret, resp)
+	}
+
+	return ret, nil
+}
+
+func (s *Server) AddNamespaceToOrganization(ctx context.Context, id, namespace string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"id":        id,
+		"namespace": namespace,
+	}).Infof("add namespace to organization")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		org, getErr := tx.OrganizationByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+			return chkErr
+		}
+
+		if ns.OrganizationID != nil {
+			return errors.ErrRequestValidationFailed().AddDetailF("namespace %s already belongs to organization", ns.Label)
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, &org.ID, ns.OwnerUserID); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := resourceNonMembersCheck(ctx, tx, org.ID, model.ResourceNamespace, ns.ID); chkErr != nil {
+			return chkErr
+		}
+
+		if setErr := tx.SetNamespaceOrganization(ctx, &ns.Namespace, &org.ID); setErr != nil {
+			return setErr
+		}
+
+		return auditResourceChange(ctx, tx, "AddNamespaceToOrganization", model.ResourceNamespace, ns.ID, nil, model.AuditState{"organization_id": org.ID})
+	})
+
+	return err
+}
+
+func (s *Server) DeleteNamespaceFromOrganization(ctx context.Context, id, namespace string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"id":        id,
+		"namespace": namespace,
+	}).Infof("delete namespace from organization")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if ns.OrganizationID == nil || *ns.OrganizationID != id {
+			return errors.ErrResourceNotExists().AddDetailF("namespace %s not exists in organization", ns.Label)
+		}
+
+		if OwnerCheck(ctx, ns.Resource) != nil {
+			if chkErr := organizationAdminCheck(ctx, tx, id); chkErr != nil {
+				return chkErr
+			}
+		}
+
+		if setErr := tx.SetNamespaceOrganization(ctx, &ns.Namespace, nil); setErr != nil {
+			return setErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteNamespaceFromOrganization", model.ResourceNamespace, ns.ID, model.AuditState{"organization_id": id}, nil)
+	})
+
+	return err
+}
+
+func (s *Server) GetOrganizationProjects(ctx context.Context, id string) ([]model.Project, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get organization projects")
+
+	if chkErr := organizationAdminCheck(ctx, s.db, id); chkErr != nil {
+		return nil, chkErr
+	}
+
+	projects, err := s.db.OrganizationProjects(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		AddOwnerLogin(ctx, &projects[i].Resource, s.clients.User)
+	}
+
+	return projects, nil
+}
+
+func (s *Server) AddProjectToOrganization(ctx context.Context, id, projectID string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"id":         id,
+		"project_id": projectID,
+	}).Infof("add project to organization")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		org, getErr := tx.OrganizationByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := OwnerCheck(ctx, project.Resource); chkErr != nil {
+			return chkErr
+		}
+
+		if project.OrganizationID != nil {
+			return errors.ErrRequestValidationFailed().AddDetailF("project %s already belongs to organization", project.Label)
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, &org.ID, project.OwnerUserID); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := resourceNonMembersCheck(ctx, tx, org.ID, model.ResourceProject, project.ID); chkErr != nil {
+			return chkErr
+		}
+
+		if setErr := tx.SetProjectOrganization(ctx, &project, &org.ID); setErr != nil {
+			return setErr
+		}
+
+		return auditResourceChange(ctx, tx, "AddProjectToOrganization", model.ResourceProject, project.ID, nil, model.AuditState{"organization_id": org.ID})
+	})
+
+	return err
+}
+
+func (s *Server) DeleteProjectFromOrganization(ctx context.Context, id, projectID string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"id":         id,
+		"project_id": projectID,
+	}).Infof("delete project from organization")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if project.OrganizationID == nil || *project.OrganizationID != id {
+			return errors.ErrResourceNotExists().AddDetailF("project %s not exists in organization", project.Label)
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		if setErr := tx.SetProjectOrganization(ctx, &project, nil); setErr != nil {
+			return setErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteProjectFromOrganization", model.ResourceProject, project.ID, model.AuditState{"organization_id": id}, nil)
+	})
+
+	return err
+}
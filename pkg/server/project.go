@@ -3,7 +3,9 @@ package server
 import (
 	"context"
 
+	"git.containerum.net/ch/permissions/pkg/clients"
 	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
 	"git.containerum.net/ch/permissions/pkg/model"
 	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/containerum/utils/httputil"
@@ -12,11 +14,19 @@ import (
 
 type ProjectActions interface {
 	CreateProject(ctx context.Context, label string) error
-	AddGroup(ctx context.Context, project, groupID string) error
+	AddGroup(ctx context.Context, project string, req model.ProjectAddGroupRequest) error
 	GetProjectGroups(ctx context.Context, projectID string) ([]kubeClientModel.UserGroup, error)
 	SetGroupMemberAccess(ctx context.Context, projectID, groupID string, req model.SetGroupMemberAccessRequest) error
 	DeleteGroupFromProject(ctx context.Context, projectID, groupID string) error
 	AddMemberToProject(ctx context.Context, projectID string, req model.AddMemberToProjectRequest) error
+	DeleteMemberFromProject(ctx context.Context, projectID, username string) error
+	GetProject(ctx context.Context, projectID string) (model.Project, error)
+	GetUserProjects(ctx context.Context) ([]model.Project, error)
+	RenameProject(ctx context.Context, projectID, newLabel string) error
+	UpdateProjectMeta(ctx context.Context, projectID string, req model.ProjectUpdateMetaRequest) error
+	DeleteProject(ctx context.Context, projectID string) error
+	AddNamespaceToProject(ctx context.Context, projectID, namespace string) error
+	DeleteNamespaceFromProject(ctx context.Context, projectID, namespace string) error
 }
 
 func (s *Server) CreateProject(ctx context.Context, label string) error {
@@ -26,46 +36,70 @@ func (s *Server) CreateProject(ctx context.Context, label string) error {
 		"label":   label,
 	}).Info("create project")
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		project := model.Project{
 			Resource: model.Resource{
 				OwnerUserID: userID,
 				Label:       label,
 			},
 		}
-		return tx.CreateProject(ctx, &project)
+		if createErr := tx.CreateProject(ctx, &project); createErr != nil {
+			return createErr
+		}
+
+		return auditResourceChange(ctx, tx, "CreateProject", model.ResourceProject, project.ID, nil, model.AuditState{"label": project.Label, "owner_user_id": project.OwnerUserID})
 	})
 	return err
 }
 
-func (s *Server) AddGroup(ctx context.Context, project, groupID string) error {
+func (s *Server) AddGroup(ctx context.Context, project string, req model.ProjectAddGroupRequest) error {
 	userID := httputil.MustGetUserID(ctx)
 	s.log.WithFields(logrus.Fields{
 		"user_id":  userID,
-		"group_id": groupID,
+		"group_id": req.GroupID,
 		"project":  project,
+		"access":   req.AccessLevel,
 	}).Info("add group")
 
-	group, err := s.clients.User.Group(ctx, groupID)
+	accessLevel, err := groupAccessLevel(req)
 	if err != nil {
 		return err
 	}
 
-	accessList := make([]database.AccessListElement, len(group.Members))
-	for i, v := range group.Members {
-		accessList[i] = database.AccessListElement{
-			AccessLevel: v.Access,
-			ToUserID:    v.Username,
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, project)
+		if getErr != nil {
+			return getErr
 		}
-	}
 
-	err = s.db.Transactional(func(tx database.DB) error {
-		project, getErr := tx.ProjectByID(ctx, project)
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := groupGrantState(ctx, tx, model.ResourceProject, project.ID, req.GroupID)
 		if getErr != nil {
 			return getErr
 		}
 
-		return tx.SetNamespacesAccesses(ctx, project.Namespaces, accessList)
+		if syncErr := syncGroupMembers(ctx, s.clients.User, tx, req.GroupID, s.cfg.GroupRoleMapping); syncErr != nil {
+			return syncErr
+		}
+
+		if setErr := tx.SetGroupPermission(ctx, &model.GroupPermission{
+			ResourceType: model.ResourceProject,
+			ResourceID:   project.ID,
+			GroupID:      req.GroupID,
+			AccessLevel:  accessLevel,
+			RoleMapping:  req.RoleMapping,
+		}); setErr != nil {
+			return setErr
+		}
+
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceProject, project.ID); applyErr != nil {
+			return applyErr
+		}
+
+		return auditGroupChange(ctx, tx, "AddGroup", model.ResourceProject, project.ID, req.GroupID, before)
 	})
 
 	return err
@@ -83,38 +117,24 @@ func (s *Server) GetProjectGroups(ctx context.Context, projectID string) ([]kube
 		return nil, err
 	}
 
-	if len(project.Namespaces) == 0 {
-		return make([]kubeClientModel.UserGroup, 0), nil
+	if chkErr := projectAccessCheck(ctx, s.db, project); chkErr != nil {
+		return nil, chkErr
 	}
 
-	nsWithPermissions := make([]model.NamespaceWithPermissions, len(project.Namespaces))
-	for i := range project.Namespaces {
-		nsWithPermissions[i].Namespace = project.Namespaces[i]
-		err = s.db.NamespacePermissions(ctx, &nsWithPermissions[i])
-		if err != nil {
-			return nil, err
-		}
+	perms, err := s.db.ResourceGroupPermissions(ctx, model.ResourceProject, project.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	var groupIDs []string
-	for _, ns := range nsWithPermissions {
-		for _, v := range ns.Permissions {
-			if v.GroupID != nil {
-				groupIDs = append(groupIDs, *v.GroupID)
-			}
+	for _, ns := range project.Namespaces {
+		nsPerms, getErr := s.db.ResourceGroupPermissions(ctx, model.ResourceNamespace, ns.ID)
+		if getErr != nil {
+			return nil, getErr
 		}
+		perms = append(perms, nsPerms...)
 	}
 
-	if len(groupIDs) == 0 {
-		return make([]kubeClientModel.UserGroup, 0), nil
-	}
-
-	groups, err := s.clients.User.GroupFullIDList(ctx, groupIDs...)
-	if err != nil {
-		return nil, err
-	}
-
-	return groups.Groups, nil
+	return groupsByPermissions(ctx, s.clients.User, perms)
 }
 
 func (s *Server) SetGroupMemberAccess(ctx context.Context, projectID, groupID string, req model.SetGroupMemberAccessRequest) error {
@@ -125,7 +145,11 @@ func (s *Server) SetGroupMemberAccess(ctx context.Context, projectID, groupID st
 		"access":   req.AccessLevel,
 	}).Infof("set group member access")
 
-	err := s.db.Transactional(func(tx database.DB) error {
+	if chkErr := checkExpiration(req.ExpiresAt); chkErr != nil {
+		return chkErr
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
 		project, getErr := tx.ProjectByID(ctx, projectID)
 		if getErr != nil {
 			return getErr
@@ -136,14 +160,36 @@ func (s *Server) SetGroupMemberAccess(ctx context.Context, projectID, groupID st
 			return getErr
 		}
 
+		if user.ID == project.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, project.OrganizationID, user.ID); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceProject, project.ID, user.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		// direct permission overrides access given by group
 		accesses := []database.AccessListElement{
-			{ToUserID: user.ID, AccessLevel: req.AccessLevel},
+			{ToUserID: user.ID, AccessLevel: req.AccessLevel, ExpiresAt: req.ExpiresAt},
 		}
-		if setErr := tx.SetNamespacesAccesses(ctx, project.Namespaces, accesses); setErr != nil {
+		if setErr := tx.SetProjectAccesses(ctx, project, accesses); setErr != nil {
 			return setErr
 		}
 
-		return updateUserAccesses(ctx, s.clients.Auth, s.db, user.ID)
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "SetGroupMemberAccess", model.ResourceProject, project.ID, user.ID, before)
 	})
 
 	return err
@@ -155,23 +201,32 @@ func (s *Server) DeleteGroupFromProject(ctx context.Context, projectID, groupID
 		"group":   groupID,
 	}).Infof("delete group from project")
 
-	err := s.db.Transactional(func(tx database.DB) error {
-		delPerms, delErr := tx.DeleteGroupFromProject(ctx, projectID, groupID)
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := groupGrantState(ctx, tx, model.ResourceProject, project.ID, groupID)
+		if getErr != nil {
+			return getErr
+		}
+
+		delPerms, delErr := tx.DeleteGroupFromProject(ctx, project, groupID)
 		if delErr != nil {
 			return delErr
 		}
-		users := make(map[string]bool)
-		for _, v := range delPerms {
-			users[v.UserID] = true
-		}
 
-		for user := range users {
-			if updErr := updateUserAccesses(ctx, s.clients.Auth, s.db, user); updErr != nil {
-				s.log.WithError(updErr).Warnf("update access failed for user %s", user)
-			}
+		// members may still have access through other groups
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceProject, project.ID, delPerms...); applyErr != nil {
+			return applyErr
 		}
 
-		return nil
+		return auditGroupChange(ctx, tx, "DeleteGroupFromProject", model.ResourceProject, project.ID, groupID, before)
 	})
 
 	return err
@@ -189,20 +244,337 @@ func (s *Server) AddMemberToProject(ctx context.Context, projectID string, req m
 		return err
 	}
 
-	err = s.db.Transactional(func(tx database.DB) error {
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
 		project, getErr := tx.ProjectByID(ctx, projectID)
 		if getErr != nil {
 			return getErr
 		}
 
-		access := []database.AccessListElement{
-			{ToUserID: user.ID, AccessLevel: req.AccessLevel},
+		if user.ID == project.OwnerUserID {
+			return errors.ErrSetOwnerAccess()
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		if chkErr := organizationMemberCheck(ctx, tx, project.OrganizationID, user.ID); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceProject, project.ID, user.ID)
+		if getErr != nil {
+			return getErr
 		}
-		if setErr := tx.SetNamespacesAccesses(ctx, project.Namespaces, access); setErr != nil {
+
+		if setErr := tx.SetProjectAccess(ctx, project, req.AccessLevel, user.ID); setErr != nil {
 			return setErr
 		}
 
-		return updateUserAccesses(ctx, s.clients.Auth, s.db, user.ID)
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "AddMemberToProject", model.ResourceProject, project.ID, user.ID, before)
+	})
+
+	return err
+}
+
+func (s *Server) DeleteMemberFromProject(ctx context.Context, projectID, username string) error {
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"username":   username,
+	}).Infof("delete member from project")
+
+	user, err := s.clients.User.UserInfoByLogin(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		before, getErr := effectiveAccessState(ctx, tx, model.ResourceProject, project.ID, user.ID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if delErr := tx.DeleteProjectAccess(ctx, project, user.ID); delErr != nil {
+			return delErr
+		}
+
+		// user may still have access through groups
+		if applyErr := applyGroupPermissions(ctx, s.clients.Auth, tx, model.ResourceProject, project.ID); applyErr != nil {
+			return applyErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user.ID); updErr != nil {
+			return updErr
+		}
+
+		return auditAccessChange(ctx, tx, "DeleteMemberFromProject", model.ResourceProject, project.ID, user.ID, before)
+	})
+
+	return err
+}
+
+// updateProjectUsersAccesses refreshes accesses of users having project permissions, including members of groups granted to project.
+// Must be called when project namespaces set was changed.
+func updateProjectUsersAccesses(ctx context.Context, auth clients.AuthClient, db database.DB, project model.Project) error {
+	return applyGroupPermissions(ctx, auth, db, model.ResourceProject, project.ID, project.Permissions...)
+}
+
+func projectAccessCheck(ctx context.Context, db database.DB, project model.Project) error {
+	if ProjectOwnerCheck(ctx, db, project) == nil {
+		return nil
+	}
+
+	userID := httputil.MustGetUserID(ctx)
+	for _, permission := range project.Permissions {
+		if permission.UserID == userID && permission.CurrentAccessLevel != kubeClientModel.None {
+			return nil
+		}
+	}
+
+	accesses, err := db.UserAccesses(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, access := range accesses {
+		for _, ns := range project.Namespaces {
+			if access.ResourceID == ns.ID && access.CurrentAccessLevel != kubeClientModel.None {
+				return nil
+			}
+		}
+	}
+
+	return errors.ErrResourceNotExists().AddDetailF("project %s not exists", project.ID)
+}
+
+func (s *Server) GetProject(ctx context.Context, projectID string) (model.Project, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"user_id":    userID,
+	}).Infof("get project")
+
+	project, err := s.db.ProjectByID(ctx, projectID)
+	if err != nil {
+		return model.Project{}, err
+	}
+
+	if chkErr := projectAccessCheck(ctx, s.db, project); chkErr != nil {
+		return model.Project{}, chkErr
+	}
+
+	AddOwnerLogin(ctx, &project.Resource, s.clients.User)
+
+	return project, nil
+}
+
+func (s *Server) GetUserProjects(ctx context.Context) ([]model.Project, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithField("user_id", userID).Infof("get user projects")
+
+	projects, err := s.db.UserProjects(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		AddOwnerLogin(ctx, &projects[i].Resource, s.clients.User)
+	}
+
+	return projects, nil
+}
+
+func (s *Server) RenameProject(ctx context.Context, projectID, newLabel string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"user_id":    userID,
+		"new_label":  newLabel,
+	}).Infof("rename project")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		oldLabel := project.Label
+		if renameErr := tx.RenameProject(ctx, &project, newLabel); renameErr != nil {
+			return renameErr
+		}
+
+		return auditResourceChange(ctx, tx, "RenameProject", model.ResourceProject, project.ID, model.AuditState{"label": oldLabel}, model.AuditState{"label": newLabel})
+	})
+
+	return err
+}
+
+func (s *Server) UpdateProjectMeta(ctx context.Context, projectID string, req model.ProjectUpdateMetaRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"user_id":    userID,
+	}).Infof("update project meta")
+
+	if err := database.ValidateLabels(req.Labels); err != nil {
+		return errors.ErrRequestValidationFailed().AddDetailsErr(err)
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		before := model.AuditState{"labels": project.Labels, "description": project.Description}
+		if req.Labels != nil {
+			project.Labels = req.Labels
+		}
+		if req.Description != nil {
+			project.Description = *req.Description
+		}
+
+		if updErr := tx.UpdateProjectMeta(ctx, &project); updErr != nil {
+			return updErr
+		}
+
+		return auditResourceChange(ctx, tx, "UpdateProjectMeta", model.ResourceProject, project.ID,
+			before, model.AuditState{"labels": project.Labels, "description": project.Description})
+	})
+
+	return err
+}
+
+func (s *Server) DeleteProject(ctx context.Context, projectID string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"user_id":    userID,
+	}).Infof("delete project")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		if delErr := tx.DeleteProject(ctx, &project); delErr != nil {
+			return delErr
+		}
+
+		if updErr := updateProjectUsersAccesses(ctx, s.clients.Auth, tx, project); updErr != nil {
+			return updErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteProject", model.ResourceProject, project.ID, model.AuditState{"label": project.Label, "owner_user_id": project.OwnerUserID}, nil)
+	})
+
+	return err
+}
+
+func (s *Server) AddNamespaceToProject(ctx context.Context, projectID, namespace string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"namespace":  namespace,
+		"user_id":    userID,
+	}).Infof("add namespace to project")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+			return chkErr
+		}
+
+		if ns.OwnerUserID != project.OwnerUserID {
+			return errors.ErrResourceNotOwned().AddDetailF("namespace %s and project %s have different owners", ns.Label, project.Label)
+		}
+
+		if setErr := tx.SetNamespaceProject(ctx, &ns.Namespace, &project.ID); setErr != nil {
+			return setErr
+		}
+
+		if updErr := updateProjectUsersAccesses(ctx, s.clients.Auth, tx, project); updErr != nil {
+			return updErr
+		}
+
+		return auditResourceChange(ctx, tx, "AddNamespaceToProject", model.ResourceNamespace, ns.ID, nil, model.AuditState{"project_id": project.ID})
+	})
+
+	return err
+}
+
+func (s *Server) DeleteNamespaceFromProject(ctx context.Context, projectID, namespace string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"namespace":  namespace,
+		"user_id":    userID,
+	}).Infof("delete namespace from project")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		project, getErr := tx.ProjectByID(ctx, projectID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return chkErr
+		}
+
+		for _, ns := range project.Namespaces {
+			if ns.KubeName == namespace {
+				if setErr := tx.SetNamespaceProject(ctx, &ns, nil); setErr != nil {
+					return setErr
+				}
+
+				if updErr := updateProjectUsersAccesses(ctx, s.clients.Auth, tx, project); updErr != nil {
+					return updErr
+				}
+
+				return auditResourceChange(ctx, tx, "DeleteNamespaceFromProject", model.ResourceNamespace, ns.ID, model.AuditState{"project_id": project.ID}, nil)
+			}
+		}
+
+		return errors.ErrResourceNotExists().AddDetailF("namespace %s not exists in project", namespace)
 	})
 
 	return err
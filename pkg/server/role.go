@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type RoleActions interface {
+	GetRoles(ctx context.Context) ([]model.Role, error)
+	CreateRole(ctx context.Context, req model.RoleCreateRequest) (model.Role, error)
+	UpdateRole(ctx context.Context, name string, req model.RoleUpdateRequest) (model.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+}
+
+func checkRole(role model.Role) error {
+	if _, builtIn := model.BuiltInRole(role.Name); builtIn {
+		return errors.ErrRequestValidationFailed().AddDetailF("role %s is built-in", role.Name)
+	}
+
+	switch role.AccessLevel {
+	case kubeClientModel.Write, kubeClientModel.ReadDelete, kubeClientModel.Read:
+	default:
+		return errors.ErrRequestValidationFailed().AddDetailF("access level %s can not be used for role", role.AccessLevel)
+	}
+
+	for _, action := range role.Actions {
+		if !action.IsValid() {
+			return errors.ErrRequestValidationFailed().AddDetailF("unknown action %s", action)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) GetRoles(ctx context.Context) ([]model.Role, error) {
+	s.log.Infof("get roles")
+
+	customRoles, err := s.db.AllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]model.Role{}, model.BuiltInRoles...), customRoles...), nil
+}
+
+func (s *Server) CreateRole(ctx context.Context, req model.RoleCreateRequest) (model.Role, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"name":    req.Name,
+		"access":  req.AccessLevel,
+		"actions": req.Actions,
+	}).Infof("create role")
+
+	role := model.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		AccessLevel: req.AccessLevel,
+		Actions:     req.Actions,
+	}
+	if role.Actions == nil {
+		role.Actions = []model.Action{}
+	}
+
+	if chkErr := checkRole(role); chkErr != nil {
+		return model.Role{}, chkErr
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		if createErr := tx.CreateRole(ctx, &role); createErr != nil {
+			return createErr
+		}
+
+		return auditResourceChange(ctx, tx, "CreateRole", model.AuditResourceRole, role.Name, nil, roleState(role))
+	})
+	if err != nil {
+		return model.Role{}, err
+	}
+
+	return role, nil
+}
+
+func (s *Server) UpdateRole(ctx context.Context, name string, req model.RoleUpdateRequest) (model.Role, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"name":    name,
+	}).Infof("update role %+v", req)
+
+	var role model.Role
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		var getErr error
+		role, getErr = tx.RoleByName(ctx, name)
+		if getErr != nil {
+			return getErr
+		}
+
+		before := roleState(role)
+		if req.Description != nil {
+			role.Description = *req.Description
+		}
+		if req.AccessLevel != nil {
+			role.AccessLevel = *req.AccessLevel
+		}
+		if req.Actions != nil {
+			role.Actions = req.Actions
+		}
+
+		if chkErr := checkRole(role); chkErr != nil {
+			return chkErr
+		}
+
+		updatedPerms, updErr := tx.UpdateRole(ctx, &role)
+		if updErr != nil {
+			return updErr
+		}
+
+		// access level of role holders may be changed
+		updatedUsers := make(map[string]struct{})
+		for _, perm := range updatedPerms {
+			if _, updated := updatedUsers[perm.UserID]; updated {
+				continue
+			}
+			updatedUsers[perm.UserID] = struct{}{}
+
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, perm.UserID); updErr != nil {
+				return updErr
+			}
+		}
+
+		return auditResourceChange(ctx, tx, "UpdateRole", model.AuditResourceRole, role.Name, before, roleState(role))
+	})
+
+	return role, err
+}
+
+func (s *Server) DeleteRole(ctx context.Context, name string) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"name":    name,
+	}).Infof("delete role")
+
+	if _, builtIn := model.BuiltInRole(name); builtIn {
+		return errors.ErrRequestValidationFailed().AddDetailF("role %s is built-in", name)
+	}
+
+	return s.db.Transactional(ctx, func(tx database.DB) error {
+		role, getErr := tx.RoleByName(ctx, name)
+		if getErr != nil {
+			return getErr
+		}
+
+		if delErr := tx.DeleteRole(ctx, name); delErr != nil {
+			return delErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteRole", model.AuditResourceRole, role.Name, roleState(role), nil)
+	})
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+)
+
+func TestCheckRole(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		role model.Role
+		err  bool
+	}{
+		{
+			name: "valid",
+			role: model.Role{Name: "maintainer", AccessLevel: kubeClientModel.Write, Actions: []model.Action{model.ActionNamespaceResize, model.ActionAccessManage}},
+		},
+		{name: "without actions", role: model.Role{Name: "viewer", AccessLevel: kubeClientModel.Read}},
+		{name: "built-in name", role: model.Role{Name: string(kubeClientModel.Write), AccessLevel: kubeClientModel.Write}, err: true},
+		{name: "owner access", role: model.Role{Name: "co-owner", AccessLevel: kubeClientModel.Owner}, err: true},
+		{name: "no access", role: model.Role{Name: "nobody", AccessLevel: kubeClientModel.None}, err: true},
+		{name: "unknown action", role: model.Role{Name: "maintainer", AccessLevel: kubeClientModel.Write, Actions: []model.Action{"namespace.explode"}}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkRole(tc.role)
+			if tc.err != (err != nil) {
+				t.Errorf("expected error %t, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type ServiceAccountActions interface {
+	CreateNamespaceServiceAccount(ctx context.Context, id string, req model.ServiceAccountCreateRequest) (model.ServiceAccountCredentials, error)
+	GetNamespaceServiceAccounts(ctx context.Context, id string) ([]model.ServiceAccountWithAccess, error)
+	SetNamespaceServiceAccountAccess(ctx context.Context, id, name string, req model.ServiceAccountSetAccessRequest) error
+	RotateNamespaceServiceAccount(ctx context.Context, id, name string) (model.ServiceAccountCredentials, error)
+	DeleteNamespaceServiceAccount(ctx context.Context, id, name string) error
+	CreateProjectServiceAccount(ctx context.Context, projectID string, req model.ServiceAccountCreateRequest) (model.ServiceAccountCredentials, error)
+	GetProjectServiceAccounts(ctx context.Context, projectID string) ([]model.ServiceAccountWithAccess, error)
+	SetProjectServiceAccountAccess(ctx context.Context, projectID, name string, req model.ServiceAccountSetAccessRequest) error
+	RotateProjectServiceAccount(ctx context.Context, projectID, name string) (model.ServiceAccountCredentials, error)
+	DeleteProjectServiceAccount(ctx context.Context, projectID, name string) error
+	AuthenticateServiceAccount(ctx context.Context, req model.ServiceAccountAuthenticateRequest) (model.ServiceAccountWithAccess, error)
+}
+
+// serviceAccountScope is a resource which service accounts are bound to.
+type serviceAccountScope struct {
+	kind      model.ResourceType
+	id        string
+	setAccess func(accessLevel kubeClientModel.AccessLevel, saID string) error
+}
+
+type serviceAccountScopeGetter func(tx database.DB) (serviceAccountScope, error)
+
+func (s *Server) namespaceServiceAccountScope(ctx context.Context, id string) serviceAccountScopeGetter {
+	return func(tx database.DB) (serviceAccountScope, error) {
+		ns, err := tx.NamespaceByName(ctx, httputil.MustGetUserID(ctx), id, IsAdminRole(ctx))
+		if err != nil {
+			return serviceAccountScope{}, err
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionAccessManage); chkErr != nil {
+			return serviceAccountScope{}, chkErr
+		}
+
+		return serviceAccountScope{
+			kind: model.ResourceNamespace,
+			id:   ns.ID,
+			setAccess: func(accessLevel kubeClientModel.AccessLevel, saID string) error {
+				return tx.SetNamespaceAccess(ctx, ns.Namespace, accessLevel, saID, nil)
+			},
+		}, nil
+	}
+}
+
+func (s *Server) projectServiceAccountScope(ctx context.Context, projectID string) serviceAccountScopeGetter {
+	return func(tx database.DB) (serviceAccountScope, error) {
+		project, err := tx.ProjectByID(ctx, projectID)
+		if err != nil {
+			return serviceAccountScope{}, err
+		}
+
+		if chkErr := ProjectOwnerCheck(ctx, tx, project); chkErr != nil {
+			return serviceAccountScope{}, chkErr
+		}
+
+		return serviceAccountScope{
+			kind: model.ResourceProject,
+			id:   project.ID,
+			setAccess: func(accessLevel kubeClientModel.AccessLevel, saID string) error {
+				return tx.SetProjectAccess(ctx, project, accessLevel, saID)
+			},
+		}, nil
+	}
+}
+
+func checkServiceAccountAccess(accessLevel kubeClientModel.AccessLevel) error {
+	switch accessLevel {
+	case kubeClientModel.Write, kubeClientModel.ReadDelete, kubeClientModel.Read:
+		return nil
+	default:
+		return errors.ErrRequestValidationFailed().AddDetailF("access level %s can not be granted to service account", accessLevel)
+	}
+}
+
+func hashServiceAccountToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// newServiceAccountToken generates random token and its hash to store.
+func newServiceAccountToken() (token, tokenHash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, hashServiceAccountToken(token), nil
+}
+
+func (s *Server) createServiceAccount(ctx context.Context, getScope serviceAccountScopeGetter, req model.ServiceAccountCreateRequest) (model.ServiceAccountCredentials, error) {
+	if chkErr := checkServiceAccountAccess(req.Access); chkErr != nil {
+		return model.ServiceAccountCredentials{}, chkErr
+	}
+
+	token, tokenHash, err := newServiceAccountToken()
+	if err != nil {
+		return model.ServiceAccountCredentials{}, errors.ErrInternal().Log(err, s.log)
+	}
+
+	var ret model.ServiceAccountCredentials
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		scope, getErr := getScope(tx)
+		if getErr != nil {
+			return getErr
+		}
+
+		ret.ServiceAccount = model.ServiceAccount{
+			Name:         req.Name,
+			ResourceType: scope.kind,
+			ResourceID:   scope.id,
+			TokenHash:    tokenHash,
+			CreatedBy:    httputil.MustGetUserID(ctx),
+		}
+		if createErr := tx.CreateServiceAccount(ctx, &ret.ServiceAccount); createErr != nil {
+			return createErr
+		}
+
+		if setErr := scope.setAccess(req.Access, ret.ID); setErr != nil {
+			return setErr
+		}
+		ret.AccessLevel = req.Access
+		ret.Token = token
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, ret.ID); updErr != nil {
+			return updErr
+		}
+
+		if pubErr := publishAccessChanged(ctx, tx, scope.kind, scope.id, "service_account_id", ret.ID, nil, accessState(req.Access)); pubErr != nil {
+			return pubErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "CreateServiceAccount",
+			ResourceType: scope.kind,
+			ResourceID:   scope.id,
+			Target:       ret.Name,
+			Before:       nil,
+			After:        accessState(req.Access),
+		})
+	})
+
+	return ret, err
+}
+
+func (s *Server) getServiceAccounts(ctx context.Context, getScope serviceAccountScopeGetter) ([]model.ServiceAccountWithAccess, error) {
+	scope, err := getScope(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.ResourceServiceAccounts(ctx, scope.kind, scope.id)
+}
+
+func (s *Server) setServiceAccountAccess(ctx context.Context, getScope serviceAccountScopeGetter, name string, req model.ServiceAccountSetAccessRequest) error {
+	if chkErr := checkServiceAccountAccess(req.Access); chkErr != nil {
+		return chkErr
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		scope, getErr := getScope(tx)
+		if getErr != nil {
+			return getErr
+		}
+
+		sa, getErr := tx.ServiceAccountByName(ctx, scope.kind, scope.id, name)
+		if getErr != nil {
+			return getErr
+		}
+
+		if setErr := scope.setAccess(req.Access, sa.ID); setErr != nil {
+			return setErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, sa.ID); updErr != nil {
+			return updErr
+		}
+
+		if pubErr := publishAccessChanged(ctx, tx, scope.kind, scope.id, "service_account_id", sa.ID, accessState(sa.AccessLevel), accessState(req.Access)); pubErr != nil {
+			return pubErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "SetServiceAccountAccess",
+			ResourceType: scope.kind,
+			ResourceID:   scope.id,
+			Target:       sa.Name,
+			Before:       accessState(sa.AccessLevel),
+			After:        accessState(req.Access),
+		})
+	})
+
+	return err
+}
+
+func (s *Server) rotateServiceAccount(ctx context.Context, getScope serviceAccountScopeGetter, name string) (model.ServiceAccountCredentials, error) {
+	token, tokenHash, err := newServiceAccountToken()
+	if err != nil {
+		return model.ServiceAccountCredentials{}, errors.ErrInternal().Log(err, s.log)
+	}
+
+	var ret model.ServiceAccountCredentials
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		scope, getErr := getScope(tx)
+		if getErr != nil {
+			return getErr
+		}
+
+		sa, getErr := tx.ServiceAccountByName(ctx, scope.kind, scope.id, name)
+		if getErr != nil {
+			return getErr
+		}
+
+		if rotateErr := tx.RotateServiceAccountToken(ctx, &sa.ServiceAccount, tokenHash); rotateErr != nil {
+			return rotateErr
+		}
+
+		ret.ServiceAccountWithAccess = sa
+		ret.Token = token
+
+		// token itself is secret, only fact of rotation is recorded
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "RotateServiceAccount",
+			ResourceType: scope.kind,
+			ResourceID:   scope.id,
+			Target:       sa.Name,
+			Before:       nil,
+			After:        nil,
+		})
+	})
+
+	return ret, err
+}
+
+func (s *Server) deleteServiceAccount(ctx context.Context, getScope serviceAccountScopeGetter, name string) error {
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		scope, getErr := getScope(tx)
+		if getErr != nil {
+			return getErr
+		}
+
+		sa, getErr := tx.ServiceAccountByName(ctx, scope.kind, scope.id, name)
+		if getErr != nil {
+			return getErr
+		}
+
+		if delErr := tx.DeleteServiceAccount(ctx, sa.ServiceAccount); delErr != nil {
+			return delErr
+		}
+
+		// pushes empty accesses so auth service revokes them
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, sa.ID); updErr != nil {
+			return updErr
+		}
+
+		if pubErr := publishAccessChanged(ctx, tx, scope.kind, scope.id, "service_account_id", sa.ID, accessState(sa.AccessLevel), nil); pubErr != nil {
+			return pubErr
+		}
+
+		return auditChange(ctx, tx, model.AuditRecord{
+			Action:       "DeleteServiceAccount",
+			ResourceType: scope.kind,
+			ResourceID:   scope.id,
+			Target:       sa.Name,
+			Before:       accessState(sa.AccessLevel),
+			After:        nil,
+		})
+	})
+
+	return err
+}
+
+func (s *Server) CreateNamespaceServiceAccount(ctx context.Context, id string, req model.ServiceAccountCreateRequest) (model.ServiceAccountCredentials, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+		"name":    req.Name,
+		"access":  req.Access,
+	}).Infof("create namespace service account")
+
+	return s.createServiceAccount(ctx, s.namespaceServiceAccountScope(ctx, id), req)
+}
+
+func (s *Server) GetNamespaceServiceAccounts(ctx context.Context, id string) ([]model.ServiceAccountWithAccess, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+	}).Infof("get namespace service accounts")
+
+	return s.getServiceAccounts(ctx, s.namespaceServiceAccountScope(ctx, id))
+}
+
+func (s *Server) SetNamespaceServiceAccountAccess(ctx context.Context, id, name string, req model.ServiceAccountSetAccessRequest) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+		"name":    name,
+		"access":  req.Access,
+	}).Infof("set namespace service account access")
+
+	return s.setServiceAccountAccess(ctx, s.namespaceServiceAccountScope(ctx, id), name, req)
+}
+
+func (s *Server) RotateNamespaceServiceAccount(ctx context.Context, id, name string) (model.ServiceAccountCredentials, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+		"name":    name,
+	}).Infof("rotate namespace service account")
+
+	return s.rotateServiceAccount(ctx, s.namespaceServiceAccountScope(ctx, id), name)
+}
+
+func (s *Server) DeleteNamespaceServiceAccount(ctx context.Context, id, name string) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+		"name":    name,
+	}).Infof("delete namespace service account")
+
+	return s.deleteServiceAccount(ctx, s.namespaceServiceAccountScope(ctx, id), name)
+}
+
+func (s *Server) CreateProjectServiceAccount(ctx context.Context, projectID string, req model.ServiceAccountCreateRequest) (model.ServiceAccountCredentials, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    httputil.MustGetUserID(ctx),
+		"project_id": projectID,
+		"name":       req.Name,
+		"access":     req.Access,
+	}).Infof("create project service account")
+
+	return s.createServiceAccount(ctx, s.projectServiceAccountScope(ctx, projectID), req)
+}
+
+func (s *Server) GetProjectServiceAccounts(ctx context.Context, projectID string) ([]model.ServiceAccountWithAccess, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    httputil.MustGetUserID(ctx),
+		"project_id": projectID,
+	}).Infof("get project service accounts")
+
+	return s.getServiceAccounts(ctx, s.projectServiceAccountScope(ctx, projectID))
+}
+
+func (s *Server) SetProjectServiceAccountAccess(ctx context.Context, projectID, name string, req model.ServiceAccountSetAccessRequest) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    httputil.MustGetUserID(ctx),
+		"project_id": projectID,
+		"name":       name,
+		"access":     req.Access,
+	}).Infof("set project service account access")
+
+	return s.setServiceAccountAccess(ctx, s.projectServiceAccountScope(ctx, projectID), name, req)
+}
+
+func (s *Server) RotateProjectServiceAccount(ctx context.Context, projectID, name string) (model.ServiceAccountCredentials, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    httputil.MustGetUserID(ctx),
+		"project_id": projectID,
+		"name":       name,
+	}).Infof("rotate project service account")
+
+	return s.rotateServiceAccount(ctx, s.projectServiceAccountScope(ctx, projectID), name)
+}
+
+func (s *Server) DeleteProjectServiceAccount(ctx context.Context, projectID, name string) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id":    httputil.MustGetUserID(ctx),
+		"project_id": projectID,
+		"name":       name,
+	}).Infof("delete project service account")
+
+	return s.deleteServiceAccount(ctx, s.projectServiceAccountScope(ctx, projectID), name)
+}
+
+func (s *Server) AuthenticateServiceAccount(ctx context.Context, req model.ServiceAccountAuthenticateRequest) (model.ServiceAccountWithAccess, error) {
+	s.log.Infof("authenticate service account")
+
+	return s.db.ServiceAccountByTokenHash(ctx, hashServiceAccountToken(req.Token))
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"time"
 
 	"git.containerum.net/ch/permissions/pkg/clients"
 	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
 	"github.com/containerum/cherry/adaptors/cherrylog"
+	kubeClientModel "github.com/containerum/kube-client/pkg/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,6 +22,13 @@ type Clients struct {
 	Billing   clients.BillingClient
 	Volume    clients.VolumeManagerClient
 	Solutions clients.SolutionsClient
+	Webhook   clients.WebhookClient
+
+	// Client of HTTP cleanup hooks defined in config
+	CleanupHook clients.CleanupHookClient
+
+	// Sinks receiving events from outbox
+	EventSinks []clients.EventSink
 }
 
 func (c *Clients) Close() error {
@@ -38,18 +48,110 @@ func (c *Clients) Close() error {
 	return nil
 }
 
+// withDryRun returns clients which skip changing calls for dry run requests.
+func (c *Clients) withDryRun() *Clients {
+	return &Clients{
+		Auth:      clients.NewDryRunAuthClient(c.Auth),
+		User:      c.User,
+		Kube:      clients.NewDryRunKubeAPIClient(c.Kube),
+		Resource:  clients.NewDryRunResourceServiceClient(c.Resource),
+		Billing:   clients.NewDryRunBillingClient(c.Billing),
+		Volume:    clients.NewDryRunVolumeManagerClient(c.Volume),
+		Solutions: clients.NewDryRunSolutionsClient(c.Solutions),
+		Webhook:   clients.NewDryRunWebhookClient(c.Webhook),
+
+		CleanupHook: clients.NewDryRunCleanupHookClient(c.CleanupHook),
+
+		// events of dry run requests are rolled back with transaction and never dispatched
+		EventSinks: c.EventSinks,
+	}
+}
+
+// Config contains tunable parameters of server actions
+type Config struct {
+	// Access level kept by previous owner after namespace transfer if not set in request
+	TransferOldOwnerAccess kubeClientModel.AccessLevel
+
+	// Period while deleted namespace can be restored
+	NamespaceRetention time.Duration
+
+	// Interval between runs of tombstones purge job
+	TombstonesPurgeInterval time.Duration
+
+	// Interval between runs of expired accesses revoking job
+	ExpiredAccessesSweepInterval time.Duration
+
+	// Interval between runs of groups membership reconciliation job
+	GroupsReconcileInterval time.Duration
+
+	// Mapping of group member roles to access levels, default rules used for roles not in mapping
+	GroupRoleMapping model.GroupRoleMapping
+
+	// Default limits of resources in all namespaces of user, zero means no limit
+	UserLimits model.ResourcesLimits
+
+	// Interval between runs of outbox events dispatch job
+	EventsDispatchInterval time.Duration
+
+	// Max number of events or webhook deliveries sent in one transaction
+	EventsBatchSize int
+
+	// Period while delivered events are kept in outbox, zero means forever
+	EventsRetention time.Duration
+
+	// Number of attempts after which event is failed and not sent anymore
+	EventsMaxAttempts int
+
+	// Delay before second attempt of event delivery, doubled for each next attempt
+	EventsRetryBackoff time.Duration
+
+	// Interval between runs of webhook deliveries job
+	WebhooksDeliveryInterval time.Duration
+
+	// Number of attempts after which webhook delivery is failed
+	WebhookMaxAttempts int
+
+	// Delay before second attempt of webhook delivery, doubled for each next attempt
+	WebhookRetryBackoff time.Duration
+
+	// Interval between runs of abandoned operations compensation job
+	OperationsRecoveryInterval time.Duration
+
+	// Period after last update when unfinished operation is considered abandoned
+	OperationsLeaseTimeout time.Duration
+
+	// Period while finished operations are kept, zero means forever
+	OperationsRetention time.Duration
+
+	// Number of attempts of cleanup step made before it is left to recovery job
+	CleanupStepAttempts int
+
+	// Delay before second attempt of cleanup step, doubled for each next attempt
+	CleanupRetryBackoff time.Duration
+
+	// Number of cleanup runs after which unfinished cleanup is failed and not resumed anymore
+	CleanupMaxRuns int
+}
+
 type Server struct {
 	db      database.DB
 	log     *cherrylog.LogrusAdapter
 	clients *Clients
+	cfg     Config
+
+	// Registered steps of deleted namespaces cleanup in order of registration
+	cleanupHooks []CleanupHook
 }
 
-func NewServer(db database.DB, clients *Clients) *Server {
-	return &Server{
+func NewServer(db database.DB, clients *Clients, cfg Config) *Server {
+	s := &Server{
 		db:      db,
 		log:     cherrylog.NewLogrusAdapter(logrus.WithField("component", "entry")),
-		clients: clients,
+		clients: clients.withDryRun(),
+		cfg:     cfg,
 	}
+	s.cleanupHooks = s.defaultCleanupHooks()
+	return s
 }
 
 func (s *Server) Close() error {
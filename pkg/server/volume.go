@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/model"
+	billing "github.com/containerum/bill-external/models"
+	"github.com/containerum/utils/httputil"
+	"github.com/sirupsen/logrus"
+)
+
+type VolumeActions interface {
+	CreateVolume(ctx context.Context, namespace string, req model.VolumeCreateRequest) error
+	GetVolume(ctx context.Context, id string) (model.VolumeWithPermissions, error)
+	GetUserVolumes(ctx context.Context, filters ...string) ([]model.VolumeWithPermissions, error)
+	GetNamespaceVolumes(ctx context.Context, namespace string) ([]model.VolumeWithPermissions, error)
+	RenameVolume(ctx context.Context, id, newLabel string) error
+	DeleteVolume(ctx context.Context, id string) error
+}
+
+func (s *Server) CreateVolume(ctx context.Context, namespace string, req model.VolumeCreateRequest) error {
+	userID := httputil.MustGetUserID(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"namespace": namespace,
+		"tariff_id": req.TariffID,
+		"label":     req.Label,
+	}).Infof("create volume")
+
+	tariff, err := s.clients.Billing.GetVolumeTariff(ctx, req.TariffID)
+	if err != nil {
+		return err
+	}
+
+	if chkErr := CheckTariff(tariff.Tariff, IsAdminRole(ctx)); chkErr != nil {
+		return chkErr
+	}
+
+	err = s.db.Transactional(ctx, func(tx database.DB) error {
+		ns, getErr := tx.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := NamespaceActionCheck(ctx, tx, ns, model.ActionVolumeCreate); chkErr != nil {
+			return chkErr
+		}
+
+		vol := model.Volume{
+			Resource: model.Resource{
+				OwnerUserID: ns.OwnerUserID,
+				Label:       req.Label,
+			},
+			TariffID:    &req.TariffID,
+			Capacity:    tariff.StorageLimit,
+			NamespaceID: ns.ID,
+		}
+
+		if createErr := tx.CreateVolume(ctx, &vol); createErr != nil {
+			return createErr
+		}
+
+		if createErr := s.clients.Volume.CreateVolume(ctx, ns.KubeName, vol.Label, vol.Capacity); createErr != nil {
+			return createErr
+		}
+
+		if subErr := s.clients.Billing.Subscribe(ctx, billing.SubscribeTariffRequest{
+			TariffID:      tariff.ID,
+			ResourceType:  billing.Volume,
+			ResourceLabel: vol.Label,
+			ResourceID:    vol.ID,
+		}); subErr != nil {
+			return subErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, ns.OwnerUserID); updErr != nil {
+			return updErr
+		}
+
+		if auditErr := auditResourceChange(ctx, tx, "CreateVolume", model.ResourceVolume, vol.ID, nil, volumeState(vol)); auditErr != nil {
+			return auditErr
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *Server) GetVolume(ctx context.Context, id string) (model.VolumeWithPermissions, error) {
+	userID := httputil.MustGetUserID(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("get volume")
+
+	vol, err := s.db.VolumeByID(ctx, userID, id, IsAdminRole(ctx))
+	if err != nil {
+		return model.VolumeWithPermissions{}, err
+	}
+
+	if err := s.db.VolumePermissions(ctx, &vol); err != nil {
+		return model.VolumeWithPermissions{}, err
+	}
+
+	AddOwnerLogin(ctx, &vol.Resource, s.clients.User)
+	AddUserLogins(ctx, vol.Permissions, s.clients.User)
+
+	return vol, nil
+}
+
+func (s *Server) GetUserVolumes(ctx context.Context, filters ...string) ([]model.VolumeWithPermissions, error) {
+	userID := httputil.MustGetUserID(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"filters": filters,
+	}).Infof("get user volumes")
+
+	var filter database.NamespaceFilter
+	if !IsAdminRole(ctx) {
+		filter = StandardNamespaceFilter
+	} else {
+		filter = database.ParseNamespaceFilter(filters...)
+	}
+
+	volumes, err := s.db.UserVolumes(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range volumes {
+		AddOwnerLogin(ctx, &volumes[i].Resource, s.clients.User)
+	}
+
+	return volumes, nil
+}
+
+func (s *Server) GetNamespaceVolumes(ctx context.Context, namespace string) ([]model.VolumeWithPermissions, error) {
+	userID := httputil.MustGetUserID(ctx)
+
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"namespace": namespace,
+	}).Infof("get namespace volumes")
+
+	ns, err := s.db.NamespaceByName(ctx, userID, namespace, IsAdminRole(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := s.db.NamespaceVolumes(ctx, userID, ns.Namespace, IsAdminRole(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range volumes {
+		AddOwnerLogin(ctx, &volumes[i].Resource, s.clients.User)
+	}
+
+	return volumes, nil
+}
+
+func (s *Server) RenameVolume(ctx context.Context, id, newLabel string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"id":        id,
+		"new_label": newLabel,
+	}).Infof("rename volume")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		vol, getErr := tx.VolumeByID(ctx, userID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := VolumeActionCheck(ctx, tx, vol, model.ActionVolumeRename); chkErr != nil {
+			return chkErr
+		}
+
+		oldLabel := vol.Label
+		if renameErr := tx.RenameVolume(ctx, &vol.Volume, newLabel); renameErr != nil {
+			return renameErr
+		}
+
+		if renameErr := s.clients.Billing.Rename(ctx, vol.ID, newLabel); renameErr != nil {
+			return renameErr
+		}
+
+		if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, vol.OwnerUserID); updErr != nil {
+			return updErr
+		}
+
+		if auditErr := auditResourceChange(ctx, tx, "RenameVolume", model.ResourceVolume, vol.ID, model.AuditState{"label": oldLabel}, model.AuditState{"label": newLabel}); auditErr != nil {
+			return auditErr
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *Server) DeleteVolume(ctx context.Context, id string) error {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id": userID,
+		"id":      id,
+	}).Infof("delete volume")
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		vol, getErr := tx.VolumeByID(ctx, userID, id, IsAdminRole(ctx))
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := VolumeActionCheck(ctx, tx, vol, model.ActionVolumeDelete); chkErr != nil {
+			return chkErr
+		}
+
+		if permErr := tx.VolumePermissions(ctx, &vol); permErr != nil {
+			return permErr
+		}
+
+		if delErr := tx.DeleteVolume(ctx, &vol.Volume); delErr != nil {
+			return delErr
+		}
+
+		if vol.Namespace != nil {
+			if delErr := s.clients.Volume.DeleteNamespaceVolume(ctx, vol.Namespace.KubeName, vol.Label); delErr != nil {
+				return delErr
+			}
+		}
+
+		if unsubErr := s.clients.Billing.Unsubscribe(ctx, vol.ID); unsubErr != nil {
+			return unsubErr
+		}
+
+		users := map[string]bool{vol.OwnerUserID: true}
+		for _, v := range vol.Permissions {
+			users[v.UserID] = true
+		}
+
+		for user := range users {
+			if updErr := updateUserAccesses(ctx, s.clients.Auth, tx, user); updErr != nil {
+				return updErr
+			}
+		}
+
+		if auditErr := auditResourceChange(ctx, tx, "DeleteVolume", model.ResourceVolume, vol.ID, volumeState(vol.Volume), nil); auditErr != nil {
+			return auditErr
+		}
+
+		return nil
+	})
+
+	return err
+}
@@ -0,0 +1,407 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"git.containerum.net/ch/permissions/pkg/clients"
+	"git.containerum.net/ch/permissions/pkg/database"
+	"git.containerum.net/ch/permissions/pkg/dryrun"
+	"git.containerum.net/ch/permissions/pkg/errors"
+	"git.containerum.net/ch/permissions/pkg/model"
+	"github.com/containerum/utils/httputil"
+	"github.com/go-pg/pg/orm"
+	"github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookActions interface {
+	CreateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionCreateRequest) (model.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id string) (model.WebhookSubscription, error)
+	UpdateWebhookSubscription(ctx context.Context, id string, req model.WebhookSubscriptionUpdateRequest) (model.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) error
+	GetWebhookDeliveries(ctx context.Context, id string, page, perPage int) ([]model.WebhookDelivery, error)
+	PingWebhookSubscription(ctx context.Context, id string) (model.WebhookDelivery, error)
+}
+
+func checkWebhookEventTypes(eventTypes []model.EventType) error {
+	for _, v := range eventTypes {
+		if !v.IsValid() {
+			return errors.ErrRequestValidationFailed().AddDetailF("invalid event type %s", v)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// webhookSubscriptionOwnerCheck returns error if user is not owner of subscription and not admin.
+// Not found error is returned to not reveal subscriptions of other users.
+func webhookSubscriptionOwnerCheck(ctx context.Context, sub model.WebhookSubscription) error {
+	if IsAdminRole(ctx) || sub.OwnerUserID == httputil.MustGetUserID(ctx) {
+		return nil
+	}
+	return errors.ErrResourceNotExists().AddDetailF("webhook subscription %s not exists", sub.ID)
+}
+
+// webhookSubscriptionState returns audit state for webhook subscription, secret is not recorded.
+func webhookSubscriptionState(sub model.WebhookSubscription) model.AuditState {
+	state := model.AuditState{"url": sub.URL, "event_types": sub.EventTypes}
+	if sub.NamespaceID != nil {
+		state["namespace_id"] = *sub.NamespaceID
+	}
+	return state
+}
+
+// webhookClaimTimeout is a period while claimed webhook deliveries are not taken by other service instances
+const webhookClaimTimeout = 10 * time.Minute
+
+// checkWebhookURL rejects urls of service itself and cluster internal services.
+func checkWebhookURL(ctx context.Context, url string) error {
+	if err := clients.CheckWebhookURL(ctx, url); err != nil {
+		return errors.ErrRequestValidationFailed().AddDetailF("webhook url %s is not allowed: %v", url, err)
+	}
+	return nil
+}
+
+// enqueueWebhookDeliveries adds deliveries of event to subscriptions accepting it.
+// Only namespace events are delivered to webhooks.
+func enqueueWebhookDeliveries(ctx context.Context, tx database.DB, event model.Event) error {
+	if event.ResourceType != model.ResourceNamespace {
+		return nil
+	}
+
+	subs, err := tx.NamespaceWebhookSubscriptions(ctx, event.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []model.WebhookDelivery
+	for i := range subs {
+		if !subs[i].Accepts(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: subs[i].ID,
+			EventType:      event.Type,
+			Event:          &event,
+			Status:         model.WebhookDeliveryPending,
+		})
+	}
+
+	return tx.AddWebhookDeliveries(ctx, deliveries)
+}
+
+func (s *Server) CreateWebhookSubscription(ctx context.Context, req model.WebhookSubscriptionCreateRequest) (model.WebhookSubscription, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithFields(logrus.Fields{
+		"user_id":     userID,
+		"namespace":   req.Namespace,
+		"url":         req.URL,
+		"event_types": req.EventTypes,
+	}).Infof("create webhook subscription")
+
+	if chkErr := checkWebhookEventTypes(req.EventTypes); chkErr != nil {
+		return model.WebhookSubscription{}, chkErr
+	}
+
+	if chkErr := checkWebhookURL(ctx, req.URL); chkErr != nil {
+		return model.WebhookSubscription{}, chkErr
+	}
+
+	sub := model.WebhookSubscription{
+		OwnerUserID: userID,
+		URL:         req.URL,
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []model.EventType{}
+	}
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return model.WebhookSubscription{}, errors.ErrInternal().Log(err, s.log)
+		}
+		sub.Secret = secret
+	}
+
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		if req.Namespace != "" {
+			ns, getErr := tx.NamespaceByName(ctx, userID, req.Namespace, IsAdminRole(ctx))
+			if getErr != nil {
+				return getErr
+			}
+
+			if chkErr := OwnerCheck(ctx, ns.Resource); chkErr != nil {
+				return chkErr
+			}
+			sub.NamespaceID = &ns.ID
+			// events are delivered to subscriptions of current namespace owner only, admin subscribes on behalf of owner
+			sub.OwnerUserID = ns.OwnerUserID
+		}
+
+		if createErr := tx.CreateWebhookSubscription(ctx, &sub); createErr != nil {
+			return createErr
+		}
+
+		return auditResourceChange(ctx, tx, "CreateWebhookSubscription", model.AuditResourceWebhook, sub.ID, nil, webhookSubscriptionState(sub))
+	})
+
+	return sub, err
+}
+
+func (s *Server) GetWebhookSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	userID := httputil.MustGetUserID(ctx)
+	s.log.WithField("user_id", userID).Infof("get webhook subscriptions")
+
+	subs, err := s.db.UserWebhookSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	return subs, nil
+}
+
+func (s *Server) GetWebhookSubscription(ctx context.Context, id string) (model.WebhookSubscription, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+	}).Infof("get webhook subscription")
+
+	sub, err := s.db.WebhookSubscriptionByID(ctx, id)
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	if chkErr := webhookSubscriptionOwnerCheck(ctx, sub); chkErr != nil {
+		return model.WebhookSubscription{}, chkErr
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+func (s *Server) UpdateWebhookSubscription(ctx context.Context, id string, req model.WebhookSubscriptionUpdateRequest) (model.WebhookSubscription, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":     httputil.MustGetUserID(ctx),
+		"id":          id,
+		"event_types": req.EventTypes,
+	}).Infof("update webhook subscription")
+
+	if chkErr := checkWebhookEventTypes(req.EventTypes); chkErr != nil {
+		return model.WebhookSubscription{}, chkErr
+	}
+
+	if req.URL != nil {
+		if chkErr := checkWebhookURL(ctx, *req.URL); chkErr != nil {
+			return model.WebhookSubscription{}, chkErr
+		}
+	}
+
+	var sub model.WebhookSubscription
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		var getErr error
+		sub, getErr = tx.WebhookSubscriptionByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := webhookSubscriptionOwnerCheck(ctx, sub); chkErr != nil {
+			return chkErr
+		}
+
+		before := webhookSubscriptionState(sub)
+		if req.URL != nil {
+			sub.URL = *req.URL
+		}
+		if req.Secret != nil {
+			sub.Secret = *req.Secret
+		}
+		if req.EventTypes != nil {
+			sub.EventTypes = req.EventTypes
+		}
+
+		if updErr := tx.UpdateWebhookSubscription(ctx, &sub); updErr != nil {
+			return updErr
+		}
+
+		return auditResourceChange(ctx, tx, "UpdateWebhookSubscription", model.AuditResourceWebhook, sub.ID, before, webhookSubscriptionState(sub))
+	})
+
+	sub.Secret = ""
+	return sub, err
+}
+
+func (s *Server) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+	}).Infof("delete webhook subscription")
+
+	return s.db.Transactional(ctx, func(tx database.DB) error {
+		sub, getErr := tx.WebhookSubscriptionByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := webhookSubscriptionOwnerCheck(ctx, sub); chkErr != nil {
+			return chkErr
+		}
+
+		if delErr := tx.DeleteWebhookSubscription(ctx, sub); delErr != nil {
+			return delErr
+		}
+
+		return auditResourceChange(ctx, tx, "DeleteWebhookSubscription", model.AuditResourceWebhook, sub.ID, webhookSubscriptionState(sub), nil)
+	})
+}
+
+func (s *Server) GetWebhookDeliveries(ctx context.Context, id string, page, perPage int) ([]model.WebhookDelivery, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id":  httputil.MustGetUserID(ctx),
+		"id":       id,
+		"page":     page,
+		"per_page": perPage,
+	}).Infof("get webhook deliveries")
+
+	sub, err := s.db.WebhookSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if chkErr := webhookSubscriptionOwnerCheck(ctx, sub); chkErr != nil {
+		return nil, chkErr
+	}
+
+	pager := orm.Pager{Limit: perPage}
+	pager.SetPage(page)
+
+	return s.db.WebhookDeliveries(ctx, sub.ID, pager)
+}
+
+func (s *Server) PingWebhookSubscription(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	s.log.WithFields(logrus.Fields{
+		"user_id": httputil.MustGetUserID(ctx),
+		"id":      id,
+	}).Infof("ping webhook subscription")
+
+	var delivery model.WebhookDelivery
+	err := s.db.Transactional(ctx, func(tx database.DB) error {
+		sub, getErr := tx.WebhookSubscriptionByID(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+
+		if chkErr := webhookSubscriptionOwnerCheck(ctx, sub); chkErr != nil {
+			return chkErr
+		}
+
+		event := model.Event{
+			CreateTime: time.Now(),
+			Type:       model.EventPing,
+			Payload:    model.EventPayload{"subscription_id": sub.ID},
+		}
+		event.RequestID, _ = ctx.Value(httputil.RequestIDContextKey).(string)
+
+		// ping is sent once, delivery is recorded to log with result
+		deliveries := []model.WebhookDelivery{{
+			SubscriptionID: sub.ID,
+			EventType:      model.EventPing,
+			Event:          &event,
+			Status:         model.WebhookDeliveryPending,
+			// not taken by delivery job while it is sent here
+			NextAttemptTime: time.Now().Add(webhookClaimTimeout),
+		}}
+		if addErr := tx.AddWebhookDeliveries(ctx, deliveries); addErr != nil {
+			return addErr
+		}
+		delivery = deliveries[0]
+		delivery.Subscription = &sub
+		return nil
+	})
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	// subscriber is called outside of transaction
+	s.sendWebhookDelivery(ctx, &delivery)
+	if delivery.Status == model.WebhookDeliveryPending {
+		delivery.Status = model.WebhookDeliveryFailed
+	}
+
+	if dryrun.FromContext(ctx) != nil {
+		return delivery, nil
+	}
+	return delivery, s.db.UpdateWebhookDelivery(ctx, &delivery)
+}
+
+// DeliverWebhooks sends pending webhook deliveries which are ready for next attempt.
+// Deliveries are claimed in transaction and sent outside of it.
+func (s *Server) DeliverWebhooks(ctx context.Context) error {
+	for {
+		var deliveries []model.WebhookDelivery
+		err := s.db.Transactional(ctx, func(tx database.DB) (claimErr error) {
+			deliveries, claimErr = tx.ClaimPendingWebhookDeliveries(ctx, webhookClaimTimeout, s.cfg.EventsBatchSize)
+			return
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range deliveries {
+			s.sendWebhookDelivery(ctx, &deliveries[i])
+			if updErr := s.db.UpdateWebhookDelivery(ctx, &deliveries[i]); updErr != nil {
+				return updErr
+			}
+		}
+
+		if len(deliveries) < s.cfg.EventsBatchSize {
+			return nil
+		}
+	}
+}
+
+// sendWebhookDelivery makes attempt of delivery and updates its status.
+// Failed delivery stays pending with delayed next attempt until attempts limit is reached.
+func (s *Server) sendWebhookDelivery(ctx context.Context, delivery *model.WebhookDelivery) {
+	body, err := jsoniter.Marshal(delivery.Event)
+	if err == nil {
+		delivery.ResponseStatus, err = s.clients.Webhook.Send(ctx, delivery.Subscription.URL, delivery.Subscription.Secret, delivery.EventType, delivery.ID, body)
+	}
+	delivery.Attempts++
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveryTime = &now
+		delivery.LastError = ""
+		return
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"id":              delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+		"attempts":        delivery.Attempts,
+	}).WithError(err).Warnf("webhook delivery failed")
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.cfg.WebhookMaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptTime = now.Add(retryDelay(s.cfg.WebhookRetryBackoff, delivery.Attempts))
+}